@@ -0,0 +1,342 @@
+package market
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Array is a dense matrix, for reading and writing Matrix Market array
+// format with a compact element type; e.g., an Array[float32] requires
+// half the storage of a Dense. Data holds the M×N elements in column
+// major order, as in the array format, such that the element at the
+// (zero-indexed) row i and column j is Data[i+j*M]. The full matrix is
+// held for symmetric, skew-symmetric and hermitian matrices.
+type Array[T Scalar] struct {
+	Object   string
+	Format   string
+	Field    string
+	Symmetry string
+	M, N     int // number of rows and columns
+	Data     []T
+}
+
+// NewArray initializes a new M×N Array matrix from data, which is in
+// column major order and is not copied. The field is real or complex,
+// per T.
+func NewArray[T Scalar](M, N int, data []T) *Array[T] {
+
+	field := mtxFieldReal
+	if isComplex[T]() {
+		field = mtxFieldComplex
+	}
+
+	return &Array[T]{
+		Object:   mtxObjectMatrix,
+		Format:   mtxFormatArray,
+		Field:    field,
+		Symmetry: mtxSymmetryGeneral,
+		M:        M,
+		N:        N,
+		Data:     data,
+	}
+}
+
+// Dims returns the number of rows and columns of the matrix.
+func (m *Array[T]) Dims() (int, int) { return m.M, m.N }
+
+// At returns the element at the (zero-indexed) row i and column j.
+func (m *Array[T]) At(i, j int) T { return m.Data[i+j*m.M] }
+
+// Do calls fn for each element, in column major order.
+func (m *Array[T]) Do(fn func(i, j int, v T)) {
+	for j := 0; j < m.N; j++ {
+		for i := 0; i < m.M; i++ {
+			fn(i, j, m.Data[i+j*m.M])
+		}
+	}
+}
+
+// MarshalText serializes the receiver to []byte in Matrix Market format
+// and returns the result.
+func (m *Array[T]) MarshalText() ([]byte, error) {
+
+	var b strings.Builder
+
+	if _, err := m.MarshalTextTo(&b); err != nil {
+		return nil, err
+	}
+
+	return []byte(b.String()), nil
+}
+
+// MarshalTextTo serializes the receiver to w in Matrix Market format,
+// as configured by opts, and returns the result. Values are written with
+// the precision of T.
+func (m *Array[T]) MarshalTextTo(w io.Writer, opts ...WriteOption) (int, error) {
+
+	var total int
+
+	o := newWriteOptions(opts)
+
+	t := mmType{m.Object, m.Format, m.Field, m.Symmetry}
+	t.canonicalize()
+
+	if t.Symmetry == SymmetryAuto {
+		t.Symmetry = detectSymmetry(m.M, m.N, entryPairs(m.Do), o.tolerance)
+	}
+
+	if !(t.isMatrix() && t.isArray() && hasField[T](&t)) {
+		return total, ErrUnsupportedType
+	}
+
+	if n, err := w.Write(t.Bytes()); err == nil {
+		total += n
+	} else {
+		return total, ErrUnwritable
+	}
+
+	if n, err := fmt.Fprintf(w, o.sizeLine(false), m.M, m.N); err == nil {
+		total += n
+	} else {
+		return total, ErrUnwritable
+	}
+
+	// real values are fit as the real part of a complex value, of twice
+	// the size
+	var (
+		a    cmplxAligner
+		bits = bitSize[T]()
+	)
+	if !isComplex[T]() {
+		bits *= 2
+	}
+	fit := a.Fit('f', -1, bits)
+	if !o.compact {
+		m.Do(stored(t.Symmetry, func(i, j int, v T) {
+			fit(i, j, widen(v))
+		}))
+	}
+
+	// entries in column major order
+	var buf = make([]byte, 0, 64)
+	for j := 0; j < m.N; j++ {
+
+		for i := 0; i < m.M; i++ {
+
+			// only the lower triangle of a symmetric matrix is written
+			if !isStored(t.Symmetry, i, j) {
+				continue
+			}
+
+			switch {
+			case o.compact:
+				buf = appendCompactValue(buf[:0], m.At(i, j), &t)
+			case isComplex[T]():
+				buf = a.Append(buf[:0], widen(m.At(i, j)), 'f', -1, bits)
+			default:
+				buf = a.r.Append(buf[:0], real(widen(m.At(i, j))), 'f', -1, bits/2)
+			}
+			buf = append(buf, '\n')
+
+			n, err := w.Write(buf)
+			if err != nil {
+				return total, ErrUnwritable
+			}
+
+			total += n
+		}
+	}
+
+	return total, nil
+}
+
+// UnmarshalText deserializes []byte from Matrix Market format into the
+// receiver.
+func (m *Array[T]) UnmarshalText(text []byte) error {
+
+	r := bytes.NewReader(text)
+
+	if _, err := m.UnmarshalTextFrom(r); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnmarshalTextFrom deserializes r from Matrix Market format into the
+// receiver, as configured by opts. The field of the file must be complex
+// if T is complex, or otherwise real or integer.
+func (m *Array[T]) UnmarshalTextFrom(r io.Reader, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	scanner := newScanner(r, o)
+
+	// read header
+	t, err := scanHeader(scanner, o)
+	if err != nil {
+		return n.total, err
+	}
+
+	if !(t.isArray() && hasField[T](t)) {
+		return n.total, ErrUnsupportedType
+	}
+
+	// apply header fields
+	m.Object = t.Object
+	m.Format = t.Format
+	m.Field = t.Field
+	m.Symmetry = o.xform.symmetry(t.Symmetry)
+
+	if err := m.scanArrayData(scanner, t, o); err != nil {
+		return n.total, err
+	}
+
+	if err := scanError(scanner.Scanner); err != nil {
+		return n.total, err
+	}
+
+	return n.total, nil
+}
+
+func (m *Array[T]) scanArrayData(scanner *lineScanner, t *mmType, o *readOptions) error {
+
+	M, N, _, err := scanSize(scanner, t, o)
+	if err != nil {
+		return err
+	}
+
+	vals, trunc, err := scanArrayValues[T](scanner, t, o, M, N)
+	if err != nil {
+		return err
+	}
+
+	Mx, Nx := o.xform.dims(M, N)
+
+	// the values of a truncated matrix do not bound its storage
+	if trunc != nil {
+		if err := o.checkDense(Mx, Nx); err != nil {
+			return err
+		}
+	}
+
+	d := make([]T, Mx*Nx)
+
+	// elements are set as transformed by any selection or transform
+	// options
+	setArrayValues(t, M, N, vals, func(i, j int, v T) {
+		if i, j, v, ok := apply(o.xform, i, j, v); ok {
+			d[i+j*Mx] = v
+		}
+	})
+
+	m.M, m.N, m.Data = Mx, Nx, d
+
+	if trunc != nil {
+		return trunc
+	}
+
+	return nil
+}
+
+// scanArrayValues reads the data section of an M×N matrix in array format,
+// returning its values as stored: in column major order, with only the
+// lower triangle stored for symmetric and hermitian matrices and only the
+// strictly lower triangle for skew-symmetric matrices. Values are
+// accumulated as read, such that storage is bounded by the input rather
+// than by the size line. If reading WithPartial and the input is
+// truncated, then the values read are returned with a *TruncatedError.
+func scanArrayValues[T Scalar](scanner *lineScanner, t *mmType, o *readOptions, M, N int) ([]T, *TruncatedError, error) {
+
+	var (
+		L     = storedEntries(t.Symmetry, M, N)
+		vals  = make([]T, 0, prealloc(L))
+		trunc *TruncatedError
+	)
+
+	// entries are v, excepting complex entries, which have both a real and
+	// imaginary part
+	n := 1
+	if t.isComplex() {
+		n = 2
+	}
+
+scan:
+	for j := 0; j < N; j++ {
+
+		for i := 0; i < M; i++ {
+
+			if !isStored(t.Symmetry, i, j) {
+				continue
+			}
+
+			toks, line, err := scanner.entry(n)
+			if trunc = scanner.truncation(o, err, len(vals), L); trunc != nil {
+				break scan
+			}
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			v, err := parseScalar[T](toks)
+			if err != nil {
+				return nil, nil, scanner.errorf(line, err)
+			}
+
+			if i == j && t.isHermitian() && imag(widen(v)) != 0 {
+				o.warnf(line, WarnHermitianDiagonal, "diagonal entry (%d, %d) of a hermitian matrix is not real", i+1, j+1)
+			}
+
+			vals = append(vals, v)
+		}
+	}
+
+	// error out if data exceed the expected number of entries
+	if trunc == nil {
+		if err := scanner.end(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return vals, trunc, nil
+}
+
+// setArrayValues calls set for each element of an M×N matrix given by the
+// values vals of its data section in array format, as returned by
+// scanArrayValues, including the elements mirroring those stored of a
+// matrix which is not general.
+func setArrayValues[T Scalar](t *mmType, M, N int, vals []T, set func(i, j int, v T)) {
+
+	var k int
+
+	for j := 0; j < N; j++ {
+
+		for i := 0; i < M; i++ {
+
+			if !isStored(t.Symmetry, i, j) {
+				continue
+			}
+
+			if k == len(vals) {
+				return
+			}
+
+			v := vals[k]
+			k++
+
+			// if off diagonal, set value for symm element
+			if i != j && !t.isGeneral() {
+				set(j, i, transpose(t.Symmetry, v))
+			}
+
+			set(i, j, v)
+		}
+	}
+}
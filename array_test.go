@@ -0,0 +1,143 @@
+package market
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestArrayUnmarshalTextFrom(t *testing.T) {
+
+	for _, k := range []int{10, 11, 12, 13, 14, 15} {
+
+		name := fmt.Sprintf("mmtype-%02d.mtx", k)
+
+		b, err := os.ReadFile(filepath.Join("testdata", name))
+		assert.Nil(t, err)
+
+		var want Dense
+		assert.Nil(t, want.UnmarshalText(b), name)
+
+		var a Array[float32]
+		assert.Nil(t, a.UnmarshalText(b), name)
+
+		got := mat.NewDense(a.M, a.N, nil)
+		a.Do(func(i, j int, v float32) {
+			got.Set(i, j, float64(v))
+		})
+
+		assert.True(t, mat.EqualApprox(got, want.ToMatrix(), 1e-6), name)
+	}
+
+	for _, k := range []int{16, 17, 18, 20} {
+
+		name := fmt.Sprintf("mmtype-%02d.mtx", k)
+
+		b, err := os.ReadFile(filepath.Join("testdata", name))
+		assert.Nil(t, err)
+
+		var want CDense
+		assert.Nil(t, want.UnmarshalText(b), name)
+
+		var a Array[complex64]
+		assert.Nil(t, a.UnmarshalText(b), name)
+
+		got := mat.NewCDense(a.M, a.N, nil)
+		a.Do(func(i, j int, v complex64) {
+			got.Set(i, j, complex128(v))
+		})
+
+		assert.True(t, mat.CEqualApprox(got, want.ToCMatrix(), 1e-6), name)
+	}
+
+	var a Array[float32]
+	_, err := a.UnmarshalTextFrom(strings.NewReader("%%MatrixMarket matrix coordinate real general\n1 1 1\n1 1 1\n"))
+	assert.Equal(t, ErrUnsupportedType, err)
+}
+
+func TestArrayMarshalTextTo(t *testing.T) {
+
+	a := NewArray(2, 2, []float32{1, 0.1, 0.1, 2})
+	a.Symmetry = SymmetryAuto
+
+	text, err := a.MarshalText()
+	assert.Nil(t, err)
+	assert.Equal(t, "%%MatrixMarket matrix array real symmetric\n%\n 2  2\n 1\n 0.1\n 2\n", string(text))
+
+	var b Array[float32]
+	assert.Nil(t, b.UnmarshalText(text))
+	assert.Equal(t, a.Data, b.Data)
+	assert.Equal(t, float32(0.1), b.At(0, 1))
+
+	z := NewArray(1, 2, []complex64{1 + 0.1i, -2})
+
+	text, err = z.MarshalText()
+	assert.Nil(t, err)
+	assert.Equal(t, "%%MatrixMarket matrix array complex general\n%\n 1  2\n 1  0.1\n-2  0\n", string(text))
+
+	var zz Array[complex64]
+	assert.Nil(t, zz.UnmarshalText(text))
+	assert.Equal(t, z.Data, zz.Data)
+}
+
+func TestArrayMarshalTextToDense(t *testing.T) {
+
+	// output at float64 precision matches that of Dense and CDense
+	for _, k := range []string{"mmtype-10.mtx", "mmtype-11.mtx", "mmtype-12.mtx"} {
+
+		b, err := os.ReadFile(filepath.Join("testdata", k))
+		assert.Nil(t, err)
+
+		var (
+			m Dense
+			a Array[float64]
+		)
+		assert.Nil(t, m.UnmarshalText(b))
+		assert.Nil(t, a.UnmarshalText(b))
+
+		want, err := m.MarshalText()
+		assert.Nil(t, err)
+
+		got, err := a.MarshalText()
+		assert.Nil(t, err)
+
+		assert.Equal(t, string(want), string(got), k)
+	}
+
+	for _, k := range []string{"mmtype-16.mtx", "mmtype-17.mtx", "mmtype-18.mtx"} {
+
+		b, err := os.ReadFile(filepath.Join("testdata", k))
+		assert.Nil(t, err)
+
+		var (
+			m CDense
+			a Array[complex128]
+		)
+		assert.Nil(t, m.UnmarshalText(b))
+		assert.Nil(t, a.UnmarshalText(b))
+
+		want, err := m.MarshalText()
+		assert.Nil(t, err)
+
+		got, err := a.MarshalText()
+		assert.Nil(t, err)
+
+		assert.Equal(t, string(want), string(got), k)
+	}
+}
+
+func TestArrayMarshalTextToCompact(t *testing.T) {
+
+	a := NewArray(2, 2, []float32{0.1, 2, 2, 3})
+	a.Symmetry = "symmetric"
+
+	var b strings.Builder
+	_, err := a.MarshalTextTo(&b, WriteCompact())
+	assert.Nil(t, err)
+	assert.Equal(t, "%%MatrixMarket matrix array real symmetric\n%\n2 2\n0.1\n2\n3\n", b.String())
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2021 William Muir. All rights reserved.
+// The use of this source code is governed by the MIT License that can be found
+// in the LICENSE file.
+
+// Command mtx-lint checks Matrix Market files against the specification,
+// without loading them, and prints each violation found.
+//
+// Usage:
+//
+//	mtx-lint [-q] [-d] [file ...]
+//
+// With no files, standard input is checked. Duplicate entries are reported
+// only given -d, as detecting them requires memory in proportion to the
+// number of entries. Each violation is printed as
+// file:line: kind: message. The exit status is 0 if every file is valid,
+// 1 if any violation is found and 2 if a file could not be read.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	market "github.com/wamuir/matrix-market"
+)
+
+func main() {
+
+	quiet := flag.Bool("q", false, "print only a summary line for each file")
+	dups := flag.Bool("d", false, "report duplicate entries")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: mtx-lint [-q] [-d] [file ...]\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	var opts []market.ValidateOption
+	if *dups {
+		opts = append(opts, market.ValidateDuplicates())
+	}
+
+	os.Exit(run(flag.Args(), *quiet, opts, os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run lints the named files, or stdin if there are none, as configured
+// by opts, returning the exit status.
+func run(names []string, quiet bool, opts []market.ValidateOption, stdin io.Reader, stdout, stderr io.Writer) int {
+
+	if len(names) == 0 {
+		return lint("<stdin>", stdin, quiet, opts, stdout, stderr)
+	}
+
+	var status int
+
+	for _, name := range names {
+
+		f, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "mtx-lint: %v\n", err)
+			status = 2
+			continue
+		}
+
+		if s := lint(name, f, quiet, opts, stdout, stderr); s > status {
+			status = s
+		}
+
+		f.Close()
+	}
+
+	return status
+}
+
+// lint validates r as configured by opts, printing its violations, and
+// returns the exit status.
+func lint(name string, r io.Reader, quiet bool, opts []market.ValidateOption, stdout, stderr io.Writer) int {
+
+	rep, err := market.Validate(r, opts...)
+	if err != nil {
+		fmt.Fprintf(stderr, "mtx-lint: %s: %v\n", name, err)
+		return 2
+	}
+
+	if !quiet {
+		for _, v := range rep.Violations {
+			fmt.Fprintf(stdout, "%s:%d: %s: %s\n", name, v.Line, v.Kind, v.Msg)
+		}
+		if n := rep.Total - len(rep.Violations); n > 0 {
+			fmt.Fprintf(stdout, "%s: %d further violations not shown\n", name, n)
+		}
+	}
+
+	if rep.Valid() {
+		if quiet {
+			fmt.Fprintf(stdout, "%s: ok\n", name)
+		}
+		return 0
+	}
+
+	if quiet {
+		fmt.Fprintf(stdout, "%s: %d violations\n", name, rep.Total)
+	}
+
+	return 1
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	market "github.com/wamuir/matrix-market"
+)
+
+func TestRun(t *testing.T) {
+
+	var stdout, stderr strings.Builder
+
+	text := "%%MatrixMarket matrix coordinate real general\n2 2 2\n1 1 1\n3 1 1\n"
+
+	status := run(nil, false, nil, strings.NewReader(text), &stdout, &stderr)
+	assert.Equal(t, 1, status)
+	assert.Equal(t, "<stdin>:4: index-range: entry (3, 1) outside 2×2 matrix\n", stdout.String())
+	assert.Empty(t, stderr.String())
+
+	// duplicates are reported only if configured
+	stdout.Reset()
+
+	text = "%%MatrixMarket matrix coordinate real general\n2 2 2\n1 1 1\n1 1 1\n"
+
+	status = run(nil, false, nil, strings.NewReader(text), &stdout, &stderr)
+	assert.Equal(t, 0, status)
+
+	status = run(nil, false, []market.ValidateOption{market.ValidateDuplicates()}, strings.NewReader(text), &stdout, &stderr)
+	assert.Equal(t, 1, status)
+	assert.Equal(t, "<stdin>:4: duplicate: duplicate entry at (1, 1)\n", stdout.String())
+
+	// a line longer than the scanner buffer is a violation rather than a
+	// read error
+	stdout.Reset()
+
+	text = "%%MatrixMarket matrix coordinate real general\n2 2 1\n1 1 " + strings.Repeat("1", 1<<17) + "\n"
+
+	status = run(nil, false, nil, strings.NewReader(text), &stdout, &stderr)
+	assert.Equal(t, 1, status)
+	assert.Equal(t, "<stdin>:3: number: line exceeds 65536 bytes\n", stdout.String())
+	assert.Empty(t, stderr.String())
+
+	// a size overflowing int is a violation rather than a read error
+	stdout.Reset()
+
+	text = "%%MatrixMarket matrix coordinate real general\n9223372036854775807 2 1\n"
+
+	status = run(nil, true, nil, strings.NewReader(text), &stdout, &stderr)
+	assert.Equal(t, 1, status)
+	assert.Empty(t, stderr.String())
+
+	stdout.Reset()
+
+	name := filepath.Join("..", "..", "testdata", "mmtype-01.mtx")
+
+	status = run([]string{name}, true, nil, nil, &stdout, &stderr)
+	assert.Equal(t, 0, status)
+	assert.Equal(t, name+": ok\n", stdout.String())
+
+	status = run([]string{name, "nonexistent.mtx"}, true, nil, nil, &stdout, &stderr)
+	assert.Equal(t, 2, status)
+	assert.Contains(t, stderr.String(), "nonexistent.mtx")
+}
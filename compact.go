@@ -0,0 +1,62 @@
+package market
+
+import "strconv"
+
+// appendCompact appends v to dst in the compact number format, as the
+// shortest representation which reads back as v, without an exponent if
+// integer is true. Negative zero is appended as zero.
+func appendCompact(dst []byte, v float64, integer bool, bitSize int) []byte {
+
+	// negative zero compares equal to zero
+	if v == 0 {
+		v = 0
+	}
+
+	if integer {
+		return strconv.AppendFloat(dst, v, 'f', -1, bitSize)
+	}
+
+	return strconv.AppendFloat(dst, v, 'g', -1, bitSize)
+}
+
+// appendCompactValue appends v to dst in the compact number format, per
+// the field of t: both the real and imaginary parts for a complex field,
+// and otherwise the real part only.
+func appendCompactValue[T Scalar](dst []byte, v T, t *mmType) []byte {
+
+	w := widen(v)
+
+	bits := bitSize[T]()
+	if isComplex[T]() {
+		bits /= 2
+	}
+
+	dst = appendCompact(dst, real(w), t.isInteger(), bits)
+
+	if t.isComplex() {
+		dst = append(dst, ' ')
+		dst = appendCompact(dst, imag(w), false, bits)
+	}
+
+	return dst
+}
+
+// appendCompactIndex appends the one-indexed indices of the (zero-indexed)
+// entry at (i, j) to dst, separated by a single space.
+func appendCompactIndex(dst []byte, i, j int) []byte {
+	dst = strconv.AppendInt(dst, int64(i+1), 10)
+	dst = append(dst, ' ')
+	return strconv.AppendInt(dst, int64(j+1), 10)
+}
+
+// appendCompactIndices appends the indices idx of an entry of a tensor to
+// dst, offset by base and separated by single spaces.
+func appendCompactIndices(dst []byte, idx []int, base int) []byte {
+	for k, i := range idx {
+		if k > 0 {
+			dst = append(dst, ' ')
+		}
+		dst = strconv.AppendInt(dst, int64(i+base), 10)
+	}
+	return dst
+}
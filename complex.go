@@ -1,7 +1,6 @@
 package market
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
 	"io"
@@ -62,13 +61,20 @@ func (m *CDense) MarshalText() ([]byte, error) {
 	return []byte(b.String()), nil
 }
 
-// MarshalTextTo serializes the receiver to w in Matrix Market format
-// and returns the result.
-func (m *CDense) MarshalTextTo(w io.Writer) (int, error) {
+// MarshalTextTo serializes the receiver to w in Matrix Market format,
+// as configured by opts, and returns the result.
+func (m *CDense) MarshalTextTo(w io.Writer, opts ...WriteOption) (int, error) {
 
 	var total int
 
+	o := newWriteOptions(opts)
+
 	t := mmType{m.Object, m.Format, m.Field, m.Symmetry}
+	t.canonicalize()
+
+	if t.Symmetry == SymmetryAuto {
+		t.Symmetry = DetectCSymmetry(m.mat, o.tolerance)
+	}
 
 	if n, err := w.Write(t.Bytes()); err == nil {
 		total += n
@@ -77,14 +83,16 @@ func (m *CDense) MarshalTextTo(w io.Writer) (int, error) {
 	}
 
 	M, N := m.mat.Dims()
-	if n, err := fmt.Fprintf(w, "%%\n %d  %d\n", M, N); err == nil {
+	if n, err := fmt.Fprintf(w, o.sizeLine(false), M, N); err == nil {
 		total += n
 	} else {
 		return total, ErrUnwritable
 	}
 
 	var a cmplxAligner
-	m.Do(a.Fit('f', -1, 128))
+	if !o.compact {
+		m.Do(stored(t.Symmetry, a.Fit('f', -1, 128)))
+	}
 
 	// entries in column major order
 	var buf = make([]byte, 0, 128)
@@ -92,7 +100,16 @@ func (m *CDense) MarshalTextTo(w io.Writer) (int, error) {
 
 		for i := 0; i < M; i++ {
 
-			buf = a.Append(buf[:0], m.mat.At(i, j), 'f', -1, 128)
+			// only the lower triangle of a symmetric matrix is written
+			if !isStored(t.Symmetry, i, j) {
+				continue
+			}
+
+			if o.compact {
+				buf = appendCompactValue(buf[:0], m.mat.At(i, j), &t)
+			} else {
+				buf = a.Append(buf[:0], m.mat.At(i, j), 'f', -1, 128)
+			}
 			buf = append(buf, '\n')
 
 			n, err := w.Write(buf)
@@ -121,19 +138,19 @@ func (m *CDense) UnmarshalText(text []byte) error {
 }
 
 // UnmarshalTextFrom deserializes r from Matrix Market format
-// into the receiver.
-func (m *CDense) UnmarshalTextFrom(r io.Reader) (int, error) {
+// into the receiver, as configured by opts.
+func (m *CDense) UnmarshalTextFrom(r io.Reader, opts ...ReadOption) (int, error) {
 
 	var n counter
 
+	o := newReadOptions(opts)
+
 	r = io.TeeReader(r, &n)
 
-	scanner := bufio.NewScanner(r)
-	buf := make([]byte, maxScanTokenSize)
-	scanner.Buffer(buf, maxScanTokenSize)
+	scanner := newScanner(r, o)
 
 	// read header
-	t, err := scanHeader(scanner)
+	t, err := scanHeader(scanner, o)
 	if err != nil {
 		return n.total, err
 	}
@@ -142,25 +159,25 @@ func (m *CDense) UnmarshalTextFrom(r io.Reader) (int, error) {
 	m.Object = t.Object
 	m.Format = t.Format
 	m.Field = t.Field
-	m.Symmetry = t.Symmetry
+	m.Symmetry = o.xform.symmetry(t.Symmetry)
 
 	switch t.index() {
 
 	case 7, 8, 9, 19:
-		if err := m.scanCoordinateData(scanner); err != nil {
+		if err := m.scanCoordinateData(scanner, t, o); err != nil {
 			return n.total, err
 		}
 
-		if err := scanner.Err(); err != nil {
+		if err := scanError(scanner.Scanner); err != nil {
 			return n.total, err
 		}
 
 	case 16, 17, 18, 20:
-		if err := m.scanArrayData(scanner); err != nil {
+		if err := m.scanArrayData(scanner, t, o); err != nil {
 			return n.total, err
 		}
 
-		if err := scanner.Err(); err != nil {
+		if err := scanError(scanner.Scanner); err != nil {
 			return n.total, err
 		}
 
@@ -172,201 +189,176 @@ func (m *CDense) UnmarshalTextFrom(r io.Reader) (int, error) {
 	return n.total, nil
 }
 
-func (m *CDense) scanArrayData(scanner *bufio.Scanner) error {
+func (m *CDense) scanArrayData(scanner *lineScanner, t *mmType, o *readOptions) error {
 
-	var M, N, k int
-
-	for scanner.Scan() {
-
-		line := scanner.Text()
-
-		// blank line or comment (%, Unicode 37)
-		if r := []rune(line); len(r) == 0 || r[0] == 37 {
-			continue
-		}
-
-		if _, err := fmt.Sscanf(line, "%d %d", &M, &N); err != nil {
-			return ErrInputScanError
-		}
-
-		break
+	M, N, _, err := scanSize(scanner, t, o)
+	if err != nil {
+		return err
 	}
 
-	d := mat.NewCDense(M, N, nil)
-
-	for scanner.Scan() {
-
-		var vr, vi float64
+	// dense storage cannot be allocated for an empty matrix
+	Mx, Nx := o.xform.dims(M, N)
+	if Mx == 0 || Nx == 0 {
+		return scanner.errorf(scanner.line, ErrInvalidSize)
+	}
 
-		line := scanner.Text()
+	vals, trunc, err := scanArrayValues[complex128](scanner, t, o, M, N)
+	if err != nil {
+		return err
+	}
 
-		// blank lines are allowed in data per design spec
-		if r := []rune(line); len(r) == 0 {
-			continue
+	// the values of a truncated matrix do not bound its storage
+	if trunc != nil {
+		if err := o.checkDense(Mx, Nx); err != nil {
+			return err
 		}
+	}
 
-		// error out if data rows exceed expected non-zero entries
-		// (note that k is zero indexed)
-		if k == M*N {
-			return ErrInputScanError
-		}
+	d := o.newCDense(Mx, Nx)
 
-		if _, err := fmt.Sscanf(line, "%f %f", &vr, &vi); err != nil {
-			return ErrInputScanError
+	// elements are set as transformed by any selection or transform
+	// options
+	setArrayValues(t, M, N, vals, func(i, j int, v complex128) {
+		if i, j, v, ok := apply(o.xform, i, j, v); ok {
+			d.Set(i, j, v)
 		}
+	})
 
-		switch m.Symmetry {
-
-		case mtxSymmetrySymm:
-
-			// if above diagonal, move to diag
-			for k%M < int(k/M) {
-				k++
-			}
-
-			// if off diagonal, set value for symm element
-			if int(k/M) != k%M {
-				d.Set(int(k/M), k%M, complex(vr, vi))
-			}
-
-		case mtxSymmetrySkew:
-
-			// if on or above diagonal, move below diag
-			for k%M <= int(k/M) {
-				k++
-			}
-
-			// set skew value for symm element
-			d.Set(int(k/M), k%M, -complex(vr, vi))
-
-		case mtxSymmetryHermitian:
+	m.mat = d
 
-			// if above diagonal, move to diag
-			for k%M < int(k/M) {
-				k++
-			}
+	if trunc != nil {
+		return trunc
+	}
 
-			// if off diagonal, set value for symm element
-			if int(k/M) != k%M {
-				d.Set(int(k/M), k%M, complex(vr, -vi))
-			}
-		}
+	return nil
+}
 
-		d.Set(k%M, int(k/M), complex(vr, vi))
-		k++
-	}
+func (m *CDense) scanCoordinateData(scanner *lineScanner, t *mmType, o *readOptions) error {
 
-	// as skew-symmetric entries are below the diagonal in the matrix
-	// market specification, there are no entries in the last column. The
-	// counter k must must be advanced by one full column for
-	// skew-symmetric matrices prior to the check which follows, otherwise
-	// k == M * (N - 1) and the check will fail.
-	if m.Symmetry == mtxSymmetrySkew {
-		k += M
+	M, N, L, err := scanSize(scanner, t, o)
+	if err != nil {
+		return err
 	}
 
-	// compare counter k against expected number of entries (matrix size)
-	if k != M*N {
-		return ErrInputScanError
+	// coordinate data are stored densely, such that storage is bounded
+	// by the number of matrix elements rather than by L
+	if err := o.checkDense(M, N); err != nil {
+		return err
 	}
 
-	if err := scanner.Err(); err != nil {
-		return ErrInputScanError
+	// dense storage cannot be allocated for an empty matrix
+	Mx, Nx := o.xform.dims(M, N)
+	if Mx == 0 || Nx == 0 {
+		return scanner.errorf(scanner.line, ErrInvalidSize)
 	}
 
-	m.mat = d
-
-	return nil
-}
-
-func (m *CDense) scanCoordinateData(scanner *bufio.Scanner) error {
+	d := newCDenseEntries(Mx, Nx, o.duplicates, o.warn != nil)
+	d.x = o.xform
 
-	var M, N, L, k int
+	var trunc *TruncatedError
 
-	for scanner.Scan() {
+	for k := 0; k < L; k++ {
 
-		line := scanner.Text()
+		var (
+			i, j   int
+			vr, vi float64
+		)
 
-		// blank line or comment (%, Unicode 37)
-		if r := []rune(line); len(r) == 0 || r[0] == 37 {
-			continue
+		toks, line, err := scanner.entry(4)
+		if trunc = scanner.truncation(o, err, k, L); trunc != nil {
+			break
 		}
 
-		if _, err := fmt.Sscanf(line, "%d %d %d", &M, &N, &L); err != nil {
-			return ErrInputScanError
+		if err != nil {
+			return err
 		}
 
-		break
-
-	}
+		if i, err = parseInt(toks[0]); err != nil {
+			return scanner.errorf(line, err)
+		}
 
-	d := mat.NewCDense(M, N, nil)
+		if j, err = parseInt(toks[1]); err != nil {
+			return scanner.errorf(line, err)
+		}
 
-	for scanner.Scan() {
+		if vr, err = parseFloat(toks[2]); err != nil {
+			return scanner.errorf(line, err)
+		}
 
-		var (
-			i, j   int
-			vr, vi float64
-		)
+		if vi, err = parseFloat(toks[3]); err != nil {
+			return scanner.errorf(line, err)
+		}
 
-		line := scanner.Text()
+		if !inBounds(i, j, M, N) {
+			return scanner.errorf(line, ErrIndexOutOfRange)
+		}
 
-		// blank lines are allowed in data per design spec
-		if r := []rune(line); len(r) == 0 {
-			continue
+		if i < j && !t.isGeneral() {
+			o.warnf(line, WarnUpperTriangle, "entry (%d, %d) is above the diagonal of a %s matrix", i, j, t.Symmetry)
 		}
 
-		// error out if data rows exceed expected non-zero entries
-		// (note that k is zero indexed)
-		if k == L {
-			return ErrInputScanError
+		if vr == 0 && vi == 0 {
+			o.warnf(line, WarnExplicitZero, "explicit zero at (%d, %d)", i, j)
 		}
 
-		if _, err := fmt.Sscanf(line, "%d %d %f %f", &i, &j, &vr, &vi); err != nil {
-			return ErrInputScanError
+		if i == j && vi != 0 && t.isHermitian() {
+			o.warnf(line, WarnHermitianDiagonal, "diagonal entry (%d, %d) of a hermitian matrix is not real", i, j)
 		}
 
-		switch m.Symmetry {
+		var dup bool
+
+		switch {
 
-		case mtxSymmetrySymm:
+		case t.isSymmetric():
 
 			// if off diagonal, set value for symm element
 			if i != j {
-				d.Set(j-1, i-1, complex(vr, vi))
+				_, err = d.add(j-1, i-1, complex(vr, vi))
 			}
 
-		case mtxSymmetrySkew:
+		case t.isSkew():
 
 			// if off diagonal, set skew value for symm element
 			// (note. diagonal elements aren't allowed for skew mats)
 			if i != j {
-				d.Set(j-1, i-1, -complex(vr, vi))
+				_, err = d.add(j-1, i-1, -complex(vr, vi))
 			}
 
-		case mtxSymmetryHermitian:
+		case t.isHermitian():
 
 			// if off diagonal, set value for symm element
 			if i != j {
-				d.Set(j-1, i-1, complex(vr, -vi))
+				_, err = d.add(j-1, i-1, complex(vr, -vi))
 			}
+		}
 
+		if err == nil {
+			dup, err = d.add(i-1, j-1, complex(vr, vi))
 		}
 
-		d.Set(i-1, j-1, complex(vr, vi))
+		if err != nil {
+			return scanner.errorf(line, err)
+		}
 
-		k++
+		if dup {
+			o.warnf(line, WarnDuplicate, "duplicate entry at (%d, %d)", i, j)
+		}
 	}
 
-	// compare counter k against expected number of expected entries L
-	if k != L {
-		return ErrInputScanError
+	// error out if data exceed the expected number of entries
+	if trunc == nil {
+		if err := scanner.end(); err != nil {
+			return err
+		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return ErrInputScanError
+	if m.mat, err = d.dense(o); err != nil {
+		return err
 	}
 
-	m.mat = d
+	if trunc != nil {
+		return trunc
+	}
 
 	return nil
 }
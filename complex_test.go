@@ -215,6 +215,39 @@ func TestCDenseUnmarshalTextFrom(t *testing.T) {
 
 }
 
+func TestCDenseUnmarshalTextFromDuplicates(t *testing.T) {
+
+	text := `%%MatrixMarket matrix coordinate complex general
+ 1  2  3
+ 1  1  1  1
+ 1  2  2  0
+ 1  1  3 -1
+`
+
+	c := map[DuplicatePolicy]*mat.CDense{
+		DuplicateSum:       mat.NewCDense(1, 2, []complex128{4, 2}),
+		DuplicateKeepLast:  mat.NewCDense(1, 2, []complex128{3 - 1i, 2}),
+		DuplicateKeepFirst: mat.NewCDense(1, 2, []complex128{1 + 1i, 2}),
+	}
+
+	for p, v := range c {
+
+		var mm CDense
+		_, err := mm.UnmarshalTextFrom(strings.NewReader(text), WithDuplicates(p))
+		assert.Nil(t, err)
+		assert.True(t, mat.CEqual(mm.ToCMatrix(), v))
+	}
+
+	// without duplicates, no more entries are stored than elements
+	var mm CDense
+	_, err := mm.UnmarshalTextFrom(strings.NewReader(text), WithDuplicates(DuplicateError))
+	assert.ErrorIs(t, err, ErrInvalidSize)
+
+	text = strings.Replace(text, " 1  2  3", " 2  2  3", 1)
+	_, err = mm.UnmarshalTextFrom(strings.NewReader(text), WithDuplicates(DuplicateError))
+	assert.EqualError(t, err, "line 5: "+ErrDuplicateEntry.Error())
+}
+
 func BenchmarkCDenseMarshalTextTo(b *testing.B) {
 	for i := 1; i <= 1000; i *= 10 {
 		a := mat.NewCDense(i, i, nil)
@@ -250,3 +283,13 @@ func BenchmarkCDenseUnmarshalTextFrom(b *testing.B) {
 		})
 	}
 }
+
+func TestCDenseMarshalTextToCompact(t *testing.T) {
+
+	m := NewCDense(mat.NewCDense(1, 2, []complex128{1 - 2i, 3e10}))
+
+	var b strings.Builder
+	_, err := m.MarshalTextTo(&b, WriteCanonical())
+	assert.Nil(t, err)
+	assert.Equal(t, "%%MatrixMarket matrix array complex general\n%\n1 2\n1 -2\n3e+10 0\n", b.String())
+}
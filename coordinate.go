@@ -1,7 +1,6 @@
 package market
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
 	"io"
@@ -58,19 +57,71 @@ func (m *COO) MarshalText() ([]byte, error) {
 	return []byte(b.String()), nil
 }
 
-// MarshalTextTo serializes the receiver to w in Matrix Market format
-// and returns the result.
-func (m *COO) MarshalTextTo(w io.Writer) (int, error) {
+// MarshalTextTo serializes the receiver to w in Matrix Market format,
+// as configured by opts, and returns the result.
+func (m *COO) MarshalTextTo(w io.Writer, opts ...WriteOption) (int, error) {
 
 	var total int
 
+	o := newWriteOptions(opts)
+
 	t := mmType{m.Object, m.Format, m.Field, m.Symmetry}
+	t.canonicalize()
+
+	if t.Symmetry == SymmetryAuto {
+		t.Symmetry = DetectSymmetry(m.mat, o.tolerance)
+	}
 
 	// Need additional checks on mmType
 	if !(t.isMatrix() && t.isCoordinate()) {
 		return total, ErrUnsupportedType
 	}
 
+	src := m.Do
+	if o.duplicates != DuplicateSum || o.zeros == ZeroDrop || o.order != OrderUnsorted {
+
+		c := newTriplets[int, float64](m.mat.NNZ(), o.duplicates, false)
+
+		var err error
+		m.Do(func(i, j int, v float64) {
+			if err == nil {
+				_, err = c.add(i, j, v)
+			}
+		})
+		if err != nil {
+			return total, err
+		}
+
+		if o.order != OrderUnsorted {
+			c.sort(o.order)
+		}
+
+		if o.zeros == ZeroDrop {
+			c.dropZeros()
+		}
+
+		src = c.Do
+	}
+
+	// only the lower triangle of a symmetric matrix is written
+	do := func(fn func(i, j int, v float64)) {
+		src(stored(t.Symmetry, fn))
+	}
+
+	// entries are fit and counted prior to writing the header, which
+	// includes the number of entries
+	var (
+		a   floatTripletAligner
+		nnz int
+	)
+	fit := a.Fit('f', -1, 64)
+	do(func(i, j int, v float64) {
+		if !o.compact {
+			fit(i, j, v)
+		}
+		nnz++
+	})
+
 	if n, err := w.Write(t.Bytes()); err == nil {
 		total += n
 	} else {
@@ -78,23 +129,35 @@ func (m *COO) MarshalTextTo(w io.Writer) (int, error) {
 	}
 
 	M, N := m.mat.Dims()
-	if n, err := fmt.Fprintf(w, "%%\n %d  %d  %d\n", M, N, m.mat.NNZ()); err == nil {
+	if n, err := fmt.Fprintf(w, o.sizeLine(true), M, N, nnz); err == nil {
 		total += n
 	} else {
 		return total, ErrUnwritable
 	}
 
-	var a floatTripletAligner
-	m.Do(a.Fit('f', -1, 64))
-
 	// entries in column major order
 	var (
 		buf = make([]byte, 0, 64)
 		err error
 		n   int
 	)
-	m.mat.DoNonZero(func(i, j int, v float64) {
-		buf = a.Append(buf[:0], i, j, v, 'f', -1, 64)
+	do(func(i, j int, v float64) {
+		switch {
+
+		case o.compact:
+			buf = appendCompactIndex(buf[:0], i, j)
+			if !t.isPattern() {
+				buf = append(buf, ' ')
+				buf = appendCompactValue(buf, v, &t)
+			}
+
+		// pattern entries have no value
+		case t.isPattern():
+			buf = indexAligner{a.row, a.col}.Append(buf[:0], i, j)
+
+		default:
+			buf = a.Append(buf[:0], i, j, v, 'f', -1, 64)
+		}
 		buf = append(buf, '\n')
 
 		n, err = w.Write(buf)
@@ -118,19 +181,19 @@ func (m *COO) UnmarshalText(text []byte) error {
 }
 
 // UnmarshalTextFrom deserializes r from Matrix Market format into the
-// receiver.
-func (m *COO) UnmarshalTextFrom(r io.Reader) (int, error) {
+// receiver, as configured by opts.
+func (m *COO) UnmarshalTextFrom(r io.Reader, opts ...ReadOption) (int, error) {
 
 	var n counter
 
+	o := newReadOptions(opts)
+
 	r = io.TeeReader(r, &n)
 
-	scanner := bufio.NewScanner(r)
-	buf := make([]byte, maxScanTokenSize)
-	scanner.Buffer(buf, maxScanTokenSize)
+	scanner := newScanner(r, o)
 
 	// read header
-	t, err := scanHeader(scanner)
+	t, err := scanHeader(scanner, o)
 	if err != nil {
 		return n.total, err
 	}
@@ -139,16 +202,16 @@ func (m *COO) UnmarshalTextFrom(r io.Reader) (int, error) {
 	m.Object = t.Object
 	m.Format = t.Format
 	m.Field = t.Field
-	m.Symmetry = t.Symmetry
+	m.Symmetry = o.xform.symmetry(t.Symmetry)
 
 	switch t.index() {
 
 	case 1, 2, 3, 4, 5, 6, 21, 22:
-		if err := m.scanCoordinateData(scanner); err != nil {
+		if err := m.scanCoordinateData(scanner, t, o); err != nil {
 			return n.total, err
 		}
 
-		if err := scanner.Err(); err != nil {
+		if err := scanError(scanner.Scanner); err != nil {
 			return n.total, err
 		}
 
@@ -160,99 +223,21 @@ func (m *COO) UnmarshalTextFrom(r io.Reader) (int, error) {
 	return n.total, nil
 }
 
-func (m *COO) scanCoordinateData(scanner *bufio.Scanner) error {
-
-	var M, N, L, k int
-
-	for scanner.Scan() {
-
-		line := scanner.Text()
-
-		// blank line or comment (%, Unicode 37)
-		if r := []rune(line); len(r) == 0 || r[0] == 37 {
-			continue
-		}
-
-		if _, err := fmt.Sscanf(line, "%d %d %d", &M, &N, &L); err != nil {
-			return ErrInputScanError
-		}
+func (m *COO) scanCoordinateData(scanner *lineScanner, t *mmType, o *readOptions) error {
 
-		break
-
-	}
-
-	c := sparse.NewCOO(M, N, make([]int, L), make([]int, L), make([]float64, L))
-
-	for scanner.Scan() {
-
-		var (
-			i, j int
-			v    float64
-		)
-
-		line := scanner.Text()
-
-		// blank lines are allowed in data per design spec
-		if r := []rune(line); len(r) == 0 {
-			continue
-		}
-
-		// error out if data rows exceed expected non-zero entries
-		// (note that k is zero indexed)
-		if k == L {
-			return ErrInputScanError
-		}
-
-		switch m.Field {
-
-		case mtxFieldInteger, mtxFieldReal:
-
-			if _, err := fmt.Sscanf(line, "%d %d %f", &i, &j, &v); err != nil {
-				return err
-			}
-
-		case mtxFieldPattern:
-
-			v = 1.0
-
-			if _, err := fmt.Sscanf(line, "%d %d", &i, &j); err != nil {
-				return err
-			}
-		}
-
-		switch m.Symmetry {
-
-		case mtxSymmetrySymm:
-
-			// if off diagonal, set value for symm element
-			if i != j {
-				c.Set(j-1, i-1, v)
-			}
-
-		case mtxSymmetrySkew:
-
-			// if off diagonal, set skew value for symm element
-			// (note. diagonal elements aren't allowed for skew mats)
-			if i != j {
-				c.Set(j-1, i-1, -v)
-			}
-		}
-
-		c.Set(i-1, j-1, v)
-
-		k++
-	}
-
-	// compare counter k against expected number of expected entries L
-	if k != L {
-		return ErrInputScanError
+	M, N, c, err := scanTriplets(scanner, t, o, o.bufs.triplets(o.coo))
+	if c == nil {
+		return err
 	}
 
-	if err := scanner.Err(); err != nil {
-		return ErrInputScanError
+	d := sparse.NewCOO(M, N, c.rows, c.cols, c.data)
+	if o.coo != nil {
+		*o.coo = *d
+		d = o.coo
 	}
 
-	m.mat = c
+	o.bufs.keep(d, c)
+	m.mat = d
 
-	return nil
+	return err
 }
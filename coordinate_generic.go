@@ -0,0 +1,382 @@
+package market
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Coordinate is a sparse matrix in triplet form, for reading and writing
+// Matrix Market coordinate format with compact index and element types;
+// e.g., a Coordinate[int32, float32] requires half the storage of a COO.
+// Entry p is the element Data[p] at the (zero-indexed) row Rows[p] and
+// column Cols[p]. As for COO, both an entry and its transpose are held
+// for symmetric, skew-symmetric and hermitian matrices, and duplicate
+// entries are summed.
+type Coordinate[I Integer, T Scalar] struct {
+	Object   string
+	Format   string
+	Field    string
+	Symmetry string
+	M, N     int // number of rows and columns
+	Rows     []I
+	Cols     []I
+	Data     []T
+}
+
+// NewCoordinate initializes a new M×N Coordinate matrix from the
+// (zero-indexed) entries in rows, cols and data, which are not copied.
+// The field is real or complex, per T.
+func NewCoordinate[I Integer, T Scalar](M, N int, rows, cols []I, data []T) *Coordinate[I, T] {
+
+	field := mtxFieldReal
+	if isComplex[T]() {
+		field = mtxFieldComplex
+	}
+
+	return &Coordinate[I, T]{
+		Object:   mtxObjectMatrix,
+		Format:   mtxFormatCoordinate,
+		Field:    field,
+		Symmetry: mtxSymmetryGeneral,
+		M:        M,
+		N:        N,
+		Rows:     rows,
+		Cols:     cols,
+		Data:     data,
+	}
+}
+
+// Dims returns the number of rows and columns of the matrix.
+func (m *Coordinate[I, T]) Dims() (int, int) { return m.M, m.N }
+
+// Do calls fn for each stored entry.
+func (m *Coordinate[I, T]) Do(fn func(i, j int, v T)) {
+	for p, v := range m.Data {
+		fn(int(m.Rows[p]), int(m.Cols[p]), v)
+	}
+}
+
+// MarshalText serializes the receiver to []byte in Matrix Market format
+// and returns the result.
+func (m *Coordinate[I, T]) MarshalText() ([]byte, error) {
+
+	var b strings.Builder
+
+	if _, err := m.MarshalTextTo(&b); err != nil {
+		return nil, err
+	}
+
+	return []byte(b.String()), nil
+}
+
+// MarshalTextTo serializes the receiver to w in Matrix Market format,
+// as configured by opts, and returns the result. Values are written with
+// the precision of T.
+func (m *Coordinate[I, T]) MarshalTextTo(w io.Writer, opts ...WriteOption) (int, error) {
+
+	var total int
+
+	o := newWriteOptions(opts)
+
+	t := mmType{m.Object, m.Format, m.Field, m.Symmetry}
+	t.canonicalize()
+
+	if t.Symmetry == SymmetryAuto {
+		t.Symmetry = detectSymmetry(m.M, m.N, entryPairs(m.Do), o.tolerance)
+	}
+
+	if !(t.isMatrix() && t.isCoordinate() && hasField[T](&t)) {
+		return total, ErrUnsupportedType
+	}
+
+	src := m.Do
+	if o.duplicates != DuplicateSum || o.zeros == ZeroDrop || o.order != OrderUnsorted {
+
+		c := newTriplets[I, T](len(m.Data), o.duplicates, false)
+
+		var err error
+		m.Do(func(i, j int, v T) {
+			if err == nil {
+				_, err = c.add(i, j, v)
+			}
+		})
+		if err != nil {
+			return total, err
+		}
+
+		if o.order != OrderUnsorted {
+			c.sort(o.order)
+		}
+
+		if o.zeros == ZeroDrop {
+			c.dropZeros()
+		}
+
+		src = c.Do
+	}
+
+	// only the lower triangle of a symmetric matrix is written
+	do := func(fn func(i, j int, v T)) {
+		src(stored(t.Symmetry, fn))
+	}
+
+	// entries are fit and counted prior to writing the header, which
+	// includes the number of entries. Real values are fit as the real
+	// part of a complex value, of twice the size.
+	var (
+		a    cmplxTripletAligner
+		nnz  int
+		bits = bitSize[T]()
+	)
+	if !isComplex[T]() {
+		bits *= 2
+	}
+	fit := a.Fit('f', -1, bits)
+	do(func(i, j int, v T) {
+		if !o.compact {
+			fit(i, j, widen(v))
+		}
+		nnz++
+	})
+
+	if n, err := w.Write(t.Bytes()); err == nil {
+		total += n
+	} else {
+		return total, ErrUnwritable
+	}
+
+	if n, err := fmt.Fprintf(w, o.sizeLine(true), m.M, m.N, nnz); err == nil {
+		total += n
+	} else {
+		return total, ErrUnwritable
+	}
+
+	var (
+		buf = make([]byte, 0, 64)
+		err error
+		n   int
+	)
+	do(func(i, j int, v T) {
+		if err != nil {
+			return
+		}
+
+		switch {
+
+		case o.compact:
+			buf = appendCompactIndex(buf[:0], i, j)
+			if !t.isPattern() {
+				buf = append(buf, ' ')
+				buf = appendCompactValue(buf, v, &t)
+			}
+
+		// pattern entries have no value
+		case t.isPattern():
+			buf = indexAligner{a.row, a.col}.Append(buf[:0], i, j)
+
+		case isComplex[T]():
+			buf = a.Append(buf[:0], i, j, widen(v), 'f', -1, bits)
+
+		default:
+			r := floatTripletAligner{a.row, a.col, a.val.r}
+			buf = r.Append(buf[:0], i, j, real(widen(v)), 'f', -1, bits/2)
+		}
+		buf = append(buf, '\n')
+
+		n, err = w.Write(buf)
+		total += n
+	})
+	if err != nil {
+		return total, ErrUnwritable
+	}
+
+	return total, nil
+}
+
+// UnmarshalText deserializes []byte from Matrix Market format into the
+// receiver.
+func (m *Coordinate[I, T]) UnmarshalText(text []byte) error {
+
+	r := bytes.NewReader(text)
+
+	if _, err := m.UnmarshalTextFrom(r); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnmarshalTextFrom deserializes r from Matrix Market format into the
+// receiver, as configured by opts. The field of the file must be complex
+// if T is complex, or otherwise real, integer or pattern. An error
+// wrapping ErrIndexOverflow is returned if the dimensions of the matrix
+// are not representable by I.
+func (m *Coordinate[I, T]) UnmarshalTextFrom(r io.Reader, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	scanner := newScanner(r, o)
+
+	// read header
+	t, err := scanHeader(scanner, o)
+	if err != nil {
+		return n.total, err
+	}
+
+	if !(t.isCoordinate() && hasField[T](t)) {
+		return n.total, ErrUnsupportedType
+	}
+
+	// apply header fields
+	m.Object = t.Object
+	m.Format = t.Format
+	m.Field = t.Field
+	m.Symmetry = o.xform.symmetry(t.Symmetry)
+
+	M, N, c, err := scanTriplets[I, T](scanner, t, o, nil)
+	if c != nil {
+		m.M, m.N, m.Rows, m.Cols, m.Data = M, N, c.rows, c.cols, c.data
+	}
+	if err != nil {
+		return n.total, err
+	}
+
+	if err := scanError(scanner.Scanner); err != nil {
+		return n.total, err
+	}
+
+	return n.total, nil
+}
+
+// scanTriplets reads the size line and data section of a file in
+// coordinate format, returning the dimensions and entries of the matrix.
+// Entries are read into c, which is reset, or into new triplets if c is
+// nil. If reading with WithPartial and the input is truncated, then the
+// entries read are returned with a *TruncatedError.
+func scanTriplets[I Integer, T Scalar](scanner *lineScanner, t *mmType, o *readOptions, c *triplets[I, T]) (int, int, *triplets[I, T], error) {
+
+	M, N, L, err := scanSize(scanner, t, o)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	// indices are held as transformed by any selection or transform
+	// options
+	Mx, Nx := o.xform.dims(M, N)
+	if err := checkIndex[I](Mx, Nx); err != nil {
+		return 0, 0, nil, scanner.errorf(scanner.line, err)
+	}
+
+	if c == nil {
+		c = newTriplets[I, T](L, o.duplicates, o.warn != nil)
+	} else {
+		c.reset(L, o.duplicates, o.warn != nil)
+	}
+
+	c.x = o.xform
+	if t.isPattern() {
+		c.x = o.xform.indices()
+	}
+
+	var trunc *TruncatedError
+
+	// entries are i, j and v, excepting pattern entries, which have no v
+	// and complex entries, which have both a real and imaginary part
+	n := 3
+	switch {
+	case t.isPattern():
+		n = 2
+	case t.isComplex():
+		n = 4
+	}
+
+	for k := 0; k < L; k++ {
+
+		var (
+			i, j int
+			v    T = 1
+		)
+
+		toks, line, err := scanner.entry(n)
+		if trunc = scanner.truncation(o, err, k, L); trunc != nil {
+			break
+		}
+
+		if err != nil {
+			return 0, 0, nil, err
+		}
+
+		if i, err = parseInt(toks[0]); err != nil {
+			return 0, 0, nil, scanner.errorf(line, err)
+		}
+
+		if j, err = parseInt(toks[1]); err != nil {
+			return 0, 0, nil, scanner.errorf(line, err)
+		}
+
+		if n > 2 {
+			if v, err = parseScalar[T](toks[2:]); err != nil {
+				return 0, 0, nil, scanner.errorf(line, err)
+			}
+		}
+
+		if !inBounds(i, j, M, N) {
+			return 0, 0, nil, scanner.errorf(line, ErrIndexOutOfRange)
+		}
+
+		if i < j && !t.isGeneral() {
+			o.warnf(line, WarnUpperTriangle, "entry (%d, %d) is above the diagonal of a %s matrix", i, j, t.Symmetry)
+		}
+
+		if v == 0 {
+			o.warnf(line, WarnExplicitZero, "explicit zero at (%d, %d)", i, j)
+		}
+
+		if i == j && t.isHermitian() && imag(widen(v)) != 0 {
+			o.warnf(line, WarnHermitianDiagonal, "diagonal entry (%d, %d) of a hermitian matrix is not real", i, j)
+		}
+
+		var dup bool
+
+		// if off diagonal, set value for symm element (note. diagonal
+		// elements aren't allowed for skew mats)
+		if i != j && !t.isGeneral() {
+			_, err = c.add(j-1, i-1, transpose(t.Symmetry, v))
+		}
+
+		if err == nil {
+			dup, err = c.add(i-1, j-1, v)
+		}
+
+		if err != nil {
+			return 0, 0, nil, scanner.errorf(line, err)
+		}
+
+		if dup {
+			o.warnf(line, WarnDuplicate, "duplicate entry at (%d, %d)", i, j)
+		}
+	}
+
+	// error out if data exceed the expected number of entries
+	if trunc == nil {
+		if err := scanner.end(); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+
+	if o.zeros == ZeroDrop {
+		c.dropZeros()
+	}
+
+	if trunc != nil {
+		return Mx, Nx, c, trunc
+	}
+
+	return Mx, Nx, c, nil
+}
@@ -0,0 +1,185 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestCoordinateUnmarshalTextFrom(t *testing.T) {
+
+	// real, integer and pattern fields
+	for _, k := range []int{1, 2, 3, 4, 5, 6, 21, 22} {
+
+		name := fmt.Sprintf("mmtype-%02d.mtx", k)
+
+		b, err := os.ReadFile(filepath.Join("testdata", name))
+		assert.Nil(t, err)
+
+		var want COO
+		assert.Nil(t, want.UnmarshalText(b), name)
+
+		var c Coordinate[int32, float32]
+		assert.Nil(t, c.UnmarshalText(b), name)
+
+		M, N := want.ToMatrix().Dims()
+		got := mat.NewDense(M, N, nil)
+		c.Do(func(i, j int, v float32) {
+			got.Set(i, j, got.At(i, j)+float64(v))
+		})
+
+		assert.Equal(t, want.Symmetry, c.Symmetry, name)
+		assert.True(t, mat.EqualApprox(got, want.ToMatrix(), 1e-6), name)
+	}
+
+	// complex field
+	for _, k := range []int{7, 8, 9, 19} {
+
+		name := fmt.Sprintf("mmtype-%02d.mtx", k)
+
+		b, err := os.ReadFile(filepath.Join("testdata", name))
+		assert.Nil(t, err)
+
+		var want CDense
+		assert.Nil(t, want.UnmarshalText(b), name)
+
+		var c Coordinate[int32, complex64]
+		assert.Nil(t, c.UnmarshalText(b), name)
+
+		M, N := want.ToCMatrix().Dims()
+		got := mat.NewCDense(M, N, nil)
+		c.Do(func(i, j int, v complex64) {
+			got.Set(i, j, got.At(i, j)+complex128(v))
+		})
+
+		assert.True(t, mat.CEqualApprox(got, want.ToCMatrix(), 1e-6), name)
+	}
+}
+
+func TestCoordinateUnmarshalTextFromErrors(t *testing.T) {
+
+	var (
+		c  Coordinate[int32, float32]
+		cc Coordinate[int32, complex64]
+	)
+
+	text := "%%MatrixMarket matrix coordinate real general\n3000000000 1 1\n1 1 1\n"
+	_, err := c.UnmarshalTextFrom(strings.NewReader(text))
+	assert.True(t, errors.Is(err, ErrIndexOverflow))
+	assert.EqualError(t, err, "line 2: "+ErrIndexOverflow.Error()+": 3000000000×1 matrix exceeds int32 indices")
+
+	// the largest representable dimensions
+	text = "%%MatrixMarket matrix coordinate real general\n2147483648 1 1\n1 1 1\n"
+	_, err = c.UnmarshalTextFrom(strings.NewReader(text))
+	assert.Nil(t, err)
+
+	var c64 Coordinate[int64, float32]
+	text = "%%MatrixMarket matrix coordinate real general\n3000000000 1 1\n1 1 1\n"
+	_, err = c64.UnmarshalTextFrom(strings.NewReader(text))
+	assert.Nil(t, err)
+
+	// the field must match the element type
+	text = "%%MatrixMarket matrix coordinate complex general\n1 1 1\n1 1 1 1\n"
+	_, err = c.UnmarshalTextFrom(strings.NewReader(text))
+	assert.Equal(t, ErrUnsupportedType, err)
+
+	text = "%%MatrixMarket matrix coordinate real general\n1 1 1\n1 1 1\n"
+	_, err = cc.UnmarshalTextFrom(strings.NewReader(text))
+	assert.Equal(t, ErrUnsupportedType, err)
+
+	text = "%%MatrixMarket matrix array real general\n1 1\n1\n"
+	_, err = c.UnmarshalTextFrom(strings.NewReader(text))
+	assert.Equal(t, ErrUnsupportedType, err)
+}
+
+func TestCoordinateMarshalTextTo(t *testing.T) {
+
+	c := NewCoordinate(3, 3, []int32{0, 1, 2, 0}, []int32{0, 1, 0, 2}, []float32{0.1, -2.5, 3, 3})
+
+	var b strings.Builder
+	_, err := c.MarshalTextTo(&b)
+	assert.Nil(t, err)
+
+	// values are written with the precision of float32
+	assert.Equal(t, `%%MatrixMarket matrix coordinate real general
+%
+ 3  3  4
+ 1  1  0.1
+ 2  2 -2.5
+ 3  1  3
+ 1  3  3
+`, b.String())
+
+	c.Symmetry = SymmetryAuto
+
+	b.Reset()
+	_, err = c.MarshalTextTo(&b)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(b.String(), "%%MatrixMarket matrix coordinate real symmetric\n%\n 3  3  3\n"))
+
+	// symmetric matrices round trip
+	c = NewCoordinate(2, 2, []int32{1, 0}, []int32{0, 1}, []float32{0.3, 0.3})
+	c.Symmetry = SymmetryAuto
+
+	text, err := c.MarshalText()
+	assert.Nil(t, err)
+	assert.Equal(t, "%%MatrixMarket matrix coordinate real symmetric\n%\n 2  2  1\n 2  1  0.3\n", string(text))
+
+	var cc Coordinate[int32, float32]
+	assert.Nil(t, cc.UnmarshalText(text))
+	assert.Equal(t, SymmetrySymmetric, cc.Symmetry)
+	assert.ElementsMatch(t, []float32{0.3, 0.3}, cc.Data)
+
+	z := NewCoordinate(2, 2, []int{0, 1}, []int{1, 0}, []complex64{1 + 2i, 1 - 2i})
+	z.Symmetry = SymmetryAuto
+
+	text, err = z.MarshalText()
+	assert.Nil(t, err)
+	assert.Equal(t, "%%MatrixMarket matrix coordinate complex hermitian\n%\n 2  2  1\n 2  1  1 -2\n", string(text))
+
+	// the field must match the element type
+	z.Field = mtxFieldReal
+	_, err = z.MarshalText()
+	assert.Equal(t, ErrUnsupportedType, err)
+}
+
+func TestCoordinateMarshalTextToCOO(t *testing.T) {
+
+	// output at float64 precision matches that of COO
+	for _, k := range []string{"mmtype-01.mtx", "mmtype-02.mtx", "mmtype-03.mtx"} {
+
+		b, err := os.ReadFile(filepath.Join("testdata", k))
+		assert.Nil(t, err)
+
+		var (
+			m COO
+			c Coordinate[int, float64]
+		)
+		assert.Nil(t, m.UnmarshalText(b))
+		assert.Nil(t, c.UnmarshalText(b))
+
+		want, err := m.MarshalText()
+		assert.Nil(t, err)
+
+		got, err := c.MarshalText()
+		assert.Nil(t, err)
+
+		assert.Equal(t, string(want), string(got), k)
+	}
+}
+
+func TestCoordinateMarshalTextToCanonical(t *testing.T) {
+
+	c := NewCoordinate(2, 2, []int32{1, 0, 1}, []int32{0, 1, 0}, []complex64{1 + 1i, 2, 0.5 - 1i})
+
+	var b strings.Builder
+	_, err := c.MarshalTextTo(&b, WriteOrder(OrderRowMajor), WriteCompact())
+	assert.Nil(t, err)
+	assert.Equal(t, "%%MatrixMarket matrix coordinate complex general\n%\n2 2 2\n1 2 2 0\n2 1 1.5 0\n", b.String())
+}
@@ -194,6 +194,172 @@ func TestCOOUnmarshalTextFrom(t *testing.T) {
 
 }
 
+func TestCOOUnmarshalTextFromDuplicates(t *testing.T) {
+
+	text := `%%MatrixMarket matrix coordinate real general
+ 2  2  4
+ 1  1  1
+ 2  1  2
+ 1  1  3
+ 2  2  0
+`
+
+	c := map[DuplicatePolicy]*mat.Dense{
+		DuplicateSum:       mat.NewDense(2, 2, []float64{4, 0, 2, 0}),
+		DuplicateKeepLast:  mat.NewDense(2, 2, []float64{3, 0, 2, 0}),
+		DuplicateKeepFirst: mat.NewDense(2, 2, []float64{1, 0, 2, 0}),
+	}
+
+	for p, v := range c {
+
+		var mm COO
+		_, err := mm.UnmarshalTextFrom(strings.NewReader(text), WithDuplicates(p))
+		assert.Nil(t, err)
+		assert.True(t, mat.Equal(mm.ToMatrix(), v))
+	}
+
+	var mm COO
+	_, err := mm.UnmarshalTextFrom(strings.NewReader(text), WithDuplicates(DuplicateError))
+	assert.EqualError(t, err, "line 5: "+ErrDuplicateEntry.Error())
+
+	// an entry and its transpose are duplicates in a symmetric matrix
+	text = `%%MatrixMarket matrix coordinate real symmetric
+ 2  2  2
+ 2  1  1
+ 1  2  3
+`
+	_, err = mm.UnmarshalTextFrom(strings.NewReader(text), WithDuplicates(DuplicateError))
+	assert.EqualError(t, err, "line 4: "+ErrDuplicateEntry.Error())
+
+	_, err = mm.UnmarshalTextFrom(strings.NewReader(text), WithDuplicates(DuplicateKeepLast))
+	assert.Nil(t, err)
+	assert.True(t, mat.Equal(mm.ToMatrix(), mat.NewDense(2, 2, []float64{0, 3, 3, 0})))
+	assert.Equal(t, 2, mm.ToCOO().NNZ())
+}
+
+func TestCOOUnmarshalTextFromZeros(t *testing.T) {
+
+	text := `%%MatrixMarket matrix coordinate real general
+ 2  2  3
+ 1  1  1
+ 2  1  0
+ 2  2  0
+`
+
+	var mm COO
+
+	_, err := mm.UnmarshalTextFrom(strings.NewReader(text))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, mm.ToCOO().NNZ())
+
+	_, err = mm.UnmarshalTextFrom(strings.NewReader(text), WithZeros(ZeroDrop))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, mm.ToCOO().NNZ())
+}
+
+func TestCOOMarshalTextToPolicies(t *testing.T) {
+
+	c := sparse.NewCOO(2, 2, []int{0, 1, 0, 1}, []int{0, 0, 0, 1}, []float64{1, 2, 3, 0})
+	m := NewCOO(c)
+
+	var b strings.Builder
+	_, err := m.MarshalTextTo(&b)
+	assert.Nil(t, err)
+	assert.Equal(t, `%%MatrixMarket matrix coordinate real general
+%
+ 2  2  4
+ 1  1  1
+ 2  1  2
+ 1  1  3
+ 2  2  0
+`, b.String())
+
+	b.Reset()
+	_, err = m.MarshalTextTo(&b, WriteDuplicates(DuplicateKeepLast), WriteZeros(ZeroDrop))
+	assert.Nil(t, err)
+	assert.Equal(t, `%%MatrixMarket matrix coordinate real general
+%
+ 2  2  2
+ 1  1  3
+ 2  1  2
+`, b.String())
+
+	_, err = m.MarshalTextTo(io.Discard, WriteDuplicates(DuplicateError))
+	assert.EqualError(t, err, ErrDuplicateEntry.Error())
+}
+
+func TestCOOMarshalTextToOrder(t *testing.T) {
+
+	c := sparse.NewCOO(3, 2, []int{2, 0, 1, 0, 2}, []int{0, 1, 0, 1, 1}, []float64{1, 2, 3, 4, -0.0})
+	m := NewCOO(c)
+
+	var b strings.Builder
+	_, err := m.MarshalTextTo(&b, WriteOrder(OrderRowMajor))
+	assert.Nil(t, err)
+	assert.Equal(t, `%%MatrixMarket matrix coordinate real general
+%
+ 3  2  4
+ 1  2  6
+ 2  1  3
+ 3  1  1
+ 3  2  0
+`, b.String())
+
+	b.Reset()
+	_, err = m.MarshalTextTo(&b, WriteOrder(OrderColMajor), WriteZeros(ZeroDrop))
+	assert.Nil(t, err)
+	assert.Equal(t, `%%MatrixMarket matrix coordinate real general
+%
+ 3  2  3
+ 2  1  3
+ 3  1  1
+ 1  2  6
+`, b.String())
+}
+
+func TestCOOMarshalTextToCanonical(t *testing.T) {
+
+	// equal matrices, held with entries in differing orders
+	a := NewCOO(sparse.NewCOO(2, 2, []int{1, 0, 0}, []int{0, 0, 1}, []float64{1e-300, -0.0, 12}))
+	b := NewCOO(sparse.NewCOO(2, 2, []int{0, 0, 1}, []int{1, 0, 0}, []float64{12, 0, 1e-300}))
+
+	var sa, sb strings.Builder
+	_, err := a.MarshalTextTo(&sa, WriteCanonical())
+	assert.Nil(t, err)
+	_, err = b.MarshalTextTo(&sb, WriteCanonical())
+	assert.Nil(t, err)
+
+	assert.Equal(t, `%%MatrixMarket matrix coordinate real general
+%
+2 2 3
+1 1 0
+2 1 1e-300
+1 2 12
+`, sa.String())
+	assert.Equal(t, sa.String(), sb.String())
+
+	// real values are written with exponents where shorter
+	sa.Reset()
+	_, err = NewCOO(sparse.NewCOO(1, 1, []int{0}, []int{0}, []float64{1e21})).MarshalTextTo(&sa, WriteCompact())
+	assert.Nil(t, err)
+	assert.Contains(t, sa.String(), "1 1 1e+21\n")
+
+	// and integer values without
+	m := NewCOO(sparse.NewCOO(1, 1, []int{0}, []int{0}, []float64{1e21}))
+	m.Field = "integer"
+	sa.Reset()
+	_, err = m.MarshalTextTo(&sa, WriteCompact())
+	assert.Nil(t, err)
+	assert.Contains(t, sa.String(), "1 1 1000000000000000000000\n")
+
+	// pattern entries have no value
+	m.Field = "pattern"
+	sa.Reset()
+	_, err = m.MarshalTextTo(&sa, WriteCompact())
+	assert.Nil(t, err)
+	assert.Equal(t, "%%MatrixMarket matrix coordinate pattern general\n%\n1 1 1\n1 1\n", sa.String())
+}
+
 func BenchmarkCOOMarshalTextTo(b *testing.B) {
 	for i := 1; i <= 1000; i *= 10 {
 		a := sparse.NewCOO(i, i, nil, nil, nil)
@@ -235,3 +401,22 @@ func BenchmarkCOOUnmarshalTextFrom(b *testing.B) {
 		})
 	}
 }
+
+func TestCOOMarshalTextToPattern(t *testing.T) {
+
+	// pattern entries are written without values
+	for k, v := range map[string]*sparse.COO{"mmtype-21.mtx": mtx21, "mmtype-22.mtx": mtx22} {
+
+		m := NewCOO(v)
+		m.Field = mtxFieldPattern
+		m.Symmetry = SymmetryAuto
+
+		b, err := m.MarshalText()
+		assert.Nil(t, err)
+
+		mm, err := os.ReadFile(filepath.Join("testdata", k))
+		assert.Nil(t, err)
+
+		assert.Equal(t, string(mm), string(b), k)
+	}
+}
@@ -0,0 +1,87 @@
+package market
+
+import (
+	"io"
+
+	"github.com/james-bowman/sparse"
+)
+
+// Unmarshaler is implemented by the matrices of this package, which may
+// be read from Matrix Market format as configured by ReadOptions.
+type Unmarshaler interface {
+	UnmarshalTextFrom(r io.Reader, opts ...ReadOption) (int, error)
+}
+
+// Decoder reads matrices from Matrix Market format, retaining its
+// internal buffers between reads, such that reading many matrices does
+// not allocate a scanner buffer for each. Entries of a COO read with
+// WithCOO into the destination last read by the Decoder reuse the storage
+// of that destination. A Decoder is not safe for concurrent use.
+type Decoder struct {
+	opts []ReadOption
+	bufs buffers
+}
+
+// NewDecoder returns a Decoder which reads as configured by opts.
+func NewDecoder(opts ...ReadOption) *Decoder {
+
+	d := Decoder{}
+
+	d.opts = append(opts[:len(opts):len(opts)], func(o *readOptions) {
+		o.bufs = &d.bufs
+	})
+
+	return &d
+}
+
+// Decode deserializes r from Matrix Market format into m, as configured
+// by the options of the Decoder and then by opts, and returns the number
+// of bytes read.
+func (d *Decoder) Decode(r io.Reader, m Unmarshaler, opts ...ReadOption) (int, error) {
+
+	if len(opts) == 0 {
+		return m.UnmarshalTextFrom(r, d.opts...)
+	}
+
+	return m.UnmarshalTextFrom(r, append(d.opts[:len(d.opts):len(d.opts)], opts...)...)
+}
+
+// buffers holds the storage retained by a Decoder between reads. A nil
+// *buffers retains nothing.
+type buffers struct {
+	scan []byte                  // scanner buffer
+	coo  *sparse.COO             // COO last read
+	trip *triplets[int, float64] // entries of the COO last read
+}
+
+// scanBuffer returns a scanner buffer.
+func (b *buffers) scanBuffer() []byte {
+
+	if b == nil {
+		return make([]byte, maxScanTokenSize)
+	}
+
+	if b.scan == nil {
+		b.scan = make([]byte, maxScanTokenSize)
+	}
+
+	return b.scan
+}
+
+// triplets returns the entries backing dst, if dst is the COO last read,
+// or otherwise nil.
+func (b *buffers) triplets(dst *sparse.COO) *triplets[int, float64] {
+
+	if b == nil || dst == nil || dst != b.coo {
+		return nil
+	}
+
+	return b.trip
+}
+
+// keep retains c, the entries backing m.
+func (b *buffers) keep(m *sparse.COO, c *triplets[int, float64]) {
+	if b != nil {
+		b.coo, b.trip = m, c
+	}
+}
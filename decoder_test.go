@@ -0,0 +1,67 @@
+package market
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestDecoder(t *testing.T) {
+
+	d := NewDecoder(WithDuplicates(DuplicateKeepLast))
+
+	// the scanner buffer is retained between reads
+	var m Dense
+	_, err := d.Decode(strings.NewReader("%%MatrixMarket matrix array real general\n1 1\n1\n"), &m)
+	assert.Nil(t, err)
+	assert.NotNil(t, d.bufs.scan)
+
+	scan := &d.bufs.scan[0]
+
+	var c COO
+	dst := sparse.NewCOO(1, 1, nil, nil, nil)
+
+	text := "%%MatrixMarket matrix coordinate real general\n2 2 3\n1 1 1\n2 2 2\n1 1 3\n"
+	_, err = d.Decode(strings.NewReader(text), &c, WithCOO(dst))
+	assert.Nil(t, err)
+	assert.Same(t, scan, &d.bufs.scan[0])
+	assert.True(t, mat.Equal(dst, mat.NewDiagDense(2, []float64{3, 2})))
+
+	// the storage of the COO last read is reused
+	rows := &d.bufs.trip.rows[0]
+
+	text = "%%MatrixMarket matrix coordinate real general\n2 2 1\n2 1 5\n"
+	_, err = d.Decode(strings.NewReader(text), &c, WithCOO(dst))
+	assert.Nil(t, err)
+	assert.Same(t, rows, &d.bufs.trip.rows[0])
+	assert.True(t, mat.Equal(dst, mat.NewDense(2, 2, []float64{0, 0, 5, 0})))
+
+	// that of any other COO is not
+	other := sparse.NewCOO(1, 1, nil, nil, nil)
+	_, err = d.Decode(strings.NewReader(text), &c, WithCOO(other))
+	assert.Nil(t, err)
+	assert.NotSame(t, rows, &d.bufs.trip.rows[0])
+	assert.True(t, mat.Equal(other, dst))
+
+	// errors are returned as read
+	_, err = d.Decode(strings.NewReader(text), &m)
+	assert.Equal(t, ErrUnsupportedType, err)
+}
+
+func BenchmarkDecoder(b *testing.B) {
+
+	text := "%%MatrixMarket matrix array real general\n2 2\n1\n2\n3\n4\n"
+
+	d := NewDecoder()
+	dst := mat.NewDense(2, 2, nil)
+
+	var m Dense
+	for i := 0; i < b.N; i++ {
+		if _, err := d.Decode(strings.NewReader(text), &m, WithDense(dst)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
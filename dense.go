@@ -1,7 +1,6 @@
 package market
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
 	"io"
@@ -62,13 +61,20 @@ func (m *Dense) MarshalText() ([]byte, error) {
 	return []byte(b.String()), nil
 }
 
-// MarshalTextTo serializes the receiver to w in Matrix Market format
-// and returns the result.
-func (m *Dense) MarshalTextTo(w io.Writer) (int, error) {
+// MarshalTextTo serializes the receiver to w in Matrix Market format,
+// as configured by opts, and returns the result.
+func (m *Dense) MarshalTextTo(w io.Writer, opts ...WriteOption) (int, error) {
 
 	var total int
 
+	o := newWriteOptions(opts)
+
 	t := mmType{m.Object, m.Format, m.Field, m.Symmetry}
+	t.canonicalize()
+
+	if t.Symmetry == SymmetryAuto {
+		t.Symmetry = DetectSymmetry(m.mat, o.tolerance)
+	}
 
 	if n, err := w.Write(t.Bytes()); err == nil {
 		total += n
@@ -77,14 +83,16 @@ func (m *Dense) MarshalTextTo(w io.Writer) (int, error) {
 	}
 
 	M, N := m.mat.Dims()
-	if n, err := fmt.Fprintf(w, "%%\n %d  %d\n", M, N); err == nil {
+	if n, err := fmt.Fprintf(w, o.sizeLine(false), M, N); err == nil {
 		total += n
 	} else {
 		return total, ErrUnwritable
 	}
 
 	var a floatAligner
-	m.Do(a.Fit('f', -1, 64))
+	if !o.compact {
+		m.Do(stored(t.Symmetry, a.Fit('f', -1, 64)))
+	}
 
 	// entries in column major order
 	var buf = make([]byte, 0, 64)
@@ -92,7 +100,16 @@ func (m *Dense) MarshalTextTo(w io.Writer) (int, error) {
 
 		for i := 0; i < M; i++ {
 
-			buf = a.Append(buf[:0], m.mat.At(i, j), 'f', -1, 64)
+			// only the lower triangle of a symmetric matrix is written
+			if !isStored(t.Symmetry, i, j) {
+				continue
+			}
+
+			if o.compact {
+				buf = appendCompactValue(buf[:0], m.mat.At(i, j), &t)
+			} else {
+				buf = a.Append(buf[:0], m.mat.At(i, j), 'f', -1, 64)
+			}
 			buf = append(buf, '\n')
 
 			n, err := w.Write(buf)
@@ -121,19 +138,19 @@ func (m *Dense) UnmarshalText(text []byte) error {
 }
 
 // UnmarshalTextFrom deserializes r from Matrix Market format
-// into the receiver.
-func (m *Dense) UnmarshalTextFrom(r io.Reader) (int, error) {
+// into the receiver, as configured by opts.
+func (m *Dense) UnmarshalTextFrom(r io.Reader, opts ...ReadOption) (int, error) {
 
 	var n counter
 
+	o := newReadOptions(opts)
+
 	r = io.TeeReader(r, &n)
 
-	scanner := bufio.NewScanner(r)
-	buf := make([]byte, maxScanTokenSize)
-	scanner.Buffer(buf, maxScanTokenSize)
+	scanner := newScanner(r, o)
 
 	// read header
-	t, err := scanHeader(scanner)
+	t, err := scanHeader(scanner, o)
 	if err != nil {
 		return n.total, err
 	}
@@ -142,16 +159,16 @@ func (m *Dense) UnmarshalTextFrom(r io.Reader) (int, error) {
 	m.Object = t.Object
 	m.Format = t.Format
 	m.Field = t.Field
-	m.Symmetry = t.Symmetry
+	m.Symmetry = o.xform.symmetry(t.Symmetry)
 
 	switch t.index() {
 
 	case 10, 11, 12, 13, 14, 15:
-		if err := m.scanArrayData(scanner); err != nil {
+		if err := m.scanArrayData(scanner, t, o); err != nil {
 			return n.total, err
 		}
 
-		if err := scanner.Err(); err != nil {
+		if err := scanError(scanner.Scanner); err != nil {
 			return n.total, err
 		}
 
@@ -163,98 +180,46 @@ func (m *Dense) UnmarshalTextFrom(r io.Reader) (int, error) {
 	return n.total, nil
 }
 
-func (m *Dense) scanArrayData(scanner *bufio.Scanner) error {
-
-	var M, N, k int
-
-	for scanner.Scan() {
+func (m *Dense) scanArrayData(scanner *lineScanner, t *mmType, o *readOptions) error {
 
-		line := scanner.Text()
-
-		// blank line or comment (%, Unicode 37)
-		if r := []rune(line); len(r) == 0 || r[0] == 37 {
-			continue
-		}
-
-		// M is number of rows, N is number of columns
-		if _, err := fmt.Sscanf(line, "%d %d", &M, &N); err != nil {
-			return ErrInputScanError
-		}
-
-		break
+	M, N, _, err := scanSize(scanner, t, o)
+	if err != nil {
+		return err
 	}
 
-	d := mat.NewDense(M, N, nil)
-
-	for scanner.Scan() {
-
-		var v float64
-
-		line := scanner.Text()
-
-		// blank lines are allowed in data per design spec
-		if r := []rune(line); len(r) == 0 {
-			continue
-		}
+	// dense storage cannot be allocated for an empty matrix
+	Mx, Nx := o.xform.dims(M, N)
+	if Mx == 0 || Nx == 0 {
+		return scanner.errorf(scanner.line, ErrInvalidSize)
+	}
 
-		// error out if data rows exceed expected non-zero entries
-		// (note that k is zero indexed)
-		if k == M*N {
-			return ErrInputScanError
-		}
+	vals, trunc, err := scanArrayValues[float64](scanner, t, o, M, N)
+	if err != nil {
+		return err
+	}
 
-		if _, err := fmt.Sscanf(line, "%g", &v); err != nil {
-			return ErrInputScanError
+	// the values of a truncated matrix do not bound its storage
+	if trunc != nil {
+		if err := o.checkDense(Mx, Nx); err != nil {
+			return err
 		}
+	}
 
-		switch m.Symmetry {
-
-		case mtxSymmetrySymm:
-
-			// if above diagonal, move to diag
-			for k%M < int(k/M) {
-				k++
-			}
-
-			// if off diagonal, set value for symm element
-			if int(k/M) != k%M {
-				d.Set(int(k/M), k%M, v)
-			}
-
-		case mtxSymmetrySkew:
-
-			// if on or above diagonal, move below diag
-			for k%M <= int(k/M) {
-				k++
-			}
+	d := o.newDense(Mx, Nx)
 
-			// set skew value for symm element
-			d.Set(int(k/M), k%M, -v)
+	// elements are set as transformed by any selection or transform
+	// options
+	setArrayValues(t, M, N, vals, func(i, j int, v float64) {
+		if i, j, v, ok := apply(o.xform, i, j, v); ok {
+			d.Set(i, j, v)
 		}
+	})
 
-		d.Set(k%M, int(k/M), v)
-		k++
-	}
-
-	// as skew-symmetric entries are below the diagonal in the matrix
-	// market specification, there are no entries in the last column. The
-	// counter k must must be advanced by one full column for
-	// skew-symmetric matrices prior to the check which follows, otherwise
-	// k == M * (N - 1) and the check will fail.
-	if m.Symmetry == mtxSymmetrySkew {
-		k += M
-	}
-
-	// compare counter k against expected number of entries (matrix size)
-	if k != M*N {
-		return ErrInputScanError
-	}
+	m.mat = d
 
-	if err := scanner.Err(); err != nil {
-		return ErrInputScanError
+	if trunc != nil {
+		return trunc
 	}
 
-	m.mat = d
-
 	return nil
 }
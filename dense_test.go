@@ -175,3 +175,13 @@ func BenchmarkDenseUnmarshalTextFrom(b *testing.B) {
 		})
 	}
 }
+
+func TestDenseMarshalTextToCompact(t *testing.T) {
+
+	m := NewDense(mat.NewDense(2, 2, []float64{1.5, math.Copysign(0, -1), -2e-7, 100}))
+
+	var b strings.Builder
+	_, err := m.MarshalTextTo(&b, WriteCompact())
+	assert.Nil(t, err)
+	assert.Equal(t, "%%MatrixMarket matrix array real general\n%\n2 2\n1.5\n-2e-07\n0\n100\n", b.String())
+}
@@ -0,0 +1,210 @@
+package market
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// UnmarshalDIMACSFrom deserializes the adjacency matrix of a graph from
+// DIMACS format into the receiver, as configured by opts, and returns the
+// number of bytes read. The problem line "p sp n m" (or of another
+// problem, such as max or edge) gives the numbers of nodes and of arcs,
+// which follow as lines "a u v w" of one-indexed nodes, or as edges
+// "e u v". Comments (c) and node descriptors (n) are skipped. The matrix
+// is of the real field if arcs are weighted and otherwise of the pattern
+// field, and is symmetric if of the edge problem or if reading
+// WithSymmetrize.
+func (m *COO) UnmarshalDIMACSFrom(r io.Reader, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	scanner := newScanner(r, o)
+
+	var (
+		problem  string
+		nodes    int
+		arcs     = -1
+		edges    []graphEdge
+		fields   []string
+		weighted bool
+	)
+
+	for scanner.Scan() {
+
+		fields = appendFields(fields[:0], scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+
+		case "c", "n":
+			continue
+
+		case "p":
+			if arcs >= 0 || len(fields) != 4 {
+				return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: invalid problem line", ErrInputScanError))
+			}
+
+			var err error
+
+			problem = fields[1]
+			if nodes, err = parseInt(fields[2]); err != nil {
+				return n.total, scanner.errorf(scanner.line, err)
+			}
+			if arcs, err = parseInt(fields[3]); err != nil {
+				return n.total, scanner.errorf(scanner.line, err)
+			}
+
+			if nodes < 0 || arcs < 0 {
+				return n.total, scanner.errorf(scanner.line, ErrInvalidSize)
+			}
+
+			L := arcs
+			if problem == "edge" || o.symmetrize {
+				L *= 2
+			}
+
+			if err := o.limits.checkSize(nodes, nodes, L); err != nil {
+				return n.total, err
+			}
+
+			edges = make([]graphEdge, 0, prealloc(arcs))
+
+		case "a", "e":
+			if arcs < 0 {
+				return n.total, scanner.errorf(scanner.line, ErrPrematureEOF)
+			}
+
+			if len(fields) != 3 && len(fields) != 4 {
+				return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: invalid arc", ErrInputScanError))
+			}
+
+			if len(edges) == 0 {
+				weighted = len(fields) == 4
+			}
+
+			if weighted != (len(fields) == 4) {
+				return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: arcs of mixed weighting", ErrInputScanError))
+			}
+
+			if len(edges) == arcs {
+				return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: more than %d arcs", ErrInputScanError, arcs))
+			}
+
+			u, err := parseInt(fields[1])
+			if err != nil {
+				return n.total, scanner.errorf(scanner.line, err)
+			}
+
+			v, err := parseInt(fields[2])
+			if err != nil {
+				return n.total, scanner.errorf(scanner.line, err)
+			}
+
+			if u < 1 || u > nodes || v < 1 || v > nodes {
+				return n.total, scanner.errorf(scanner.line, ErrIndexOutOfRange)
+			}
+
+			w := 1.0
+			if weighted {
+				if w, err = parseFloat(fields[3]); err != nil {
+					return n.total, scanner.errorf(scanner.line, err)
+				}
+			}
+
+			edges = append(edges, graphEdge{u - 1, v - 1, w})
+
+		default:
+			return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: unknown line type %q", ErrInputScanError, fields[0]))
+		}
+	}
+
+	if err := scanError(scanner.Scanner); err != nil {
+		return n.total, err
+	}
+
+	if arcs < 0 {
+		return n.total, ErrPrematureEOF
+	}
+
+	if len(edges) < arcs {
+		return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: %d of %d arcs", ErrTruncated, len(edges), arcs))
+	}
+
+	if err := m.setGraph(nodes, edges, weighted, problem == "edge" || o.symmetrize, o); err != nil {
+		return n.total, err
+	}
+
+	return n.total, nil
+}
+
+// MarshalDIMACSTo serializes the receiver, as the adjacency matrix of a
+// graph, to w in DIMACS format, as configured by opts, and returns the
+// number of bytes written. A symmetric matrix of the pattern field is
+// written as an edge problem ("p edge n m"), of the edges "e u v" of its
+// lower triangle. Any other matrix is written as a shortest path problem
+// ("p sp n m"), of the arcs "a u v w", in which the weight of the pattern
+// field is one. Edges and arcs are in row major order with duplicates
+// merged.
+func (m *COO) MarshalDIMACSTo(w io.Writer, opts ...WriteOption) (int, error) {
+
+	var n counter
+
+	o := newWriteOptions(opts)
+
+	// arcs of a symmetric matrix are written in both directions
+	x := *m
+	edge := m.Field == mtxFieldPattern && m.Symmetry == mtxSymmetrySymm
+	if !edge {
+		x.Symmetry = mtxSymmetryGeneral
+	}
+
+	c, err := x.graphEntries(o)
+	if err != nil {
+		return 0, err
+	}
+
+	N, _ := m.mat.Dims()
+
+	integer := m.Field == mtxFieldInteger || m.Field == mtxFieldPattern
+
+	bw := bufio.NewWriterSize(io.MultiWriter(w, &n), maxScanTokenSize)
+
+	kind, problem := "a", "sp"
+	if edge {
+		kind, problem = "e", "edge"
+	}
+
+	fmt.Fprintf(bw, "p %s %d %d\n", problem, N, len(c.data))
+
+	var b []byte
+
+	for k, v := range c.data {
+
+		b = append(b[:0], kind...)
+		b = append(b, ' ')
+		b = appendCompactIndex(b, c.rows[k], c.cols[k])
+
+		if !edge {
+			if m.Field == mtxFieldPattern {
+				v = 1
+			}
+			b = append(b, ' ')
+			b = appendCompact(b, v, integer, 64)
+		}
+
+		bw.Write(append(b, '\n'))
+	}
+
+	if err := bw.Flush(); err != nil {
+		return n.total, ErrUnwritable
+	}
+
+	return n.total, nil
+}
@@ -0,0 +1,113 @@
+package market
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// dimacs01 is a shortest path problem of three nodes and four arcs.
+const dimacs01 = `c shortest path problem
+p sp 3 4
+c arcs
+a 1 2 5
+a 2 3 1
+a 1 3 7
+a 3 1 2
+`
+
+func TestCOOUnmarshalDIMACSFrom(t *testing.T) {
+
+	var m COO
+
+	n, err := m.UnmarshalDIMACSFrom(strings.NewReader(dimacs01))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, len(dimacs01), n)
+	assert.Equal(t, mtxFieldReal, m.Field)
+	assert.Equal(t, mtxSymmetryGeneral, m.Symmetry)
+	assert.True(t, mat.Equal(mat.NewDense(3, 3, []float64{
+		0, 5, 7,
+		0, 0, 1,
+		2, 0, 0,
+	}), m.ToCOO()))
+
+	// edge problems are undirected
+	_, err = m.UnmarshalDIMACSFrom(strings.NewReader("p edge 3 2\ne 1 2\ne 3 2\n"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, mtxFieldPattern, m.Field)
+		assert.Equal(t, mtxSymmetrySymm, m.Symmetry)
+		assert.True(t, mat.Equal(mat.NewDense(3, 3, []float64{
+			0, 1, 0,
+			1, 0, 1,
+			0, 1, 0,
+		}), m.ToCOO()))
+	}
+
+	for _, test := range []struct {
+		name string
+		file string
+		err  error
+	}{
+		{"problem", "a 1 2 3\n", ErrPrematureEOF},
+		{"empty", "c nothing\n", ErrPrematureEOF},
+		{"line", "p sp 2 1\nx 1 2\n", ErrInputScanError},
+		{"index", "p sp 2 1\na 1 3 1\n", ErrIndexOutOfRange},
+		{"mixed", "p sp 2 2\na 1 2 1\na 2 1\n", ErrInputScanError},
+		{"more", "p sp 2 1\na 1 2 1\na 2 1 1\n", ErrInputScanError},
+		{"fewer", "p sp 2 2\na 1 2 1\n", ErrTruncated},
+		{"limit", "p sp 5 1\na 1 2 1\n", ErrLimitExceeded},
+	} {
+		_, err := m.UnmarshalDIMACSFrom(strings.NewReader(test.file), WithLimits(Limits{MaxRows: 4}))
+		assert.ErrorIs(t, err, test.err, test.name)
+	}
+}
+
+func TestCOOMarshalDIMACSTo(t *testing.T) {
+
+	var m COO
+
+	_, err := m.UnmarshalDIMACSFrom(strings.NewReader(dimacs01))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var b bytes.Buffer
+
+	n, err := m.MarshalDIMACSTo(&b)
+	if assert.NoError(t, err) {
+		assert.Equal(t, b.Len(), n)
+		assert.Equal(t, "p sp 3 4\na 1 2 5\na 1 3 7\na 2 3 1\na 3 1 2\n", b.String())
+	}
+
+	// symmetric pattern matrices are written as edge problems
+	c := sparse.NewCOO(3, 3, []int{0, 1, 1, 2}, []int{1, 0, 2, 1}, []float64{1, 1, 1, 1})
+	s := &COO{mtxObjectMatrix, mtxFormatCoordinate, mtxFieldPattern, mtxSymmetrySymm, c}
+
+	b.Reset()
+	_, err = s.MarshalDIMACSTo(&b)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "p edge 3 2\ne 2 1\ne 3 2\n", b.String())
+	}
+
+	// and other pattern matrices as of unit weights
+	s.Symmetry = mtxSymmetryGeneral
+
+	b.Reset()
+	_, err = s.MarshalDIMACSTo(&b)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "p sp 3 4\na 1 2 1\na 2 1 1\na 2 3 1\na 3 2 1\n", b.String())
+	}
+
+	_, err = NewCOO(mtx01).MarshalDIMACSTo(&b)
+	assert.ErrorIs(t, err, ErrInvalidGraph)
+
+	_, err = m.MarshalDIMACSTo(failWriter{})
+	assert.ErrorIs(t, err, ErrUnwritable)
+}
@@ -0,0 +1,328 @@
+package market
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/james-bowman/sparse"
+)
+
+// graphEdge is a (zero-indexed) edge of a graph file.
+type graphEdge struct {
+	u, v int
+	w    float64
+}
+
+// setGraph sets the receiver to the adjacency matrix of the graph of n
+// nodes and the edges, of the real field if weighted and otherwise of the
+// pattern field, as configured by o. The edges of a symmetric matrix are
+// stored in both directions.
+func (m *COO) setGraph(n int, edges []graphEdge, weighted, symmetric bool, o *readOptions) error {
+
+	if err := o.xform.check(n, n); err != nil {
+		return err
+	}
+
+	c := newTriplets[int, float64](2*len(edges), o.duplicates, false)
+	c.x = o.xform
+	if !weighted {
+		c.x = o.xform.indices()
+	}
+
+	for _, e := range edges {
+
+		if e.w == 0 && o.zeros == ZeroDrop {
+			continue
+		}
+
+		if symmetric && e.u != e.v {
+			if _, err := c.add(e.v, e.u, e.w); err != nil {
+				return err
+			}
+		}
+
+		if _, err := c.add(e.u, e.v, e.w); err != nil {
+			return err
+		}
+	}
+
+	Mx, Nx := o.xform.dims(n, n)
+	d := sparse.NewCOO(Mx, Nx, c.rows, c.cols, c.data)
+	if o.coo != nil {
+		*o.coo = *d
+		d = o.coo
+	}
+
+	// apply header fields
+	m.Object = mtxObjectMatrix
+	m.Format = mtxFormatCoordinate
+	m.Field = mtxFieldPattern
+	if weighted {
+		m.Field = mtxFieldReal
+	}
+	m.Symmetry = mtxSymmetryGeneral
+	if symmetric {
+		m.Symmetry = o.xform.symmetry(mtxSymmetrySymm)
+	}
+	m.mat = d
+
+	return nil
+}
+
+// graphEntries returns the entries of the receiver, as the adjacency
+// matrix of a graph, in row major order with duplicates merged, as
+// configured by o. Only the lower triangle of a symmetric matrix is
+// returned.
+func (m *COO) graphEntries(o *writeOptions) (*triplets[int, float64], error) {
+
+	M, N := m.mat.Dims()
+	if M != N {
+		return nil, fmt.Errorf("%w: %d×%d matrix is not square", ErrInvalidGraph, M, N)
+	}
+
+	symmetry := m.Symmetry
+	if symmetry != mtxSymmetrySymm {
+		symmetry = mtxSymmetryGeneral
+	}
+
+	c := newTriplets[int, float64](m.mat.NNZ(), o.duplicates, false)
+
+	var err error
+	m.Do(stored(symmetry, func(i, j int, v float64) {
+		if err == nil {
+			_, err = c.add(i, j, v)
+		}
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	c.sort(OrderRowMajor)
+
+	if o.zeros == ZeroDrop {
+		c.dropZeros()
+	}
+
+	return c, nil
+}
+
+// snapNodes returns the number of nodes of a SNAP comment line of the form
+// "# Nodes: n Edges: m", if any.
+func snapNodes(line string) (int, bool) {
+
+	fields := strings.Fields(line)
+
+	for k := 0; k+1 < len(fields); k++ {
+		if fields[k] == "Nodes:" {
+			n, err := strconv.Atoi(fields[k+1])
+			return n, err == nil && n >= 0
+		}
+	}
+
+	return 0, false
+}
+
+// UnmarshalEdgeListFrom deserializes the adjacency matrix of a graph from
+// an edge list, such as those of the SNAP datasets, into the receiver, as
+// configured by opts, and returns the number of bytes read. Each line
+// holds an edge "u v", or a weighted edge "u v w", of which the node ids
+// are zero-based unless set by WithIndexBase, and comments begin with #
+// or %. The matrix is of the pattern field, or of the real field if edges
+// are weighted, and is of as many nodes as are given by a SNAP comment
+// "# Nodes: n" or else as the greatest node id.
+//
+// If ids is non-nil then node ids, which need not be consecutive, are
+// remapped to indices in ascending order of id, and ids is set to the id
+// of each index, as permuted and selected with the rows of the matrix.
+// The edges are directed unless reading WithSymmetrize.
+func (m *COO) UnmarshalEdgeListFrom(r io.Reader, ids *[]int, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	scanner := newScanner(r, o)
+
+	base := o.indexBase(0)
+
+	var (
+		edges   []graphEdge
+		fields  []string
+		columns int
+		nodes   int
+	)
+
+	for scanner.Scan() {
+
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			continue
+		}
+
+		if line[0] == '#' || line[0] == '%' {
+			if k, ok := snapNodes(line); ok && ids == nil {
+				nodes = max(nodes, k)
+			}
+			continue
+		}
+
+		fields = appendFields(fields[:0], line)
+
+		if columns == 0 {
+			columns = len(fields)
+			if columns != 2 && columns != 3 {
+				return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: %d columns", ErrInputScanError, columns))
+			}
+		}
+
+		if len(fields) != columns {
+			return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: %d of %d columns", ErrInputScanError, len(fields), columns))
+		}
+
+		u, err := parseInt(fields[0])
+		if err != nil {
+			return n.total, scanner.errorf(scanner.line, err)
+		}
+
+		v, err := parseInt(fields[1])
+		if err != nil {
+			return n.total, scanner.errorf(scanner.line, err)
+		}
+
+		w := 1.0
+		if columns == 3 {
+			if w, err = parseFloat(fields[2]); err != nil {
+				return n.total, scanner.errorf(scanner.line, err)
+			}
+		}
+
+		// ids which are remapped are not offset
+		if ids == nil {
+
+			u, v = u-base, v-base
+			if u < 0 || v < 0 {
+				return n.total, scanner.errorf(scanner.line, ErrIndexOutOfRange)
+			}
+
+			nodes = max(nodes, max(u, v)+1)
+		}
+
+		if err := o.limits.checkSize(nodes, nodes, len(edges)+1); err != nil {
+			return n.total, scanner.errorf(scanner.line, err)
+		}
+
+		edges = append(edges, graphEdge{u, v, w})
+	}
+
+	if err := scanError(scanner.Scanner); err != nil {
+		return n.total, err
+	}
+
+	var remap []int
+	if ids != nil {
+
+		index := make(map[int]int)
+		for _, e := range edges {
+			index[e.u], index[e.v] = 0, 0
+		}
+
+		remap = make([]int, 0, len(index))
+		for id := range index {
+			remap = append(remap, id)
+		}
+		slices.Sort(remap)
+
+		for k, id := range remap {
+			index[id] = k
+		}
+
+		for k, e := range edges {
+			edges[k].u, edges[k].v = index[e.u], index[e.v]
+		}
+
+		nodes = len(remap)
+
+		if err := o.limits.checkSize(nodes, nodes, len(edges)); err != nil {
+			return n.total, err
+		}
+	}
+
+	if err := m.setGraph(nodes, edges, columns == 3, o.symmetrize, o); err != nil {
+		return n.total, err
+	}
+
+	if ids != nil {
+		*ids = selectRows(o.xform, remap)
+	}
+
+	return n.total, nil
+}
+
+// MarshalEdgeListTo serializes the receiver, as the adjacency matrix of a
+// graph, to w as an edge list in the style of the SNAP datasets, as
+// configured by opts, and returns the number of bytes written. A comment
+// "# Nodes: n Edges: m" is followed by the edges "u v", or "u v w" unless
+// of the pattern field, in row major order with duplicates merged. Only
+// the lower triangle of a symmetric matrix is written. Node ids are the
+// zero-based indices, unless set by WriteIndexBase, or if ids is non-nil,
+// the id of each index.
+func (m *COO) MarshalEdgeListTo(w io.Writer, ids []int, opts ...WriteOption) (int, error) {
+
+	var n counter
+
+	o := newWriteOptions(opts)
+
+	c, err := m.graphEntries(o)
+	if err != nil {
+		return 0, err
+	}
+
+	N, _ := m.mat.Dims()
+	if ids != nil && len(ids) != N {
+		return 0, fmt.Errorf("%w: %d ids for %d nodes", ErrInvalidSize, len(ids), N)
+	}
+
+	base := o.indexBase(0)
+
+	id := func(i int) int64 {
+		if ids != nil {
+			return int64(ids[i])
+		}
+		return int64(i + base)
+	}
+
+	integer := m.Field == mtxFieldInteger
+
+	bw := bufio.NewWriterSize(io.MultiWriter(w, &n), maxScanTokenSize)
+
+	fmt.Fprintf(bw, "# Nodes: %d Edges: %d\n", N, len(c.data))
+
+	var b []byte
+
+	for k, v := range c.data {
+
+		b = strconv.AppendInt(b[:0], id(c.rows[k]), 10)
+		b = append(b, '\t')
+		b = strconv.AppendInt(b, id(c.cols[k]), 10)
+
+		if m.Field != mtxFieldPattern {
+			b = append(b, '\t')
+			b = appendCompact(b, v, integer, 64)
+		}
+
+		bw.Write(append(b, '\n'))
+	}
+
+	if err := bw.Flush(); err != nil {
+		return n.total, ErrUnwritable
+	}
+
+	return n.total, nil
+}
@@ -0,0 +1,124 @@
+package market
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// snap01 is a directed graph in the style of the SNAP datasets, of which
+// the last node has no edges.
+const snap01 = `# Directed graph (each unordered pair of nodes is saved once): example.txt
+# Nodes: 4 Edges: 3
+# FromNodeId	ToNodeId
+0	1
+0	2
+2	1
+`
+
+func TestCOOUnmarshalEdgeListFrom(t *testing.T) {
+
+	var m COO
+
+	n, err := m.UnmarshalEdgeListFrom(strings.NewReader(snap01), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, len(snap01), n)
+	assert.Equal(t, mtxFieldPattern, m.Field)
+	assert.Equal(t, mtxSymmetryGeneral, m.Symmetry)
+	assert.True(t, mat.Equal(mat.NewDense(4, 4, []float64{
+		0, 1, 1, 0,
+		0, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 0, 0,
+	}), m.ToCOO()))
+
+	// one-based and undirected
+	_, err = m.UnmarshalEdgeListFrom(strings.NewReader("1 2 0.5\n3 2 2\n"), nil, WithIndexBase(1), WithSymmetrize())
+	if assert.NoError(t, err) {
+		assert.Equal(t, mtxFieldReal, m.Field)
+		assert.Equal(t, mtxSymmetrySymm, m.Symmetry)
+		assert.True(t, mat.Equal(mat.NewDense(3, 3, []float64{
+			0, 0.5, 0,
+			0.5, 0, 2,
+			0, 2, 0,
+		}), m.ToCOO()))
+	}
+
+	// sparse ids are remapped in ascending order
+	var ids []int
+
+	_, err = m.UnmarshalEdgeListFrom(strings.NewReader("1000 -7\n42 1000\n"), &ids)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{-7, 42, 1000}, ids)
+		assert.True(t, mat.Equal(mat.NewDense(3, 3, []float64{
+			0, 0, 0,
+			0, 0, 1,
+			1, 0, 0,
+		}), m.ToCOO()))
+	}
+
+	for _, test := range []struct {
+		name string
+		file string
+		line int
+		err  error
+	}{
+		{"columns", "0 1\n0 1 2\n", 2, ErrInputScanError},
+		{"width", "0\n", 1, ErrInputScanError},
+		{"id", "0 x\n", 1, ErrInputScanError},
+		{"negative", "0 -1\n", 1, ErrIndexOutOfRange},
+		{"limit", "0 1\n0 5\n", 2, ErrLimitExceeded},
+	} {
+		_, err := m.UnmarshalEdgeListFrom(strings.NewReader(test.file), nil, WithLimits(Limits{MaxRows: 4}))
+		assert.ErrorIs(t, err, test.err, test.name)
+
+		var pe *ParseError
+		if assert.ErrorAs(t, err, &pe, test.name) {
+			assert.Equal(t, test.line, pe.Line, test.name)
+		}
+	}
+}
+
+func TestCOOMarshalEdgeListTo(t *testing.T) {
+
+	var m COO
+
+	_, err := m.UnmarshalEdgeListFrom(strings.NewReader(snap01), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var b bytes.Buffer
+
+	n, err := m.MarshalEdgeListTo(&b, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, b.Len(), n)
+		assert.Equal(t, "# Nodes: 4 Edges: 3\n0\t1\n0\t2\n2\t1\n", b.String())
+	}
+
+	// only the lower triangle of a symmetric matrix is written, with ids
+	c := sparse.NewCOO(2, 2, []int{0, 1}, []int{1, 0}, []float64{2, 2})
+	s := &COO{mtxObjectMatrix, mtxFormatCoordinate, mtxFieldInteger, mtxSymmetrySymm, c}
+
+	b.Reset()
+	_, err = s.MarshalEdgeListTo(&b, []int{10, 20})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "# Nodes: 2 Edges: 1\n20\t10\t2\n", b.String())
+	}
+
+	_, err = s.MarshalEdgeListTo(&b, []int{10})
+	assert.ErrorIs(t, err, ErrInvalidSize)
+
+	_, err = NewCOO(mtx01).MarshalEdgeListTo(&b, nil)
+	assert.ErrorIs(t, err, ErrInvalidGraph)
+
+	_, err = m.MarshalEdgeListTo(failWriter{}, nil)
+	assert.ErrorIs(t, err, ErrUnwritable)
+}
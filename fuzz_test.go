@@ -0,0 +1,128 @@
+package market
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fuzzSeed adds each Matrix Market file in testdata, along with a set of
+// known problematic inputs, to the seed corpus of f.
+func fuzzSeed(f *testing.F) {
+
+	files, err := filepath.Glob(filepath.Join("testdata", "mmtype-*.mtx"))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+
+	for _, s := range []string{
+		"%%MatrixMarket matrix coordinate real general\n2 2 1\n0 1 1\n",
+		"%%MatrixMarket matrix coordinate real general\n2 2 1\n3 1 1\n",
+		"%%MatrixMarket matrix coordinate complex general\n2 2 1\n1 3 1 1\n",
+		"%%MatrixMarket matrix array real general\n-1 -1\n",
+		"%%MatrixMarket matrix array real symmetric\n0 0\n1\n",
+		"%%MatrixMarket matrix array real skew-symmetric\n2 2\n1\n2\n3\n",
+		"%%MatrixMarket matrix array real symmetric\n1 5\n1\n",
+		"%%MatrixMarket matrix array complex hermitian\n2 2\n1 0\n2 0\n3 0\n4 0\n",
+		"%%MatrixMarket matrix array real general\n100000000 100000000\n",
+		"%%MatrixMarket matrix coordinate real general\n10 10 100000000000000\n",
+		"%%MatrixMarket matrix coordinate complex general\n100000000 100000000 1\n1 1 1 1\n",
+		"%%MatrixMarket matrix coordinate pattern general\n100000000000 1 0\n",
+	} {
+		f.Add([]byte(s))
+	}
+}
+
+func FuzzCOOUnmarshalTextFrom(f *testing.F) {
+
+	fuzzSeed(f)
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var m COO
+		m.UnmarshalTextFrom(bytes.NewReader(b), WithLimits(Limits{MaxEntries: 1 << 16}))
+	})
+}
+
+func FuzzDenseUnmarshalTextFrom(f *testing.F) {
+
+	fuzzSeed(f)
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var m Dense
+		m.UnmarshalTextFrom(bytes.NewReader(b), WithLimits(Limits{MaxEntries: 1 << 16}))
+	})
+}
+
+func FuzzCDenseUnmarshalTextFrom(f *testing.F) {
+
+	fuzzSeed(f)
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var m CDense
+		m.UnmarshalTextFrom(bytes.NewReader(b), WithLimits(Limits{MaxEntries: 1 << 16}))
+	})
+}
+
+func FuzzCoordinateUnmarshalTextFrom(f *testing.F) {
+
+	fuzzSeed(f)
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var m Coordinate[int32, float32]
+		m.UnmarshalTextFrom(bytes.NewReader(b), WithLimits(Limits{MaxEntries: 1 << 16}))
+	})
+}
+
+func FuzzArrayUnmarshalTextFrom(f *testing.F) {
+
+	fuzzSeed(f)
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var m Array[complex64]
+		m.UnmarshalTextFrom(bytes.NewReader(b), WithLimits(Limits{MaxEntries: 1 << 16}))
+	})
+}
+
+func FuzzPatternUnmarshalTextFrom(f *testing.F) {
+
+	fuzzSeed(f)
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var m Pattern[int32]
+		m.UnmarshalTextFrom(bytes.NewReader(b), WithLimits(Limits{MaxEntries: 1 << 16}))
+	})
+}
+
+// FuzzUnmarshalTextFrom reads each type without options, such that the
+// default read path, of no limits, is fuzzed.
+func FuzzUnmarshalTextFrom(f *testing.F) {
+
+	fuzzSeed(f)
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+
+		for _, m := range []interface {
+			UnmarshalTextFrom(io.Reader, ...ReadOption) (int, error)
+		}{
+			&COO{},
+			&Dense{},
+			&CDense{},
+			&Coordinate[int32, float32]{},
+			&Array[complex64]{},
+			&Pattern[int32]{},
+			&Pattern[int]{},
+		} {
+			m.UnmarshalTextFrom(bytes.NewReader(b))
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package market
+
+import (
+	"fmt"
+	"math"
+)
+
+// Integer is the type of the row and column indices of a Coordinate or
+// Pattern matrix. Compact index types, such as int32, halve the storage
+// of indices relative to int on 64-bit platforms.
+type Integer interface {
+	int | int32 | int64
+}
+
+// Scalar is the type of the elements of a Coordinate or Array matrix.
+// The real types, float32 and float64, hold matrices with a real, integer
+// or pattern field, and the complex types, complex64 and complex128, hold
+// matrices with a complex field.
+type Scalar interface {
+	float32 | float64 | complex64 | complex128
+}
+
+// isComplex reports whether T is a complex type.
+func isComplex[T Scalar]() bool {
+
+	var v T
+
+	switch any(v).(type) {
+	case complex64, complex128:
+		return true
+	}
+
+	return false
+}
+
+// bitSize returns the size of T in bits, as taken by strconv when
+// formatting values of T.
+func bitSize[T Scalar]() int {
+
+	var v T
+
+	switch any(v).(type) {
+	case float32:
+		return 32
+	case float64, complex64:
+		return 64
+	}
+
+	return 128
+}
+
+// widen returns v as a complex128, having a zero imaginary part if T is
+// real.
+func widen[T Scalar](v T) complex128 {
+
+	switch v := any(v).(type) {
+	case float32:
+		return complex(float64(v), 0)
+	case float64:
+		return complex(v, 0)
+	case complex64:
+		return complex128(v)
+	}
+
+	return any(v).(complex128)
+}
+
+// narrow returns v as a T, discarding the imaginary part if T is real.
+func narrow[T Scalar](v complex128) T {
+
+	var t T
+
+	switch p := any(&t).(type) {
+	case *float32:
+		*p = float32(real(v))
+	case *float64:
+		*p = real(v)
+	case *complex64:
+		*p = complex64(v)
+	case *complex128:
+		*p = v
+	}
+
+	return t
+}
+
+// maxIndex returns the greatest value representable by I.
+func maxIndex[I Integer]() int64 {
+
+	var i I
+
+	switch any(i).(type) {
+	case int32:
+		return math.MaxInt32
+	case int64:
+		return math.MaxInt64
+	}
+
+	return math.MaxInt
+}
+
+// checkIndex returns an error wrapping ErrIndexOverflow if the
+// (zero-indexed) indices of an M×N matrix are not representable by I.
+func checkIndex[I Integer](M, N int) error {
+
+	if m := maxIndex[I](); int64(M)-1 > m || int64(N)-1 > m {
+		return fmt.Errorf("%w: %d×%d matrix exceeds %T indices", ErrIndexOverflow, M, N, I(0))
+	}
+
+	return nil
+}
+
+// hasField reports whether a matrix of the field of t may be held with
+// elements of type T.
+func hasField[T Scalar](t *mmType) bool {
+	return t.isComplex() == isComplex[T]()
+}
+
+// parseScalar parses the value of an entry, which is one token for a
+// real value or two for a complex value.
+func parseScalar[T Scalar](toks []string) (T, error) {
+
+	re, err := parseFloat(toks[0])
+	if err != nil {
+		return 0, err
+	}
+
+	var im float64
+
+	if len(toks) > 1 {
+		if im, err = parseFloat(toks[1]); err != nil {
+			return 0, err
+		}
+	}
+
+	return narrow[T](complex(re, im)), nil
+}
+
+// transpose returns the element mirroring v across the diagonal of a
+// matrix of the given symmetry.
+func transpose[T Scalar](symmetry string, v T) T {
+
+	switch symmetry {
+
+	case mtxSymmetrySkew:
+		return -v
+
+	case mtxSymmetryHermitian:
+		w := widen(v)
+		return narrow[T](complex(real(w), -imag(w)))
+	}
+
+	return v
+}
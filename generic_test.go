@@ -0,0 +1,51 @@
+package market
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScalarConversions(t *testing.T) {
+
+	assert.False(t, isComplex[float32]())
+	assert.True(t, isComplex[complex64]())
+
+	assert.Equal(t, 32, bitSize[float32]())
+	assert.Equal(t, 64, bitSize[float64]())
+	assert.Equal(t, 64, bitSize[complex64]())
+	assert.Equal(t, 128, bitSize[complex128]())
+
+	assert.Equal(t, complex(0.5, 0), widen(float32(0.5)))
+	assert.Equal(t, complex(0.5, -1), widen(complex64(complex(0.5, -1))))
+	assert.Equal(t, float32(0.5), narrow[float32](complex(0.5, 1)))
+	assert.Equal(t, complex64(complex(0.5, 1)), narrow[complex64](complex(0.5, 1)))
+
+	assert.Equal(t, float32(-2), transpose(mtxSymmetrySkew, float32(2)))
+	assert.Equal(t, complex64(1-2i), transpose(mtxSymmetryHermitian, complex64(1+2i)))
+	assert.Equal(t, complex64(1+2i), transpose(mtxSymmetrySymm, complex64(1+2i)))
+}
+
+func TestCheckIndex(t *testing.T) {
+
+	assert.Nil(t, checkIndex[int32](1<<31, 1))
+	assert.True(t, errors.Is(checkIndex[int32](1<<31+1, 1), ErrIndexOverflow))
+	assert.True(t, errors.Is(checkIndex[int32](1, 1<<31+1), ErrIndexOverflow))
+	assert.Nil(t, checkIndex[int64](1<<31+1, 1))
+	assert.Nil(t, checkIndex[int](1<<31+1, 1))
+}
+
+func TestParseScalar(t *testing.T) {
+
+	v, err := parseScalar[float32]([]string{"0.1"})
+	assert.Nil(t, err)
+	assert.Equal(t, float32(0.1), v)
+
+	z, err := parseScalar[complex64]([]string{"1", "-2"})
+	assert.Nil(t, err)
+	assert.Equal(t, complex64(1-2i), z)
+
+	_, err = parseScalar[float32]([]string{"x"})
+	assert.True(t, errors.Is(err, ErrInputScanError))
+}
@@ -1,6 +1,6 @@
 module github.com/wamuir/matrix-market
 
-go 1.22
+go 1.23
 
 require (
 	github.com/james-bowman/sparse v0.0.0-20210729090128-1e6c7dd483e9
@@ -0,0 +1,134 @@
+package market
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/james-bowman/sparse"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// ToGraph returns the graph of which the receiver is the adjacency
+// matrix, of nodes having the ids 0, ..., n-1 of the rows and columns of
+// the matrix. The graph is a *simple.UndirectedGraph, or a
+// *simple.WeightedUndirectedGraph unless of the pattern field, if the
+// matrix is symmetric, and is otherwise a *simple.DirectedGraph or a
+// *simple.WeightedDirectedGraph. Duplicate entries are summed. As simple
+// graphs have no self-loops, the diagonal is dropped. An error wrapping
+// ErrInvalidGraph is returned if the matrix is not square.
+func (m *COO) ToGraph() (graph.Graph, error) {
+
+	M, N := m.mat.Dims()
+	if M != N {
+		return nil, fmt.Errorf("%w: %d×%d matrix is not square", ErrInvalidGraph, M, N)
+	}
+
+	undirected := strings.EqualFold(m.Symmetry, mtxSymmetrySymm)
+	weighted := !strings.EqualFold(m.Field, mtxFieldPattern)
+
+	// the lower triangle of a symmetric matrix gives each edge once
+	c := newTriplets[int, float64](m.mat.NNZ(), DuplicateSum, false)
+	m.Do(func(i, j int, v float64) {
+		if i != j && (!undirected || i > j) {
+			c.add(i, j, v)
+		}
+	})
+	c.sort(OrderRowMajor)
+
+	var (
+		addNode func(graph.Node)
+		addEdge func(u, v graph.Node, w float64)
+		g       graph.Graph
+	)
+
+	switch {
+
+	case undirected && weighted:
+		h := simple.NewWeightedUndirectedGraph(0, 0)
+		addNode = h.AddNode
+		addEdge = func(u, v graph.Node, w float64) { h.SetWeightedEdge(h.NewWeightedEdge(u, v, w)) }
+		g = h
+
+	case undirected:
+		h := simple.NewUndirectedGraph()
+		addNode = h.AddNode
+		addEdge = func(u, v graph.Node, _ float64) { h.SetEdge(h.NewEdge(u, v)) }
+		g = h
+
+	case weighted:
+		h := simple.NewWeightedDirectedGraph(0, 0)
+		addNode = h.AddNode
+		addEdge = func(u, v graph.Node, w float64) { h.SetWeightedEdge(h.NewWeightedEdge(u, v, w)) }
+		g = h
+
+	default:
+		h := simple.NewDirectedGraph()
+		addNode = h.AddNode
+		addEdge = func(u, v graph.Node, _ float64) { h.SetEdge(h.NewEdge(u, v)) }
+		g = h
+	}
+
+	for i := 0; i < N; i++ {
+		addNode(simple.Node(i))
+	}
+
+	c.Do(func(i, j int, v float64) {
+		addEdge(simple.Node(i), simple.Node(j), v)
+	})
+
+	return g, nil
+}
+
+// NewCOOFromGraph returns the adjacency matrix of g, of which the rows and
+// columns are the nodes of g in ascending order of id, and the ids of the
+// nodes. The matrix is symmetric if g is a graph.Undirected, and is of the
+// real field if g is a graph.Weighted, holding the weights of its edges,
+// and otherwise of the pattern field.
+func NewCOOFromGraph(g graph.Graph) (*COO, []int64) {
+
+	var ids []int64
+	for nodes := g.Nodes(); nodes.Next(); {
+		ids = append(ids, nodes.Node().ID())
+	}
+	slices.Sort(ids)
+
+	index := make(map[int64]int, len(ids))
+	for k, id := range ids {
+		index[id] = k
+	}
+
+	wg, weighted := g.(graph.Weighted)
+
+	var rows, cols []int
+	var data []float64
+
+	for i, uid := range ids {
+		for nodes := g.From(uid); nodes.Next(); {
+
+			vid := nodes.Node().ID()
+
+			w := 1.0
+			if weighted {
+				w = wg.WeightedEdge(uid, vid).Weight()
+			}
+
+			rows, cols, data = append(rows, i), append(cols, index[vid]), append(data, w)
+		}
+	}
+
+	field := mtxFieldPattern
+	if weighted {
+		field = mtxFieldReal
+	}
+
+	symmetry := mtxSymmetryGeneral
+	if _, ok := g.(graph.Undirected); ok {
+		symmetry = mtxSymmetrySymm
+	}
+
+	d := sparse.NewCOO(len(ids), len(ids), rows, cols, data)
+
+	return &COO{mtxObjectMatrix, mtxFormatCoordinate, field, symmetry, d}, ids
+}
@@ -0,0 +1,113 @@
+package market
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestCOOToGraph(t *testing.T) {
+
+	// the lower triangle and the diagonal of a symmetric matrix
+	c := sparse.NewCOO(3, 3, []int{0, 1, 0, 2, 1}, []int{0, 0, 1, 1, 2}, []float64{9, 2, 2, 3, 3})
+
+	for _, test := range []struct {
+		field    string
+		symmetry string
+		check    func(g graph.Graph)
+	}{
+		{mtxFieldPattern, mtxSymmetrySymm, func(g graph.Graph) {
+			if assert.IsType(t, &simple.UndirectedGraph{}, g) {
+				assert.True(t, g.(*simple.UndirectedGraph).HasEdgeBetween(0, 1))
+				assert.Equal(t, 2, g.(*simple.UndirectedGraph).Edges().Len())
+			}
+		}},
+		{mtxFieldReal, mtxSymmetrySymm, func(g graph.Graph) {
+			if assert.IsType(t, &simple.WeightedUndirectedGraph{}, g) {
+				w, ok := g.(*simple.WeightedUndirectedGraph).Weight(2, 1)
+				assert.True(t, ok)
+				assert.Equal(t, 3.0, w)
+			}
+		}},
+		{mtxFieldPattern, mtxSymmetryGeneral, func(g graph.Graph) {
+			if assert.IsType(t, &simple.DirectedGraph{}, g) {
+				assert.True(t, g.(*simple.DirectedGraph).HasEdgeFromTo(1, 0))
+				assert.Equal(t, 4, g.(*simple.DirectedGraph).Edges().Len())
+			}
+		}},
+		{mtxFieldInteger, mtxSymmetryGeneral, func(g graph.Graph) {
+			if assert.IsType(t, &simple.WeightedDirectedGraph{}, g) {
+				w, ok := g.(*simple.WeightedDirectedGraph).Weight(2, 1)
+				assert.True(t, ok)
+				assert.Equal(t, 3.0, w)
+			}
+		}},
+	} {
+		m := &COO{mtxObjectMatrix, mtxFormatCoordinate, test.field, test.symmetry, c}
+
+		g, err := m.ToGraph()
+		if assert.NoError(t, err) {
+			assert.Equal(t, 3, g.Nodes().Len())
+			test.check(g)
+		}
+	}
+
+	// header fields are compared without regard to case
+	g, err := (&COO{mtxObjectMatrix, mtxFormatCoordinate, "Pattern", "Symmetric", c}).ToGraph()
+	if assert.NoError(t, err) {
+		assert.IsType(t, &simple.UndirectedGraph{}, g)
+	}
+
+	_, err = NewCOO(mtx01).ToGraph()
+	assert.ErrorIs(t, err, ErrInvalidGraph)
+}
+
+func TestNewCOOFromGraph(t *testing.T) {
+
+	// a weighted undirected graph of sparse node ids
+	g := simple.NewWeightedUndirectedGraph(0, 0)
+	g.AddNode(simple.Node(30))
+	g.SetWeightedEdge(g.NewWeightedEdge(simple.Node(20), simple.Node(10), 2.5))
+
+	m, ids := NewCOOFromGraph(g)
+
+	assert.Equal(t, []int64{10, 20, 30}, ids)
+	assert.Equal(t, mtxFieldReal, m.Field)
+	assert.Equal(t, mtxSymmetrySymm, m.Symmetry)
+	assert.True(t, mat.Equal(mat.NewDense(3, 3, []float64{
+		0, 2.5, 0,
+		2.5, 0, 0,
+		0, 0, 0,
+	}), m.ToCOO()))
+
+	// only the lower triangle is written
+	var b bytes.Buffer
+
+	_, err := m.MarshalTextTo(&b, WriteCompact())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "%%MatrixMarket matrix coordinate real symmetric\n%\n3 3 1\n2 1 2.5\n", b.String())
+	}
+
+	// an unweighted directed graph
+	d := simple.NewDirectedGraph()
+	d.SetEdge(d.NewEdge(simple.Node(0), simple.Node(1)))
+
+	m, ids = NewCOOFromGraph(d)
+
+	assert.Equal(t, []int64{0, 1}, ids)
+	assert.Equal(t, mtxFieldPattern, m.Field)
+	assert.Equal(t, mtxSymmetryGeneral, m.Symmetry)
+	assert.True(t, mat.Equal(mat.NewDense(2, 2, []float64{0, 1, 0, 0}), m.ToCOO()))
+
+	// round trip
+	r, err := m.ToGraph()
+	if assert.NoError(t, err) {
+		assert.True(t, r.(*simple.DirectedGraph).HasEdgeFromTo(0, 1))
+		assert.False(t, r.(*simple.DirectedGraph).HasEdgeFromTo(1, 0))
+	}
+}
@@ -0,0 +1,1036 @@
+package market
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/james-bowman/sparse"
+)
+
+// HBInfo holds the description and supplementary data of a matrix in
+// Harwell-Boeing or Rutherford-Boeing format, which are read and written
+// along with the matrix by UnmarshalHBFrom and MarshalHBTo. The
+// right-hand sides, starting guesses and exact solutions are each held
+// in column major order as NRHS vectors of the M rows of the matrix, such
+// that element i of vector k is RHS[i+k*M]. Guess and Solution are nil if
+// absent.
+type HBInfo[T float64 | complex128] struct {
+	Title      string // title, of at most 72 characters
+	Key        string // key, of at most 8 characters
+	Type       string // type code, such as "RUA", as read
+	Rutherford bool   // whether in Rutherford-Boeing format
+	NRHS       int    // number of right-hand sides
+	RHS        []T
+	Guess      []T
+	Solution   []T
+}
+
+// hbHeader is the header of a Harwell-Boeing or Rutherford-Boeing file.
+type hbHeader struct {
+	title, key     string
+	mxtype         string
+	t              mmType
+	M, N, nnz      int
+	valcrd, rhscrd int
+	ptrfmt, indfmt fortranFormat
+	valfmt, rhsfmt fortranFormat
+	rhstyp         string
+	nrhs           int
+}
+
+// fortranFormat is a Fortran edit descriptor for a line of fixed-width
+// fields, such as (10I8) or (1P,4E20.12).
+type fortranFormat struct {
+	repeat int  // number of fields per line
+	kind   byte // descriptor: I, E, D, F or G
+	width  int  // width of each field
+	digits int  // number of digits after the decimal point
+}
+
+// fortranFormatRE matches a Fortran format having a single, repeated edit
+// descriptor, optionally preceded by a scale factor.
+var fortranFormatRE = regexp.MustCompile(`^\(?(?:[-+]?\d+P,?)?(\d*)(ES|EN|[IEDFG])(\d+)(?:\.(\d+))?(?:E\d+)?\)?$`)
+
+// parseFortranFormat parses a Fortran format, as of the pointers, indices
+// and values of a Harwell-Boeing file.
+func parseFortranFormat(s string) (fortranFormat, error) {
+
+	s = strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+
+	m := fortranFormatRE.FindStringSubmatch(s)
+	if m == nil {
+		return fortranFormat{}, fmt.Errorf("%w: unsupported fortran format %q", ErrInputScanError, s)
+	}
+
+	f := fortranFormat{repeat: 1, kind: m[2][0]}
+
+	if m[1] != "" {
+		f.repeat, _ = strconv.Atoi(m[1])
+	}
+
+	f.width, _ = strconv.Atoi(m[3])
+
+	if m[4] != "" {
+		f.digits, _ = strconv.Atoi(m[4])
+	}
+
+	if f.repeat < 1 || f.width < 1 {
+		return fortranFormat{}, fmt.Errorf("%w: unsupported fortran format %q", ErrInputScanError, s)
+	}
+
+	return f, nil
+}
+
+// parseFortranInt parses an integer field, which is zero if blank.
+func parseFortranInt(tok string) (int, error) {
+
+	if tok == "" {
+		return 0, nil
+	}
+
+	return parseInt(tok)
+}
+
+// parseFortranFloat parses a real field of format f, which is zero if
+// blank. Fortran permits a D exponent, an exponent without a letter (as
+// in 1.5-300) and, absent a decimal point, that the rightmost digits are
+// the fractional part, per the digits of f.
+func parseFortranFloat(tok string, f fortranFormat) (float64, error) {
+
+	if tok == "" {
+		return 0, nil
+	}
+
+	b := []byte(strings.ToUpper(tok))
+
+	// split the mantissa and exponent
+	e := -1
+	for k := 0; k < len(b); k++ {
+
+		switch b[k] {
+
+		case 'D', 'Q':
+			b[k] = 'E'
+			e = k
+
+		case 'E':
+			e = k
+
+		case '+', '-':
+			if k > 0 && e < 0 && b[k-1] != 'E' {
+				b = append(b[:k], append([]byte{'E'}, b[k:]...)...)
+				e = k
+			}
+		}
+
+		if e >= 0 {
+			break
+		}
+	}
+
+	mant, exp := b, []byte(nil)
+	if e >= 0 {
+		mant, exp = b[:e], b[e:]
+	}
+
+	// infinities and NaNs have no digits to be scaled
+	if f.kind != 'I' && f.digits > 0 && !strings.ContainsAny(string(mant), ".INFA") {
+
+		digits := strings.TrimLeft(string(mant), "+-")
+		sign := string(mant[:len(mant)-len(digits)])
+
+		if n := f.digits - len(digits); n > 0 {
+			digits = strings.Repeat("0", n) + digits
+		}
+
+		k := len(digits) - f.digits
+		mant = []byte(sign + digits[:k] + "." + digits[k:])
+	}
+
+	return parseFloat(string(mant) + string(exp))
+}
+
+// scanFixed reads n fixed-width fields of format f from the lines of the
+// scanner, calling fn with each, trimmed of spaces.
+func scanFixed(scanner *lineScanner, f fortranFormat, n int, fn func(tok string) error) error {
+
+	for n > 0 {
+
+		if !scanner.Scan() {
+
+			if err := scanError(scanner.Scanner); err != nil {
+				return err
+			}
+
+			return scanner.errorf(scanner.line, fmt.Errorf("%w: fewer values than expected", ErrInputScanError))
+		}
+
+		line := scanner.Text()
+
+		for k := 0; k < f.repeat && n > 0; k++ {
+
+			a := k * f.width
+			if a >= len(line) {
+				break
+			}
+
+			tok := strings.TrimSpace(line[a:min(a+f.width, len(line))])
+			if err := fn(tok); err != nil {
+				return scanner.errorf(scanner.line, err)
+			}
+
+			n--
+		}
+	}
+
+	return nil
+}
+
+// scanHBHeader reads the header of a Harwell-Boeing or Rutherford-Boeing
+// file, checking the size of the matrix against the limits.
+func scanHBHeader(scanner *lineScanner, o *readOptions) (*hbHeader, error) {
+
+	var h hbHeader
+
+	lines := make([]string, 0, 5)
+	for len(lines) < 4 && scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if err := scanError(scanner.Scanner); err != nil {
+		return nil, err
+	}
+
+	if len(lines) < 4 {
+		return nil, scanner.errorf(scanner.line, ErrPrematureEOF)
+	}
+
+	// title (A72) and key (A8)
+	line := lines[0]
+	h.title = strings.TrimSpace(line[:min(72, len(line))])
+	if len(line) > 72 {
+		h.key = strings.TrimSpace(line[72:min(80, len(line))])
+	}
+
+	// number of lines of each block (5I14), of which there are only four
+	// in Rutherford-Boeing files, having no right-hand sides
+	crd, err := hbInts(lines[1], 5)
+	if err != nil {
+		return nil, scanner.errorf(2, err)
+	}
+	h.valcrd, h.rhscrd = crd[3], crd[4]
+
+	// type code (A3), and size of the matrix (11X, 4I14)
+	line = lines[2]
+	if len(line) < 3 {
+		return nil, scanner.errorf(3, ErrUnsupportedType)
+	}
+	h.mxtype = strings.TrimSpace(line[:3])
+
+	size, err := hbInts(line[3:], 4)
+	if err != nil {
+		return nil, scanner.errorf(3, err)
+	}
+	h.M, h.N, h.nnz = size[0], size[1], size[2]
+
+	t, ok := hbType(h.mxtype)
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+	h.t = *t
+
+	if h.M < 0 || h.N < 0 || h.nnz < 0 || (!h.t.isGeneral() && h.M != h.N) {
+		return nil, scanner.errorf(3, ErrInvalidSize)
+	}
+
+	if _, err := elements(h.M, h.N); err != nil {
+		return nil, err
+	}
+
+	if err := o.limits.checkSize(h.M, h.N, h.nnz); err != nil {
+		return nil, err
+	}
+
+	if err := o.xform.check(h.M, h.N); err != nil {
+		return nil, err
+	}
+
+	// formats of the pointers, indices, values and right-hand sides
+	fmts := appendFields(nil, lines[3])
+	if len(fmts) < 2 {
+		return nil, scanner.errorf(4, fmt.Errorf("%w: missing fortran formats", ErrInputScanError))
+	}
+
+	if h.ptrfmt, err = parseFortranFormat(fmts[0]); err != nil {
+		return nil, scanner.errorf(4, err)
+	}
+
+	if h.indfmt, err = parseFortranFormat(fmts[1]); err != nil {
+		return nil, scanner.errorf(4, err)
+	}
+
+	if h.valcrd > 0 && !h.t.isPattern() {
+		if len(fmts) < 3 {
+			return nil, scanner.errorf(4, fmt.Errorf("%w: missing fortran format of values", ErrInputScanError))
+		}
+		if h.valfmt, err = parseFortranFormat(fmts[2]); err != nil {
+			return nil, scanner.errorf(4, err)
+		}
+	}
+
+	if h.rhscrd <= 0 {
+		return &h, nil
+	}
+
+	if len(fmts) < 4 {
+		return nil, scanner.errorf(4, fmt.Errorf("%w: missing fortran format of right-hand sides", ErrInputScanError))
+	}
+
+	if h.rhsfmt, err = parseFortranFormat(fmts[3]); err != nil {
+		return nil, scanner.errorf(4, err)
+	}
+
+	// right-hand side type (A3), and number of right-hand sides (11X,
+	// 2I14)
+	if !scanner.Scan() {
+		if err := scanError(scanner.Scanner); err != nil {
+			return nil, err
+		}
+		return nil, scanner.errorf(scanner.line, ErrPrematureEOF)
+	}
+
+	line = scanner.Text()
+	if len(line) < 3 {
+		return nil, scanner.errorf(5, ErrUnsupportedType)
+	}
+	h.rhstyp = strings.ToUpper(line[:3])
+
+	rhs, err := hbInts(line[3:], 2)
+	if err != nil {
+		return nil, scanner.errorf(5, err)
+	}
+	h.nrhs = rhs[0]
+
+	if h.nrhs < 0 {
+		return nil, scanner.errorf(5, ErrInvalidSize)
+	}
+
+	// right-hand sides are stored densely
+	if n, err := elements(h.M, h.nrhs); err != nil {
+		return nil, err
+	} else if err := o.limits.checkSize(h.M, h.nrhs, n); err != nil {
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+// hbInts parses the integers of a line of the header, of which there are
+// at most n. Missing integers are zero.
+func hbInts(line string, n int) ([]int, error) {
+
+	v := make([]int, n)
+
+	for k, tok := range appendFields(nil, line) {
+
+		if k == n {
+			break
+		}
+
+		var err error
+		if v[k], err = parseInt(tok); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+// hbType returns the Matrix Market type of a Harwell-Boeing or
+// Rutherford-Boeing type code, reporting false if the type code is not
+// supported. Only assembled matrices are supported.
+func hbType(code string) (*mmType, bool) {
+
+	code = strings.ToUpper(code)
+	if len(code) != 3 || code[2] != 'A' {
+		return nil, false
+	}
+
+	t := mmType{Object: mtxObjectMatrix, Format: mtxFormatCoordinate}
+
+	switch code[0] {
+	case 'R':
+		t.Field = mtxFieldReal
+	case 'C':
+		t.Field = mtxFieldComplex
+	case 'I':
+		t.Field = mtxFieldInteger
+	case 'P', 'Q':
+		t.Field = mtxFieldPattern
+	default:
+		return nil, false
+	}
+
+	switch code[1] {
+	case 'U', 'R':
+		t.Symmetry = mtxSymmetryGeneral
+	case 'S':
+		t.Symmetry = mtxSymmetrySymm
+	case 'Z':
+		t.Symmetry = mtxSymmetrySkew
+	case 'H':
+		t.Symmetry = mtxSymmetryHermitian
+	default:
+		return nil, false
+	}
+
+	if !t.isSupported() {
+		return nil, false
+	}
+
+	return &t, true
+}
+
+// hbCode returns the Harwell-Boeing or Rutherford-Boeing type code of an
+// M×N matrix of Matrix Market type t. Integer matrices are real in
+// Harwell-Boeing format.
+func hbCode(t *mmType, M, N int, rutherford bool) string {
+
+	code := []byte("RUA")
+
+	switch {
+	case t.isComplex():
+		code[0] = 'C'
+	case t.isPattern():
+		code[0] = 'P'
+	case t.isInteger() && rutherford:
+		code[0] = 'I'
+	}
+
+	switch {
+	case t.isSymmetric():
+		code[1] = 'S'
+	case t.isSkew():
+		code[1] = 'Z'
+	case t.isHermitian():
+		code[1] = 'H'
+	case M != N:
+		code[1] = 'R'
+	}
+
+	if rutherford {
+		return strings.ToLower(string(code))
+	}
+
+	return string(code)
+}
+
+// scanHBData reads the compressed sparse column form of the matrix of a
+// Harwell-Boeing file, and its right-hand sides into info, if not nil,
+// calling fn with each (zero-indexed) entry in the order stored. Pattern
+// entries have a value of one.
+func scanHBData[T float64 | complex128](scanner *lineScanner, h *hbHeader, info *HBInfo[T], fn func(i, j int, v T) error) error {
+
+	// pointers, indices and values are accumulated as read, such that
+	// storage is bounded by the input rather than by the header
+	ptr := make([]int, 0, prealloc(h.N+1))
+	err := scanFixed(scanner, h.ptrfmt, h.N+1, func(tok string) error {
+		p, err := parseFortranInt(tok)
+		ptr = append(ptr, p-1)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// column pointers are one-indexed and non-decreasing
+	line := scanner.line
+	if ptr[0] != 0 || ptr[h.N] != h.nnz {
+		return scanner.errorf(line, fmt.Errorf("%w: column pointers do not span %d entries", ErrInputScanError, h.nnz))
+	}
+	for j := 0; j < h.N; j++ {
+		if ptr[j] > ptr[j+1] {
+			return scanner.errorf(line, fmt.Errorf("%w: column pointers are decreasing", ErrInputScanError))
+		}
+	}
+
+	ind := make([]int, 0, prealloc(h.nnz))
+	err = scanFixed(scanner, h.indfmt, h.nnz, func(tok string) error {
+		i, err := parseFortranInt(tok)
+		if err == nil && (i < 1 || i > h.M) {
+			err = ErrIndexOutOfRange
+		}
+		ind = append(ind, i-1)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// pattern entries, having no values, are of one
+	var vals []T
+	if !h.t.isPattern() && h.valcrd > 0 {
+		if vals, err = scanHBValues[T](scanner, h.valfmt, h.nnz); err != nil {
+			return err
+		}
+	}
+
+	for j := 0; j < h.N; j++ {
+		for p := ptr[j]; p < ptr[j+1]; p++ {
+
+			i, v := ind[p], T(1)
+			if vals != nil {
+				v = vals[p]
+			}
+
+			if i < j && !h.t.isGeneral() {
+				return scanner.errorf(line, fmt.Errorf("%w: entry (%d, %d) is above the diagonal of a %s matrix", ErrInputScanError, i+1, j+1, h.t.Symmetry))
+			}
+
+			// if off diagonal, set value for symm element
+			if i != j && !h.t.isGeneral() {
+				if err := fn(j, i, transpose(h.t.Symmetry, v)); err != nil {
+					return scanner.errorf(line, err)
+				}
+			}
+
+			if err := fn(i, j, v); err != nil {
+				return scanner.errorf(line, err)
+			}
+		}
+	}
+
+	if info == nil {
+		return nil
+	}
+
+	info.Title = h.title
+	info.Key = h.key
+	info.Type = h.mxtype
+	info.Rutherford = h.mxtype != strings.ToUpper(h.mxtype)
+	info.NRHS = 0
+	info.RHS, info.Guess, info.Solution = nil, nil, nil
+
+	if h.rhscrd <= 0 || h.nrhs == 0 {
+		return nil
+	}
+
+	// only full (rather than sparse) right-hand sides are supported
+	if h.rhstyp[0] != 'F' {
+		return ErrUnsupportedType
+	}
+
+	n, err := elements(h.M, h.nrhs)
+	if err != nil {
+		return err
+	}
+
+	info.NRHS = h.nrhs
+
+	if info.RHS, err = scanHBValues[T](scanner, h.rhsfmt, n); err != nil {
+		return err
+	}
+
+	if h.rhstyp[1] == 'G' {
+		if info.Guess, err = scanHBValues[T](scanner, h.rhsfmt, n); err != nil {
+			return err
+		}
+	}
+
+	if h.rhstyp[2] == 'X' {
+		if info.Solution, err = scanHBValues[T](scanner, h.rhsfmt, n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scanHBValues reads L values, of format f, of which both the real and
+// imaginary part are read if T is complex. Values are accumulated as
+// read, such that storage is bounded by the input rather than by L.
+func scanHBValues[T float64 | complex128](scanner *lineScanner, f fortranFormat, L int) ([]T, error) {
+
+	var re float64
+
+	dst := make([]T, 0, prealloc(L))
+
+	n := L
+	if isComplex[T]() {
+		n *= 2
+	}
+
+	err := scanFixed(scanner, f, n, func(tok string) error {
+
+		v, err := parseFortranFloat(tok, f)
+		if err != nil {
+			return err
+		}
+
+		switch {
+
+		case !isComplex[T]():
+			dst = append(dst, narrow[T](complex(v, 0)))
+
+		case n%2 == 0:
+			re = v
+
+		default:
+			dst = append(dst, narrow[T](complex(re, v)))
+		}
+
+		n--
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dst, nil
+}
+
+// hbWriter writes the fixed-width lines of a Harwell-Boeing file,
+// tallying the bytes written and retaining the first error.
+type hbWriter struct {
+	w     io.Writer
+	total int
+	err   error
+	buf   []byte
+}
+
+// line writes s as a line.
+func (hw *hbWriter) line(s string) {
+
+	if hw.err != nil {
+		return
+	}
+
+	hw.buf = append(append(hw.buf[:0], s...), '\n')
+
+	n, err := hw.w.Write(hw.buf)
+	hw.total += n
+
+	if err != nil {
+		hw.err = ErrUnwritable
+	}
+}
+
+// fields writes the n fields returned by field, as lines of f.repeat
+// fields.
+func (hw *hbWriter) fields(f fortranFormat, n int, field func(k int) string) {
+
+	var b strings.Builder
+
+	for k := 0; k < n; k++ {
+
+		fmt.Fprintf(&b, "%*s", f.width, field(k))
+
+		if (k+1)%f.repeat == 0 || k == n-1 {
+			hw.line(b.String())
+			b.Reset()
+		}
+	}
+}
+
+// lines returns the number of lines of n fields of format f.
+func (f fortranFormat) lines(n int) int {
+	return (n + f.repeat - 1) / f.repeat
+}
+
+// String returns the format, as written in the header.
+func (f fortranFormat) String() string {
+
+	if f.kind == 'I' {
+		return fmt.Sprintf("(%dI%d)", f.repeat, f.width)
+	}
+
+	return fmt.Sprintf("(1P,%dE%d.%dE3)", f.repeat, f.width, f.digits)
+}
+
+// hbIntFormat returns an integer format for values of at most max, with
+// as many fields as fit a line of 80 characters.
+func hbIntFormat(max int) fortranFormat {
+	w := len(strconv.Itoa(max)) + 1
+	return fortranFormat{repeat: 80 / w, kind: 'I', width: w}
+}
+
+// hbRealFormat is the format of written values, having the 17
+// significant digits of a float64 such that values are read exactly.
+var hbRealFormat = fortranFormat{repeat: 3, kind: 'E', width: 25, digits: 16}
+
+// formatFortranE formats v with the E edit descriptor of f, with a
+// scale factor of one and an exponent of three digits.
+func formatFortranE(v float64, f fortranFormat) string {
+
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return strconv.FormatFloat(v, 'E', -1, 64)
+	}
+
+	s := strconv.FormatFloat(v, 'E', f.digits, 64)
+
+	// pad the exponent to three digits
+	e := strings.IndexByte(s, 'E')
+	exp := s[e+2:]
+	for len(exp) < 3 {
+		exp = "0" + exp
+	}
+
+	return s[:e+2] + exp
+}
+
+// writeHB writes the M×N matrix of type t, the entries of which are
+// enumerated by do, and the right-hand sides of info, if not nil, in
+// Harwell-Boeing or Rutherford-Boeing format, per info.
+func writeHB[T float64 | complex128](w io.Writer, t *mmType, M, N int, do func(fn func(i, j int, v T)), info *HBInfo[T], o *writeOptions) (int, error) {
+
+	if info == nil {
+		info = &HBInfo[T]{}
+	}
+
+	// entries are written in compressed sparse column form, of which only
+	// the lower triangle of a symmetric matrix is stored
+	c := newTriplets[int, T](0, o.duplicates, false)
+
+	var err error
+	do(stored(t.Symmetry, func(i, j int, v T) {
+		if err == nil {
+			_, err = c.add(i, j, v)
+		}
+	}))
+	if err != nil {
+		return 0, err
+	}
+
+	c.sort(OrderColMajor)
+
+	if o.zeros == ZeroDrop {
+		c.dropZeros()
+	}
+
+	nnz := len(c.data)
+
+	ptr := make([]int, N+1)
+	for _, j := range c.cols {
+		ptr[j+1]++
+	}
+	for j := 0; j < N; j++ {
+		ptr[j+1] += ptr[j]
+	}
+
+	// right-hand sides are written only in Harwell-Boeing format, and
+	// only if given
+	nrhs := info.NRHS
+	if info.Rutherford {
+		nrhs = 0
+	}
+
+	n := M * nrhs
+	for _, v := range [][]T{info.RHS, info.Guess, info.Solution} {
+		if nrhs > 0 && v != nil && len(v) != n {
+			return 0, fmt.Errorf("%w: %d right-hand side values for %d×%d vectors", ErrInvalidSize, len(v), M, nrhs)
+		}
+	}
+	if nrhs > 0 && info.RHS == nil {
+		return 0, fmt.Errorf("%w: missing right-hand sides", ErrInvalidSize)
+	}
+
+	var (
+		ptrfmt = hbIntFormat(nnz + 1)
+		indfmt = hbIntFormat(M)
+		valfmt = hbRealFormat
+	)
+
+	// values of a complex matrix are written as both real and imaginary
+	// parts
+	parts := 1
+	if isComplex[T]() {
+		parts = 2
+	}
+
+	integer := t.isInteger() && info.Rutherford
+	if integer {
+		var max float64
+		for _, v := range c.data {
+			max = math.Max(max, math.Abs(real(widen(v))))
+		}
+		valfmt = hbIntFormat(int(max))
+		valfmt.width++
+		valfmt.repeat = 80 / valfmt.width
+	}
+
+	var (
+		ptrcrd = ptrfmt.lines(N + 1)
+		indcrd = indfmt.lines(nnz)
+		valcrd = valfmt.lines(nnz * parts)
+		rhscrd int
+	)
+
+	if t.isPattern() {
+		valcrd = 0
+	}
+
+	var rhstyp string
+	if nrhs > 0 {
+		rhstyp = "F"
+		rhscrd = valfmt.lines(n * parts)
+		if info.Guess != nil {
+			rhstyp += "G"
+			rhscrd += valfmt.lines(n * parts)
+		} else {
+			rhstyp += " "
+		}
+		if info.Solution != nil {
+			rhstyp += "X"
+			rhscrd += valfmt.lines(n * parts)
+		}
+	}
+
+	hw := hbWriter{w: w}
+
+	hw.line(fmt.Sprintf("%-72.72s%-8.8s", info.Title, info.Key))
+
+	if info.Rutherford {
+		hw.line(fmt.Sprintf("%14d%14d%14d%14d", ptrcrd+indcrd+valcrd, ptrcrd, indcrd, valcrd))
+	} else {
+		hw.line(fmt.Sprintf("%14d%14d%14d%14d%14d", ptrcrd+indcrd+valcrd+rhscrd, ptrcrd, indcrd, valcrd, rhscrd))
+	}
+
+	hw.line(fmt.Sprintf("%-3s%11s%14d%14d%14d%14d", hbCode(t, M, N, info.Rutherford), "", M, N, nnz, 0))
+
+	switch {
+	case nrhs == 0 && t.isPattern():
+		hw.line(fmt.Sprintf("%-16s%-16s", ptrfmt, indfmt))
+	case nrhs == 0:
+		hw.line(fmt.Sprintf("%-16s%-16s%-20s", ptrfmt, indfmt, valfmt))
+	default:
+		hw.line(fmt.Sprintf("%-16s%-16s%-20s%-20s", ptrfmt, indfmt, valfmt, valfmt))
+		hw.line(fmt.Sprintf("%-3s%11s%14d%14d", rhstyp, "", nrhs, 0))
+	}
+
+	hw.fields(ptrfmt, N+1, func(k int) string {
+		return strconv.Itoa(ptr[k] + 1)
+	})
+
+	hw.fields(indfmt, nnz, func(k int) string {
+		return strconv.Itoa(c.rows[k] + 1)
+	})
+
+	// field returns part k of the values of v
+	field := func(v []T) func(k int) string {
+		return func(k int) string {
+
+			x := widen(v[k/parts])
+
+			f := real(x)
+			if k%parts == 1 {
+				f = imag(x)
+			}
+
+			if integer {
+				return strconv.FormatFloat(f, 'f', 0, 64)
+			}
+
+			return formatFortranE(f, valfmt)
+		}
+	}
+
+	if !t.isPattern() {
+		hw.fields(valfmt, nnz*parts, field(c.data))
+	}
+
+	if nrhs > 0 {
+		for _, v := range [][]T{info.RHS, info.Guess, info.Solution} {
+			if v != nil {
+				hw.fields(valfmt, n*parts, field(v))
+			}
+		}
+	}
+
+	return hw.total, hw.err
+}
+
+// UnmarshalHBFrom deserializes r from Harwell-Boeing or Rutherford-Boeing
+// format into the receiver, as configured by opts, and reads the title,
+// key and right-hand sides of the file into info, if not nil. The matrix
+// must be real, integer or pattern.
+func (m *COO) UnmarshalHBFrom(r io.Reader, info *HBInfo[float64], opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	scanner := newScanner(r, o)
+
+	h, err := scanHBHeader(scanner, o)
+	if err != nil {
+		return n.total, err
+	}
+
+	if h.t.isComplex() {
+		return n.total, ErrUnsupportedType
+	}
+
+	// indices are held as transformed by any selection or transform
+	// options
+	Mx, Nx := o.xform.dims(h.M, h.N)
+
+	c := newTriplets[int, float64](h.nnz, o.duplicates, false)
+
+	c.x = o.xform
+	if h.t.isPattern() {
+		c.x = o.xform.indices()
+	}
+
+	err = scanHBData(scanner, h, info, func(i, j int, v float64) error {
+
+		if v == 0 && o.zeros == ZeroDrop {
+			return nil
+		}
+
+		_, err := c.add(i, j, v)
+		return err
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	d := sparse.NewCOO(Mx, Nx, c.rows, c.cols, c.data)
+	if o.coo != nil {
+		*o.coo = *d
+		d = o.coo
+	}
+
+	// apply header fields
+	m.Object = h.t.Object
+	m.Format = h.t.Format
+	m.Field = h.t.Field
+	m.Symmetry = o.xform.symmetry(h.t.Symmetry)
+	m.mat = d
+
+	return n.total, nil
+}
+
+// MarshalHBTo serializes the receiver to w in Harwell-Boeing format, or in
+// Rutherford-Boeing format if info.Rutherford, as configured by opts, and
+// returns the number of bytes written. The title, key and right-hand
+// sides of info, which may be nil, are also written, although right-hand
+// sides are not written in Rutherford-Boeing format.
+func (m *COO) MarshalHBTo(w io.Writer, info *HBInfo[float64], opts ...WriteOption) (int, error) {
+
+	o := newWriteOptions(opts)
+
+	t := mmType{m.Object, m.Format, m.Field, m.Symmetry}
+	t.canonicalize()
+
+	if t.Symmetry == SymmetryAuto {
+		t.Symmetry = DetectSymmetry(m.mat, o.tolerance)
+	}
+
+	if !(t.isMatrix() && t.isCoordinate()) || t.isComplex() || t.isHermitian() {
+		return 0, ErrUnsupportedType
+	}
+
+	M, N := m.mat.Dims()
+
+	return writeHB(w, &t, M, N, m.Do, info, o)
+}
+
+// UnmarshalHBFrom deserializes r from Harwell-Boeing or Rutherford-Boeing
+// format into the receiver, as configured by opts, and reads the title,
+// key and right-hand sides of the file into info, if not nil. The matrix
+// must be complex.
+func (m *CDense) UnmarshalHBFrom(r io.Reader, info *HBInfo[complex128], opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	scanner := newScanner(r, o)
+
+	h, err := scanHBHeader(scanner, o)
+	if err != nil {
+		return n.total, err
+	}
+
+	if !h.t.isComplex() {
+		return n.total, ErrUnsupportedType
+	}
+
+	// entries are stored densely, such that storage is bounded by the
+	// number of matrix elements rather than by the number of entries
+	if err := o.checkDense(h.M, h.N); err != nil {
+		return n.total, err
+	}
+
+	// dense storage cannot be allocated for an empty matrix
+	Mx, Nx := o.xform.dims(h.M, h.N)
+	if Mx == 0 || Nx == 0 {
+		return n.total, scanner.errorf(3, ErrInvalidSize)
+	}
+
+	d := newCDenseEntries(Mx, Nx, o.duplicates, false)
+	d.x = o.xform
+
+	err = scanHBData(scanner, h, info, func(i, j int, v complex128) error {
+		_, err := d.add(i, j, v)
+		return err
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	// apply header fields
+	m.Object = h.t.Object
+	m.Format = mtxFormatArray
+	m.Field = h.t.Field
+	m.Symmetry = o.xform.symmetry(h.t.Symmetry)
+	if m.mat, err = d.dense(o); err != nil {
+		return n.total, err
+	}
+
+	return n.total, nil
+}
+
+// MarshalHBTo serializes the receiver to w in Harwell-Boeing format, or in
+// Rutherford-Boeing format if info.Rutherford, as configured by opts, and
+// returns the number of bytes written. Only the nonzero elements of the
+// receiver are written, together with the title, key and right-hand sides
+// of info, which may be nil. Right-hand sides are not written in
+// Rutherford-Boeing format.
+func (m *CDense) MarshalHBTo(w io.Writer, info *HBInfo[complex128], opts ...WriteOption) (int, error) {
+
+	o := newWriteOptions(opts)
+
+	t := mmType{m.Object, mtxFormatCoordinate, m.Field, m.Symmetry}
+	t.canonicalize()
+
+	if t.Symmetry == SymmetryAuto {
+		t.Symmetry = DetectCSymmetry(m.mat, o.tolerance)
+	}
+
+	if !(t.isMatrix() && t.isComplex()) {
+		return 0, ErrUnsupportedType
+	}
+
+	// elements of dense storage are written only if nonzero
+	do := func(fn func(i, j int, v complex128)) {
+		m.Do(func(i, j int, v complex128) {
+			if v != 0 {
+				fn(i, j, v)
+			}
+		})
+	}
+
+	M, N := m.mat.Dims()
+
+	return writeHB(w, &t, M, N, do, info, o)
+}
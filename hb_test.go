@@ -0,0 +1,360 @@
+package market
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// hb01 is the symmetric 5×5 matrix mtx02, in Harwell-Boeing format with a
+// right-hand side, starting guess and solution.
+const hb01 = `Symmetric example                                                       SYM01
+             7             1             1             2             3
+RSA                        5             5             9             0
+(6I3)           (9I3)           (5E15.8)            (5E15.8)
+FGX                        1             0
+  1  3  6  8  9 10
+  1  5  2  3  4  3  5  4  5
+ 1.10000000E+01 1.50000000E+01 2.20000000E+01 2.30000000E+01 2.40000000E+01
+ 3.30000000E+01 3.50000000E+01 4.40000000E+01 5.50000000E+01
+ 1.00000000E+00 2.00000000E+00 3.00000000E+00 4.00000000E+00 5.00000000E+00
+ 0.00000000E+00 0.00000000E+00 0.00000000E+00 0.00000000E+00 0.00000000E+00
+ 1.00000000E+00 1.00000000E+00 1.00000000E+00 1.00000000E+00 1.00000000E+00
+`
+
+func TestParseFortranFormat(t *testing.T) {
+
+	for _, test := range []struct {
+		s    string
+		want fortranFormat
+	}{
+		{"(10I8)", fortranFormat{10, 'I', 8, 0}},
+		{"(16I5)", fortranFormat{16, 'I', 5, 0}},
+		{"(1P,4E20.12)", fortranFormat{4, 'E', 20, 12}},
+		{"(1P4D20.12)", fortranFormat{4, 'D', 20, 12}},
+		{"(3E26.16E3)", fortranFormat{3, 'E', 26, 16}},
+		{"(5f15.8)", fortranFormat{5, 'F', 15, 8}},
+		{"(I8)", fortranFormat{1, 'I', 8, 0}},
+	} {
+		f, err := parseFortranFormat(test.s)
+		assert.NoError(t, err, test.s)
+		assert.Equal(t, test.want, f, test.s)
+	}
+
+	_, err := parseFortranFormat("(A8)")
+	assert.ErrorIs(t, err, ErrInputScanError)
+}
+
+func TestParseFortranFloat(t *testing.T) {
+
+	e := fortranFormat{1, 'E', 15, 8}
+	f := fortranFormat{1, 'F', 10, 3}
+
+	for _, test := range []struct {
+		s    string
+		f    fortranFormat
+		want float64
+	}{
+		{"", e, 0},
+		{"1.5E+01", e, 15},
+		{"1.5D+01", e, 15},
+		{"-1.5d-1", e, -0.15},
+		{"1.5+01", e, 15},
+		{"-1.5-300", e, -1.5e-300},
+		{"12345", f, 12.345},
+		{"-12", f, -0.012},
+		{"1.5", f, 1.5},
+	} {
+		v, err := parseFortranFloat(test.s, test.f)
+		assert.NoError(t, err, test.s)
+		assert.InDelta(t, test.want, v, 1e-15, test.s)
+	}
+}
+
+func TestCOOUnmarshalHBFrom(t *testing.T) {
+
+	var (
+		m    COO
+		info HBInfo[float64]
+	)
+
+	_, err := m.UnmarshalHBFrom(strings.NewReader(hb01), &info)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, mtxSymmetrySymm, m.Symmetry)
+	assert.Equal(t, mtxFieldReal, m.Field)
+	assert.True(t, mat.Equal(mtx02, m.ToCOO()))
+
+	assert.Equal(t, "Symmetric example", info.Title)
+	assert.Equal(t, "SYM01", info.Key)
+	assert.Equal(t, "RSA", info.Type)
+	assert.False(t, info.Rutherford)
+	assert.Equal(t, 1, info.NRHS)
+	assert.Equal(t, []float64{1, 2, 3, 4, 5}, info.RHS)
+	assert.Equal(t, []float64{0, 0, 0, 0, 0}, info.Guess)
+	assert.Equal(t, []float64{1, 1, 1, 1, 1}, info.Solution)
+
+	// info may be nil
+	_, err = m.UnmarshalHBFrom(strings.NewReader(hb01), nil)
+	assert.NoError(t, err)
+	assert.True(t, mat.Equal(mtx02, m.ToCOO()))
+}
+
+func TestCOOUnmarshalHBFromPattern(t *testing.T) {
+
+	const text = `Pattern                                                                 PAT
+             3             1             2             0
+psa                        3             3             4             0
+(4I2)           (4I2)
+ 1 3 4 5
+ 1 2 2 3
+`
+
+	var (
+		m    COO
+		info HBInfo[float64]
+	)
+
+	_, err := m.UnmarshalHBFrom(strings.NewReader(text), &info)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, mtxFieldPattern, m.Field)
+	assert.True(t, info.Rutherford)
+	assert.True(t, mat.Equal(
+		mat.NewDense(3, 3, []float64{1, 1, 0, 1, 1, 0, 0, 0, 1}),
+		m.ToCOO(),
+	))
+}
+
+func TestCOOUnmarshalHBFromErrors(t *testing.T) {
+
+	var m COO
+
+	for _, test := range []struct {
+		name string
+		text string
+		err  error
+	}{
+		{"complex", strings.Replace(hb01, "RSA", "CSA", 1), ErrUnsupportedType},
+		{"elemental", strings.Replace(hb01, "RSA", "RSE", 1), ErrUnsupportedType},
+		{"format", strings.Replace(hb01, "(6I3)", "(6A3)", 1), ErrInputScanError},
+		{"pointers", strings.Replace(hb01, "  1  3  6  8  9 10", "  1  3  6  8  9  9", 1), ErrInputScanError},
+		{"index", strings.Replace(hb01, "  1  5  2  3", "  1  6  2  3", 1), ErrIndexOutOfRange},
+		{"upper", strings.Replace(hb01, "  1  5  2  3", "  1  5  1  3", 1), ErrInputScanError},
+		{"truncated", hb01[:strings.Index(hb01, "  1  5  2")], ErrInputScanError},
+		{"header", hb01[:100], ErrPrematureEOF},
+	} {
+		_, err := m.UnmarshalHBFrom(strings.NewReader(test.text), nil)
+		assert.ErrorIs(t, err, test.err, test.name)
+	}
+
+	_, err := m.UnmarshalHBFrom(strings.NewReader(hb01), nil, WithLimits(Limits{MaxRows: 4}))
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	// hostile numbers of entries and right-hand sides do not drive
+	// allocation
+	for _, text := range []string{
+		hbHostileEntries,
+		hbHostileRHS,
+	} {
+		var info HBInfo[float64]
+
+		_, err := m.UnmarshalHBFrom(strings.NewReader(text), &info)
+		assert.ErrorIs(t, err, ErrInputScanError)
+	}
+
+	// as for coordinate data, dense storage is bounded by the number of
+	// matrix elements, such that the rows are fewer for a CDense
+	text := strings.Replace(strings.Replace(hbHostileEntries, "RUA", "CUA", 1), "50000000", "    1000", 1)
+
+	var d CDense
+
+	_, err = d.UnmarshalHBFrom(strings.NewReader(text), nil)
+	assert.ErrorIs(t, err, ErrInputScanError)
+}
+
+// hbHostileEntries is the header of a 50000000×1 matrix of 50000000
+// entries, of which none are given.
+const hbHostileEntries = `Hostile                                                                 HOST01
+             3             1             1             1             0
+RUA                 50000000             1      50000000             0
+(2I9)           (1I9)           (1E15.8)            (1E15.8)
+        1 50000001
+`
+
+// hbHostileRHS is a 50000000×1 matrix of one entry, of which the
+// right-hand side is not given.
+const hbHostileRHS = `Hostile                                                                 HOST02
+             4             1             1             1             1
+RUA                 50000000             1             1             0
+(2I9)           (1I9)           (1E15.8)            (1E15.8)
+F                          1             0
+        1        2
+        1
+ 1.00000000E+00
+`
+
+func TestCOOMarshalHBTo(t *testing.T) {
+
+	for _, test := range []struct {
+		name string
+		m    *COO
+	}{
+		{"general", NewCOO(mtx01)},
+		{"symmetric", &COO{mtxObjectMatrix, mtxFormatCoordinate, mtxFieldReal, mtxSymmetrySymm, mtx02}},
+		{"auto", &COO{mtxObjectMatrix, mtxFormatCoordinate, mtxFieldReal, SymmetryAuto, mtx02}},
+	} {
+		for _, rutherford := range []bool{false, true} {
+
+			var b strings.Builder
+
+			info := HBInfo[float64]{Title: "Title", Key: "KEY", Rutherford: rutherford}
+
+			_, err := test.m.MarshalHBTo(&b, &info)
+			if !assert.NoError(t, err, test.name) {
+				continue
+			}
+
+			for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+				assert.LessOrEqual(t, len(line), 80, test.name)
+			}
+
+			var (
+				m   COO
+				got HBInfo[float64]
+			)
+
+			_, err = m.UnmarshalHBFrom(strings.NewReader(b.String()), &got)
+			if !assert.NoError(t, err, test.name) {
+				continue
+			}
+
+			assert.True(t, mat.Equal(test.m.ToCOO(), m.ToCOO()), test.name)
+			assert.Equal(t, "Title", got.Title, test.name)
+			assert.Equal(t, "KEY", got.Key, test.name)
+			assert.Equal(t, rutherford, got.Rutherford, test.name)
+		}
+	}
+}
+
+func TestCOOMarshalHBToRHS(t *testing.T) {
+
+	var b strings.Builder
+
+	m := &COO{mtxObjectMatrix, mtxFormatCoordinate, mtxFieldReal, mtxSymmetrySymm, mtx02}
+
+	info := HBInfo[float64]{
+		NRHS:     2,
+		RHS:      []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		Solution: []float64{-1, -2, -3, -4, -5, -6, -7, -8, -9, -10},
+	}
+
+	_, err := m.MarshalHBTo(&b, &info)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Contains(t, b.String(), "RSA")
+	assert.Contains(t, b.String(), "F X")
+
+	var got HBInfo[float64]
+
+	_, err = m.UnmarshalHBFrom(strings.NewReader(b.String()), &got)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 2, got.NRHS)
+	assert.Equal(t, info.RHS, got.RHS)
+	assert.Nil(t, got.Guess)
+	assert.Equal(t, info.Solution, got.Solution)
+
+	// right-hand sides must be of NRHS vectors of M rows
+	info.RHS = info.RHS[:9]
+	_, err = m.MarshalHBTo(&b, &info)
+	assert.ErrorIs(t, err, ErrInvalidSize)
+}
+
+func TestCOOMarshalHBToInteger(t *testing.T) {
+
+	var b strings.Builder
+
+	c := sparse.NewCOO(2, 3, []int{0, 1, 1}, []int{0, 0, 2}, []float64{-7, 12, 3})
+	m := &COO{mtxObjectMatrix, mtxFormatCoordinate, mtxFieldInteger, mtxSymmetryGeneral, c}
+
+	_, err := m.MarshalHBTo(&b, &HBInfo[float64]{Rutherford: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Contains(t, b.String(), "ira")
+
+	var (
+		got  COO
+		info HBInfo[float64]
+	)
+
+	_, err = got.UnmarshalHBFrom(strings.NewReader(b.String()), &info)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, mtxFieldInteger, got.Field)
+	assert.Equal(t, "ira", info.Type)
+	assert.True(t, mat.Equal(c, got.ToCOO()))
+}
+
+// failWriter is an io.Writer that always fails.
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestCOOMarshalHBToUnwritable(t *testing.T) {
+
+	_, err := NewCOO(mtx01).MarshalHBTo(failWriter{}, nil)
+	assert.ErrorIs(t, err, ErrUnwritable)
+}
+
+func TestCDenseHB(t *testing.T) {
+
+	d := mat.NewCDense(3, 3, []complex128{
+		1, 2 - 1i, 0,
+		2 + 1i, 3, -1i,
+		0, 1i, 5,
+	})
+
+	for _, symmetry := range []string{mtxSymmetryGeneral, mtxSymmetryHermitian} {
+
+		var b strings.Builder
+
+		m := &CDense{mtxObjectMatrix, mtxFormatArray, mtxFieldComplex, symmetry, d}
+
+		_, err := m.MarshalHBTo(&b, nil)
+		if !assert.NoError(t, err, symmetry) {
+			continue
+		}
+
+		var got CDense
+
+		_, err = got.UnmarshalHBFrom(strings.NewReader(b.String()), nil)
+		if !assert.NoError(t, err, symmetry) {
+			continue
+		}
+
+		assert.Equal(t, symmetry, got.Symmetry)
+		assert.True(t, mat.CEqual(d, got.ToCDense()), symmetry)
+	}
+
+	// a real matrix cannot be read into complex storage
+	var got CDense
+	_, err := got.UnmarshalHBFrom(strings.NewReader(hb01), nil)
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
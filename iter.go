@@ -0,0 +1,170 @@
+package market
+
+import (
+	"iter"
+	"strings"
+)
+
+// Index is the (zero-indexed) row and column of an entry.
+type Index struct {
+	Row, Col int
+}
+
+// storedOnly returns an iterator over the entries of seq which are stored
+// in a Matrix Market file of the given symmetry.
+func storedOnly[T any](symmetry string, seq iter.Seq2[Index, T]) iter.Seq2[Index, T] {
+
+	symmetry = strings.ToLower(symmetry)
+
+	return func(yield func(Index, T) bool) {
+		for k, v := range seq {
+			if isStored(symmetry, k.Row, k.Col) && !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the entries of the matrix, including both
+// an entry and its transpose for symmetric and skew-symmetric matrices.
+// Duplicate entries are yielded as held.
+func (m *COO) All() iter.Seq2[Index, float64] {
+	return func(yield func(Index, float64) bool) {
+
+		// the entries of a sparse.COO are enumerated only by DoNonZero,
+		// which cannot be stopped, such that the remaining entries are
+		// passed over once yield returns false
+		more := true
+		m.mat.DoNonZero(func(i, j int, v float64) {
+			more = more && yield(Index{i, j}, v)
+		})
+	}
+}
+
+// Stored returns an iterator over the entries of the matrix which are
+// stored in Matrix Market format: only those of the lower triangle of
+// symmetric matrices, and of the strictly lower triangle of
+// skew-symmetric matrices.
+func (m *COO) Stored() iter.Seq2[Index, float64] {
+	return storedOnly(m.Symmetry, m.All())
+}
+
+// All returns an iterator over the elements of the matrix, in column
+// major order.
+func (m *Dense) All() iter.Seq2[Index, float64] {
+	return func(yield func(Index, float64) bool) {
+		M, N := m.mat.Dims()
+		for j := 0; j < N; j++ {
+			for i := 0; i < M; i++ {
+				if !yield(Index{i, j}, m.mat.At(i, j)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Stored returns an iterator over the elements of the matrix which are
+// stored in Matrix Market format, in column major order: only those of
+// the lower triangle of symmetric matrices, and of the strictly lower
+// triangle of skew-symmetric matrices.
+func (m *Dense) Stored() iter.Seq2[Index, float64] {
+	return storedOnly(m.Symmetry, m.All())
+}
+
+// All returns an iterator over the elements of the matrix, in column
+// major order.
+func (m *CDense) All() iter.Seq2[Index, complex128] {
+	return func(yield func(Index, complex128) bool) {
+		M, N := m.mat.Dims()
+		for j := 0; j < N; j++ {
+			for i := 0; i < M; i++ {
+				if !yield(Index{i, j}, m.mat.At(i, j)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Stored returns an iterator over the elements of the matrix which are
+// stored in Matrix Market format, in column major order: only those of
+// the lower triangle of symmetric and hermitian matrices, and of the
+// strictly lower triangle of skew-symmetric matrices.
+func (m *CDense) Stored() iter.Seq2[Index, complex128] {
+	return storedOnly(m.Symmetry, m.All())
+}
+
+// All returns an iterator over the entries of the matrix, including both
+// an entry and its transpose for symmetric, skew-symmetric and hermitian
+// matrices. Duplicate entries are yielded as held.
+func (m *Coordinate[I, T]) All() iter.Seq2[Index, T] {
+	return func(yield func(Index, T) bool) {
+		for p, v := range m.Data {
+			if !yield(Index{int(m.Rows[p]), int(m.Cols[p])}, v) {
+				return
+			}
+		}
+	}
+}
+
+// Stored returns an iterator over the entries of the matrix which are
+// stored in Matrix Market format: only those of the lower triangle of
+// symmetric and hermitian matrices, and of the strictly lower triangle
+// of skew-symmetric matrices.
+func (m *Coordinate[I, T]) Stored() iter.Seq2[Index, T] {
+	return storedOnly(m.Symmetry, m.All())
+}
+
+// All returns an iterator over the elements of the matrix, in column
+// major order.
+func (m *Array[T]) All() iter.Seq2[Index, T] {
+	return func(yield func(Index, T) bool) {
+		for j := 0; j < m.N; j++ {
+			for i := 0; i < m.M; i++ {
+				if !yield(Index{i, j}, m.Data[i+j*m.M]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Stored returns an iterator over the elements of the matrix which are
+// stored in Matrix Market format, in column major order: only those of
+// the lower triangle of symmetric and hermitian matrices, and of the
+// strictly lower triangle of skew-symmetric matrices.
+func (m *Array[T]) Stored() iter.Seq2[Index, T] {
+	return storedOnly(m.Symmetry, m.All())
+}
+
+// All returns an iterator over the entries of the matrix, in row major
+// order, including both an entry and its transpose for symmetric
+// matrices.
+func (m *Pattern[I]) All() iter.Seq[Index] {
+	return func(yield func(Index) bool) {
+		for i := 0; i < m.M; i++ {
+			for _, j := range m.row(i) {
+				if !yield(Index{i, int(j)}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Stored returns an iterator over the entries of the matrix which are
+// stored in Matrix Market format, in row major order: only those of the
+// lower triangle of symmetric matrices.
+func (m *Pattern[I]) Stored() iter.Seq[Index] {
+
+	symmetry := strings.ToLower(m.Symmetry)
+
+	return func(yield func(Index) bool) {
+		for k := range m.All() {
+			if isStored(symmetry, k.Row, k.Col) && !yield(k) {
+				return
+			}
+		}
+	}
+}
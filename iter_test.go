@@ -0,0 +1,150 @@
+package market
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+const iterSkewText = `%%MatrixMarket matrix coordinate real skew-symmetric
+%
+ 3  3  2
+ 2  1  1
+ 3  2  2
+`
+
+func TestCOOAllStored(t *testing.T) {
+
+	var m COO
+	assert.Nil(t, m.UnmarshalText([]byte(iterSkewText)))
+
+	all := make(map[Index]float64)
+	for k, v := range m.All() {
+		all[k] = v
+	}
+	assert.Equal(t, map[Index]float64{
+		{1, 0}: 1, {0, 1}: -1,
+		{2, 1}: 2, {1, 2}: -2,
+	}, all)
+
+	stored := make(map[Index]float64)
+	for k, v := range m.Stored() {
+		stored[k] = v
+	}
+	assert.Equal(t, map[Index]float64{{1, 0}: 1, {2, 1}: 2}, stored)
+
+	// breaking stops yielding the entries of the sparse matrix
+	var n int
+	for range m.All() {
+		n++
+		break
+	}
+	assert.Equal(t, 1, n)
+
+	// panics of the loop body propagate
+	assert.PanicsWithValue(t, "boom", func() {
+		for range m.All() {
+			panic("boom")
+		}
+	})
+}
+
+func TestDenseAllStored(t *testing.T) {
+
+	m := NewDense(mat.NewDense(2, 2, []float64{1, 2, 2, 3}))
+	m.Symmetry = mtxSymmetrySymm
+
+	var all []Index
+	for k := range m.All() {
+		all = append(all, k)
+	}
+	assert.Equal(t, []Index{{0, 0}, {1, 0}, {0, 1}, {1, 1}}, all)
+
+	var stored []float64
+	for _, v := range m.Stored() {
+		stored = append(stored, v)
+	}
+	assert.Equal(t, []float64{1, 2, 3}, stored)
+
+	var n int
+	for range m.All() {
+		n++
+		break
+	}
+	assert.Equal(t, 1, n)
+}
+
+func TestCDenseAllStored(t *testing.T) {
+
+	m := NewCDense(mat.NewCDense(2, 2, []complex128{1, 2 - 1i, 2 + 1i, 3}))
+	m.Symmetry = mtxSymmetryHermitian
+
+	var stored []complex128
+	for _, v := range m.Stored() {
+		stored = append(stored, v)
+	}
+	assert.Equal(t, []complex128{1, 2 + 1i, 3}, stored)
+}
+
+func TestCoordinateAllStored(t *testing.T) {
+
+	var c Coordinate[int32, float32]
+	assert.Nil(t, c.UnmarshalText([]byte(iterSkewText)))
+
+	var all []Index
+	for k := range c.All() {
+		all = append(all, k)
+	}
+	assert.ElementsMatch(t, []Index{{1, 0}, {0, 1}, {2, 1}, {1, 2}}, all)
+
+	stored := make(map[Index]float32)
+	for k, v := range c.Stored() {
+		stored[k] = v
+	}
+	assert.Equal(t, map[Index]float32{{1, 0}: 1, {2, 1}: 2}, stored)
+}
+
+func TestArrayAllStored(t *testing.T) {
+
+	a := NewArray(3, 3, []float64{0, 1, 2, -1, 0, 3, -2, -3, 0})
+	a.Symmetry = mtxSymmetrySkew
+
+	var stored []Index
+	for k := range a.Stored() {
+		stored = append(stored, k)
+	}
+	assert.Equal(t, []Index{{1, 0}, {2, 0}, {2, 1}}, stored)
+
+	var n int
+	for range a.All() {
+		n++
+		if n == 4 {
+			break
+		}
+	}
+	assert.Equal(t, 4, n)
+}
+
+func TestPatternAllStored(t *testing.T) {
+
+	var p Pattern[int32]
+	assert.Nil(t, p.UnmarshalText([]byte(`%%MatrixMarket matrix coordinate pattern symmetric
+%
+ 3  3  2
+ 1  1
+ 3  1
+`)))
+
+	var all []Index
+	for k := range p.All() {
+		all = append(all, k)
+	}
+	assert.Equal(t, []Index{{0, 0}, {0, 2}, {2, 0}}, all)
+
+	var stored []Index
+	for k := range p.Stored() {
+		stored = append(stored, k)
+	}
+	assert.Equal(t, []Index{{0, 0}, {2, 0}}, stored)
+}
@@ -0,0 +1,212 @@
+package market
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/james-bowman/sparse"
+)
+
+// UnmarshalLIBSVMFrom deserializes a dataset from LIBSVM (or SVMlight)
+// format into the receiver, as its feature matrix, and into labels, as
+// configured by opts, and returns the number of bytes read. Each line of
+// the file is a row of the matrix, of the form
+//
+//	label index:value index:value ...
+//
+// of which the feature indices, one-indexed unless set by WithIndexBase,
+// are the columns of the matrix. The number of columns is that of the
+// greatest feature index. Comments, which begin with #, and the query
+// identifiers (qid:n) of SVMlight are skipped. If labels is nil then
+// labels are discarded. Labels are permuted and selected with the rows of
+// the matrix.
+func (m *COO) UnmarshalLIBSVMFrom(r io.Reader, labels *[]float64, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	scanner := newScanner(r, o)
+
+	base := o.indexBase(1)
+
+	var (
+		ys     []float64
+		rows   []int
+		cols   []int
+		data   []float64
+		fields []string
+		N      int
+	)
+
+	for scanner.Scan() {
+
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+
+		fields = appendFields(fields[:0], line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		y, err := parseFloat(fields[0])
+		if err != nil {
+			return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: invalid label %q", ErrInputScanError, fields[0]))
+		}
+
+		i := len(ys)
+		ys = append(ys, y)
+
+		for _, tok := range fields[1:] {
+
+			idx, val, ok := strings.Cut(tok, ":")
+			if !ok {
+				return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: invalid feature %q", ErrInputScanError, tok))
+			}
+
+			if idx == "qid" {
+				continue
+			}
+
+			j, err := parseInt(idx)
+			if err != nil {
+				return n.total, scanner.errorf(scanner.line, err)
+			}
+
+			j -= base
+			if j < 0 {
+				return n.total, scanner.errorf(scanner.line, ErrIndexOutOfRange)
+			}
+
+			v, err := parseFloat(val)
+			if err != nil {
+				return n.total, scanner.errorf(scanner.line, err)
+			}
+
+			N = max(N, j+1)
+
+			if v == 0 && o.zeros == ZeroDrop {
+				continue
+			}
+
+			rows, cols, data = append(rows, i), append(cols, j), append(data, v)
+		}
+
+		if err := o.limits.checkSize(len(ys), N, len(data)); err != nil {
+			return n.total, scanner.errorf(scanner.line, err)
+		}
+	}
+
+	if err := scanError(scanner.Scanner); err != nil {
+		return n.total, err
+	}
+
+	M := len(ys)
+
+	if err := o.xform.check(M, N); err != nil {
+		return n.total, err
+	}
+
+	c := newTriplets[int, float64](len(data), o.duplicates, false)
+	c.x = o.xform
+
+	for k, v := range data {
+		if _, err := c.add(rows[k], cols[k], v); err != nil {
+			return n.total, err
+		}
+	}
+
+	Mx, Nx := o.xform.dims(M, N)
+	d := sparse.NewCOO(Mx, Nx, c.rows, c.cols, c.data)
+	if o.coo != nil {
+		*o.coo = *d
+		d = o.coo
+	}
+
+	// apply header fields
+	m.Object = mtxObjectMatrix
+	m.Format = mtxFormatCoordinate
+	m.Field = mtxFieldReal
+	m.Symmetry = mtxSymmetryGeneral
+	m.mat = d
+
+	if labels != nil {
+		*labels = selectRows(o.xform, ys)
+	}
+
+	return n.total, nil
+}
+
+// MarshalLIBSVMTo serializes the receiver, as the feature matrix of a
+// dataset, and labels to w in LIBSVM format, as configured by opts, and
+// returns the number of bytes written. Each row of the matrix is written
+// as a line, of its label followed by its entries in ascending column
+// order, with duplicates merged. Feature indices are one-indexed unless
+// set by WriteIndexBase. As the number of columns is not written, columns
+// following the last column having an entry are not read back. The number
+// of labels must equal the number of rows.
+func (m *COO) MarshalLIBSVMTo(w io.Writer, labels []float64, opts ...WriteOption) (int, error) {
+
+	var n counter
+
+	o := newWriteOptions(opts)
+
+	M, _ := m.mat.Dims()
+	if len(labels) != M {
+		return 0, fmt.Errorf("%w: %d labels for %d rows", ErrInvalidSize, len(labels), M)
+	}
+
+	c := newTriplets[int, float64](m.mat.NNZ(), o.duplicates, false)
+
+	var err error
+	m.Do(func(i, j int, v float64) {
+		if err == nil {
+			_, err = c.add(i, j, v)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	c.sort(OrderRowMajor)
+
+	if o.zeros == ZeroDrop {
+		c.dropZeros()
+	}
+
+	base := o.indexBase(1)
+
+	integer := m.Field == mtxFieldInteger || m.Field == mtxFieldPattern
+
+	bw := bufio.NewWriterSize(io.MultiWriter(w, &n), maxScanTokenSize)
+
+	var (
+		b []byte
+		k int
+	)
+
+	for i, y := range labels {
+
+		b = appendCompact(b[:0], y, false, 64)
+
+		for ; k < len(c.data) && c.rows[k] == i; k++ {
+			b = append(b, ' ')
+			b = strconv.AppendInt(b, int64(c.cols[k]+base), 10)
+			b = append(b, ':')
+			b = appendCompact(b, c.data[k], integer, 64)
+		}
+
+		b = append(b, '\n')
+		bw.Write(b)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return n.total, ErrUnwritable
+	}
+
+	return n.total, nil
+}
@@ -0,0 +1,130 @@
+package market
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// libsvm01 is a dataset of three rows and four features, in SVMlight
+// format with a comment and query identifiers.
+const libsvm01 = `# dataset
++1 qid:1 1:0.5 3:2
+-1 qid:1 4:1.5 # trailing comment
+
+0 2:-3 3:1
+`
+
+func TestCOOUnmarshalLIBSVMFrom(t *testing.T) {
+
+	var (
+		m      COO
+		labels []float64
+	)
+
+	n, err := m.UnmarshalLIBSVMFrom(strings.NewReader(libsvm01), &labels)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, len(libsvm01), n)
+	assert.Equal(t, []float64{1, -1, 0}, labels)
+	assert.True(t, mat.Equal(mat.NewDense(3, 4, []float64{
+		0.5, 0, 2, 0,
+		0, 0, 0, 1.5,
+		0, -3, 1, 0,
+	}), m.ToCOO()))
+
+	// zero-based feature indices
+	_, err = m.UnmarshalLIBSVMFrom(strings.NewReader(libsvm01), nil, WithIndexBase(0))
+	if assert.NoError(t, err) {
+		r, c := m.ToCOO().Dims()
+		assert.Equal(t, []int{3, 5}, []int{r, c})
+		assert.Equal(t, 0.5, m.ToCOO().At(0, 1))
+	}
+
+	// labels follow the rows
+	_, err = m.UnmarshalLIBSVMFrom(strings.NewReader(libsvm01), &labels, WithRowPermutation([]int{2, 0, 1}), WithRows(0, 2))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []float64{-1, 0}, labels)
+		assert.True(t, mat.Equal(mat.NewDense(2, 4, []float64{
+			0, 0, 0, 1.5,
+			0, -3, 1, 0,
+		}), m.ToCOO()))
+	}
+}
+
+func TestCOOUnmarshalLIBSVMFromErrors(t *testing.T) {
+
+	for _, test := range []struct {
+		name string
+		file string
+		line int
+		err  error
+	}{
+		{"label", "x 1:1\n", 1, ErrInputScanError},
+		{"feature", "1 1:1\n1 2\n", 2, ErrInputScanError},
+		{"index", "1 1:1\n1 a:1\n", 2, ErrInputScanError},
+		{"zero", "1 0:1\n", 1, ErrIndexOutOfRange},
+		{"value", "1 1:x\n", 1, ErrInputScanError},
+		{"limit", "1 1:1\n1 1:1 2:1\n", 2, ErrLimitExceeded},
+	} {
+		var m COO
+
+		_, err := m.UnmarshalLIBSVMFrom(strings.NewReader(test.file), nil, WithLimits(Limits{MaxEntries: 2}))
+		assert.ErrorIs(t, err, test.err, test.name)
+
+		var pe *ParseError
+		if assert.ErrorAs(t, err, &pe, test.name) {
+			assert.Equal(t, test.line, pe.Line, test.name)
+		}
+	}
+}
+
+func TestCOOMarshalLIBSVMTo(t *testing.T) {
+
+	var b bytes.Buffer
+
+	// entries are in ascending column order, with duplicates merged, and
+	// rows without entries are written as labels only
+	c := sparse.NewCOO(3, 4, []int{0, 0, 2, 2}, []int{2, 0, 1, 1}, []float64{2, 0.5, -1, -2})
+
+	n, err := NewCOO(c).MarshalLIBSVMTo(&b, []float64{1, -1, 0.25})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, b.Len(), n)
+	assert.Equal(t, "1 1:0.5 3:2\n-1\n0.25 2:-3\n", b.String())
+
+	b.Reset()
+	_, err = NewCOO(c).MarshalLIBSVMTo(&b, []float64{1, -1, 0.25}, WriteIndexBase(0))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "1 0:0.5 2:2\n-1\n0.25 1:-3\n", b.String())
+	}
+
+	// round trip
+	var (
+		m      COO
+		labels []float64
+	)
+
+	b.Reset()
+	_, err = NewCOO(mtx01).MarshalLIBSVMTo(&b, []float64{1, 2, 3, 4})
+	if assert.NoError(t, err) {
+		_, err = m.UnmarshalLIBSVMFrom(&b, &labels)
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{1, 2, 3, 4}, labels)
+		assert.True(t, mat.Equal(mtx01, m.ToCOO()))
+	}
+
+	_, err = NewCOO(c).MarshalLIBSVMTo(&b, []float64{1})
+	assert.ErrorIs(t, err, ErrInvalidSize)
+
+	_, err = NewCOO(c).MarshalLIBSVMTo(failWriter{}, []float64{1, -1, 0.25})
+	assert.ErrorIs(t, err, ErrUnwritable)
+}
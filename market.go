@@ -2,17 +2,31 @@ package market
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"strings"
 )
 
 const maxScanTokenSize = 64 * 1024
 
+// maxPrealloc bounds the number of entries for which storage is allocated
+// ahead of reading them, such that storage grows with the entries read
+// rather than with the counts of a header, which may be hostile.
+const maxPrealloc = 64 * 1024
+
+// prealloc returns the capacity to allocate for n entries yet to be read.
+func prealloc(n int) int {
+	return min(max(n, 0), maxPrealloc)
+}
+
 const matrixMktBanner = `%%MatrixMarket`
 
 const (
 	// object
 	mtxObjectMatrix = "matrix"
+	mtxObjectTensor = "tensor"
 
 	// format
 	mtxFormatArray      = "array"
@@ -35,13 +49,26 @@ const (
 
 // Errors returned by failures to read a matrix
 var (
-	ErrInputScanError  = fmt.Errorf("error while scanning matrix input")
-	ErrLineTooLong     = fmt.Errorf("input line exceeds maximum length")
-	ErrPrematureEOF    = fmt.Errorf("required header items are missing")
-	ErrNoHeader        = fmt.Errorf("missing matrix market header line")
-	ErrNotMTX          = fmt.Errorf("input is not a matrix market file")
-	ErrUnsupportedType = fmt.Errorf("unrecognizable matrix description")
-	ErrUnwritable      = fmt.Errorf("error writing matrix to io writer")
+	ErrDuplicateEntry     = fmt.Errorf("duplicate entry in coordinate data")
+	ErrIndexOutOfRange    = fmt.Errorf("entry index outside matrix dimensions")
+	ErrIndexOverflow      = fmt.Errorf("matrix dimensions overflow index type")
+	ErrInputScanError     = fmt.Errorf("error while scanning matrix input")
+	ErrInvalidSize        = fmt.Errorf("invalid matrix dimensions")
+	ErrInvalidPermutation = fmt.Errorf("invalid permutation vector")
+	ErrLimitExceeded      = fmt.Errorf("matrix exceeds configured resource limits")
+	ErrInvalidName        = fmt.Errorf("invalid variable name")
+	ErrInvalidGraph       = fmt.Errorf("matrix is not an undirected graph")
+	ErrLineTooLong        = fmt.Errorf("input line exceeds maximum length")
+	ErrPrematureEOF       = fmt.Errorf("required header items are missing")
+	ErrNoVariable         = fmt.Errorf("variable not found in input")
+	ErrNoHeader           = fmt.Errorf("missing matrix market header line")
+	ErrNotMTX             = fmt.Errorf("input is not a matrix market file")
+	ErrNotMAT             = fmt.Errorf("input is not a level 5 mat-file")
+	ErrNotPETSc           = fmt.Errorf("input is not a petsc binary file")
+	ErrNotNPY             = fmt.Errorf("input is not a numpy array file")
+	ErrTruncated          = fmt.Errorf("input ends before all entries were read")
+	ErrUnsupportedType    = fmt.Errorf("unrecognizable matrix description")
+	ErrUnwritable         = fmt.Errorf("error writing matrix to io writer")
 )
 
 var supported = map[int]mmType{
@@ -77,6 +104,7 @@ type mmType struct {
 }
 
 func (t *mmType) isMatrix() bool     { return t.Object == mtxObjectMatrix }
+func (t *mmType) isTensor() bool     { return t.Object == mtxObjectTensor }
 func (t *mmType) isArray() bool      { return t.Format == mtxFormatArray }
 func (t *mmType) isCoordinate() bool { return t.Format == mtxFormatCoordinate }
 func (t *mmType) isDense() bool      { return t.Format == mtxFormatDense }
@@ -90,24 +118,33 @@ func (t *mmType) isHermitian() bool  { return t.Symmetry == mtxSymmetryHermitian
 func (t *mmType) isSkew() bool       { return t.Symmetry == mtxSymmetrySkew }
 func (t *mmType) isSymmetric() bool  { return t.Symmetry == mtxSymmetrySymm }
 
-// isMMType tests equality of two Matrix Market headers
+// isMMType tests equality of two Matrix Market headers, ignoring case
 func (t *mmType) isMMType(t2 *mmType) bool {
 
-	if strings.ToLower(t.Object) != t2.Object {
+	if !strings.EqualFold(t.Object, t2.Object) {
 		return false
 	}
-	if strings.ToLower(t.Format) != t2.Format {
+	if !strings.EqualFold(t.Format, t2.Format) {
 		return false
 	}
-	if strings.ToLower(t.Field) != t2.Field {
+	if !strings.EqualFold(t.Field, t2.Field) {
 		return false
 	}
-	if strings.ToLower(t.Symmetry) != t2.Symmetry {
+	if !strings.EqualFold(t.Symmetry, t2.Symmetry) {
 		return false
 	}
 	return true
 }
 
+// canonicalize converts the fields of the receiver to lower case, as
+// they are written in the Matrix Market specification.
+func (t *mmType) canonicalize() {
+	t.Object = strings.ToLower(t.Object)
+	t.Format = strings.ToLower(t.Format)
+	t.Field = strings.ToLower(t.Field)
+	t.Symmetry = strings.ToLower(t.Symmetry)
+}
+
 // Bytes returns a formatted Matrix Market headers
 func (t *mmType) Bytes() []byte {
 
@@ -147,35 +184,218 @@ func (t *mmType) index() int {
 	return -1
 }
 
+// newScanner returns a line scanner over r, bounded by the configured
+// limits.
+func newScanner(r io.Reader, o *readOptions) *lineScanner {
+
+	scanner := bufio.NewScanner(o.limits.reader(r))
+	buf := o.bufs.scanBuffer()
+	scanner.Buffer(buf, maxScanTokenSize)
+
+	return &lineScanner{Scanner: scanner}
+}
+
+// scanError returns the error encountered by scanner, if any. Errors
+// other than exceeded limits are reported as ErrInputScanError.
+func scanError(scanner *bufio.Scanner) error {
+
+	err := scanner.Err()
+	if err == nil || errors.Is(err, ErrLimitExceeded) {
+		return err
+	}
+
+	return ErrInputScanError
+}
+
+// aliases maps common misspellings of header fields, in lower case, to
+// the fields of the Matrix Market specification. Aliases are accepted
+// only in lenient mode.
+var aliases = map[string]string{
+	"skewsymmetric":  mtxSymmetrySkew,
+	"skew_symmetric": mtxSymmetrySkew,
+	"skew":           mtxSymmetrySkew,
+	"symm":           mtxSymmetrySymm,
+	"hermetian":      mtxSymmetryHermitian,
+	"double":         mtxFieldReal,
+	"int":            mtxFieldInteger,
+	"dense":          mtxFormatArray,
+	"sparse":         mtxFormatCoordinate,
+}
+
 // scanHeader scans one line from a scanner and attempts to parse as a
-// Matrix Market header
-func scanHeader(scanner *bufio.Scanner) (*mmType, error) {
+// Matrix Market header. The banner and fields are matched regardless of
+// case and of the whitespace between them, and a leading byte order mark
+// is ignored. The fields of the returned header are canonicalized.
+func scanHeader(scanner *lineScanner, o *readOptions) (*mmType, error) {
 
-	var (
-		banner string
-		t      mmType
-	)
+	t, err := scanBanner(scanner, o)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(t.isSupported()) {
+		return nil, ErrUnsupportedType
+	}
+
+	return t, nil
+}
+
+// scanBanner scans and parses a header line as does scanHeader, but
+// without regard to whether the header is of a supported matrix type.
+func scanBanner(scanner *lineScanner, o *readOptions) (*mmType, error) {
+
+	var t mmType
 
 	if ok := scanner.Scan(); !ok {
+		if err := scanError(scanner.Scanner); err != nil {
+			return nil, err
+		}
 		return nil, ErrInputScanError
 	}
 
-	_, err := fmt.Sscan(scanner.Text(), &banner, &t.Object, &t.Format, &t.Field, &t.Symmetry)
-	if err != nil {
+	line := strings.TrimPrefix(scanner.Text(), "\uFEFF")
+	fields := appendFields(nil, line)
+
+	// banner separated from MatrixMarket, as in "%% MatrixMarket"
+	if o.lenient && len(fields) > 1 && fields[0] == "%%" && strings.EqualFold(fields[1], matrixMktBanner[2:]) {
+		o.warnf(scanner.line, WarnHeader, "banner %q %q is not %q", fields[0], fields[1], matrixMktBanner)
+		fields = append([]string{matrixMktBanner}, fields[2:]...)
+	}
+
+	if len(fields) < 5 {
 		return nil, ErrPrematureEOF
 	}
 
-	if banner != matrixMktBanner {
+	if !strings.EqualFold(fields[0], matrixMktBanner) {
 		return nil, ErrNoHeader
 	}
 
-	if !(t.isSupported()) {
-		return nil, ErrUnsupportedType
+	t = mmType{fields[1], fields[2], fields[3], fields[4]}
+	t.canonicalize()
+
+	if len(fields) > 5 {
+		o.warnf(scanner.line, WarnTrailingTokens, "header has %d trailing tokens", len(fields)-5)
+	}
+
+	if o.lenient {
+		for _, f := range []*string{&t.Object, &t.Format, &t.Field, &t.Symmetry} {
+			if a, ok := aliases[*f]; ok {
+				o.warnf(scanner.line, WarnHeader, "header field %q read as %q", *f, a)
+				*f = a
+			}
+		}
 	}
 
 	return &t, nil
 }
 
+// scanSize skips comments and blank lines, then scans the size line of
+// a Matrix Market file, returning the number of rows M and columns N and,
+// for the coordinate format, the number of stored entries L. For the
+// array format, L is the number of matrix elements M*N. The size is
+// checked against the limits before returning, so that no storage has
+// been allocated for a matrix which exceeds them, as is the applicability
+// of any selection or transform options.
+func scanSize(scanner *lineScanner, t *mmType, o *readOptions) (M, N, L int, err error) {
+
+	var found bool
+
+	for scanner.Scan() {
+
+		fields := appendFields(nil, scanner.Text())
+
+		// blank line or comment (%, Unicode 37)
+		if len(fields) == 0 || fields[0][0] == 37 {
+			continue
+		}
+
+		// M is number of rows, N is number of columns and L is the
+		// number of entries (coordinate format only)
+		dims := []*int{&M, &N, &L}
+		if !t.isCoordinate() {
+			dims = dims[:2]
+		}
+
+		if len(fields) < len(dims) {
+			return 0, 0, 0, scanner.errorf(scanner.line, ErrInputScanError)
+		}
+
+		for i, d := range dims {
+			if *d, err = parseInt(fields[i]); err != nil {
+				return 0, 0, 0, scanner.errorf(scanner.line, err)
+			}
+		}
+
+		if len(fields) > len(dims) {
+			o.warnf(scanner.line, WarnTrailingTokens, "size line has %d trailing tokens", len(fields)-len(dims))
+		}
+
+		found = true
+
+		break
+	}
+
+	if err := scanError(scanner.Scanner); err != nil {
+		return 0, 0, 0, err
+	}
+
+	if !found {
+		return 0, 0, 0, scanner.errorf(scanner.line, ErrPrematureEOF)
+	}
+
+	if M < 0 || N < 0 || L < 0 {
+		return 0, 0, 0, scanner.errorf(scanner.line, ErrInvalidSize)
+	}
+
+	// symmetric, skew-symmetric and hermitian matrices must be square
+	if !t.isGeneral() && M != N {
+		return 0, 0, 0, scanner.errorf(scanner.line, ErrInvalidSize)
+	}
+
+	// the product M*N must be representable, both for dense storage
+	// and for bounds on the number of stored entries
+	if _, err := elements(M, N); err != nil {
+		return 0, 0, 0, err
+	}
+
+	if t.isArray() {
+		L = M * N
+	}
+
+	// without duplicates, no more entries are stored than there are
+	// matrix elements
+	if o.duplicates == DuplicateError && L > M*N {
+		return 0, 0, 0, scanner.errorf(scanner.line, fmt.Errorf("%w: %d entries exceed %d×%d elements", ErrInvalidSize, L, M, N))
+	}
+
+	if err := o.limits.checkSize(M, N, L); err != nil {
+		return 0, 0, 0, err
+	}
+
+	if err := o.xform.check(M, N); err != nil {
+		return 0, 0, 0, err
+	}
+
+	return M, N, L, nil
+}
+
+// elements returns the number of elements M*N in an M×N matrix, or an
+// error wrapping ErrLimitExceeded if the product overflows an int.
+func elements(M, N int) (int, error) {
+
+	if M > 0 && N > math.MaxInt/M {
+		return 0, fmt.Errorf("%w: %d×%d elements overflows int", ErrLimitExceeded, M, N)
+	}
+
+	return M * N, nil
+}
+
+// inBounds reports whether the one-indexed coordinates (i, j) fall
+// within an M×N matrix.
+func inBounds(i, j, M, N int) bool {
+	return i >= 1 && i <= M && j >= 1 && j <= N
+}
+
 // counter tallies the number of bytes written to it
 type counter struct {
 	total int
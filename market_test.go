@@ -8,10 +8,10 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func sts(s string) *bufio.Scanner {
+func sts(s string) *lineScanner {
 
 	r := strings.NewReader(s)
-	return bufio.NewScanner(r)
+	return &lineScanner{Scanner: bufio.NewScanner(r)}
 }
 
 func TestMmTypeIndex(t *testing.T) {
@@ -28,50 +28,105 @@ func TestScanHeader(t *testing.T) {
 	)
 
 	// example valid arry real general
-	h, err = scanHeader(sts(`%%MatrixMarket matrix array real general`))
+	h, err = scanHeader(sts(`%%MatrixMarket matrix array real general`), &readOptions{})
 	assert.Nil(t, err)
 	assert.True(t, (h.isArray() && h.isReal() && h.isGeneral()))
 
 	// example valid coordinate-integer header
-	h, err = scanHeader(sts(`%%MatrixMarket matrix coordinate integer skew-symmetric`))
+	h, err = scanHeader(sts(`%%MatrixMarket matrix coordinate integer skew-symmetric`), &readOptions{})
 	assert.Nil(t, err)
 	assert.True(t, (h.isSparse() && h.isInteger() && h.isSkew()))
 
 	// example valid coordinate-pattern header
-	h, err = scanHeader(sts(`%%MatrixMarket matrix coordinate pattern symmetric`))
+	h, err = scanHeader(sts(`%%MatrixMarket matrix coordinate pattern symmetric`), &readOptions{})
 	assert.Nil(t, err)
 	assert.True(t, (h.isSparse() && h.isPattern() && h.isSymmetric()))
 
 	// example valid array-complex header
-	h, err = scanHeader(sts(`%%MatrixMarket matrix array complex hermitian`))
+	h, err = scanHeader(sts(`%%MatrixMarket matrix array complex hermitian`), &readOptions{})
 	assert.Nil(t, err)
 	assert.True(t, (h.isDense() && h.isComplex() && h.isHermitian()))
 
 	// empty header
-	_, err = scanHeader(sts(``))
+	_, err = scanHeader(sts(``), &readOptions{})
 	assert.EqualError(t, err, ErrInputScanError.Error())
 
 	// too few fields in header
-	_, err = scanHeader(sts(`%%MatrixMarket coordinate integer general`))
+	_, err = scanHeader(sts(`%%MatrixMarket coordinate integer general`), &readOptions{})
 	assert.EqualError(t, err, ErrPrematureEOF.Error())
 
 	// superfluous field(s) in header (expect to be discarded)
-	_, err = scanHeader(sts(`%%MatrixMarket matrix coordinate integer general extra`))
+	_, err = scanHeader(sts(`%%MatrixMarket matrix coordinate integer general extra`), &readOptions{})
 	assert.Nil(t, err)
 
 	// malformed banner
-	_, err = scanHeader(sts(`MatrixMarket matrix coordinate integer general`))
+	_, err = scanHeader(sts(`MatrixMarket matrix coordinate integer general`), &readOptions{})
 	assert.EqualError(t, err, ErrNoHeader.Error())
 
 	// unsupported object field
-	_, err = scanHeader(sts(`%%MatrixMarket xirtam coordinate integer general`))
+	_, err = scanHeader(sts(`%%MatrixMarket xirtam coordinate integer general`), &readOptions{})
 	assert.EqualError(t, err, ErrUnsupportedType.Error())
 
 	// invalid field combination (real and hermitian)
-	_, err = scanHeader(sts(`%%MatrixMarket matrix coordinate real hermitian`))
+	_, err = scanHeader(sts(`%%MatrixMarket matrix coordinate real hermitian`), &readOptions{})
 	assert.EqualError(t, err, ErrUnsupportedType.Error())
 
 	// invalid field combination (array and pattern)
-	_, err = scanHeader(sts(`%%MatrixMarket matrix array pattern general`))
+	_, err = scanHeader(sts(`%%MatrixMarket matrix array pattern general`), &readOptions{})
 	assert.EqualError(t, err, ErrUnsupportedType.Error())
 }
+
+func TestScanHeaderTolerant(t *testing.T) {
+
+	c := []string{
+		"%%matrixmarket matrix coordinate real general",
+		"%%MATRIXMARKET MATRIX COORDINATE REAL GENERAL",
+		"\uFEFF%%MatrixMarket matrix coordinate real general",
+		"%%MatrixMarket\tmatrix  coordinate\treal general\r\n",
+		"  %%MatrixMarket matrix coordinate Real General  ",
+	}
+
+	for _, v := range c {
+		h, err := scanHeader(sts(v), &readOptions{})
+		assert.Nil(t, err, v)
+		assert.Equal(t, mmType{"matrix", "coordinate", "real", "general"}, *h, v)
+	}
+}
+
+func TestScanHeaderLenient(t *testing.T) {
+
+	var w []Warning
+
+	o := newReadOptions([]ReadOption{
+		WithLenient(),
+		WithWarnings(func(v Warning) { w = append(w, v) }),
+	})
+
+	// misspelt symmetry is rejected unless lenient
+	_, err := scanHeader(sts(`%%MatrixMarket matrix coordinate real skewsymmetric`), &readOptions{})
+	assert.EqualError(t, err, ErrUnsupportedType.Error())
+
+	h, err := scanHeader(sts(`%%MatrixMarket matrix coordinate real skewsymmetric`), o)
+	assert.Nil(t, err)
+	assert.True(t, h.isSkew())
+	assert.Equal(t, []Warning{{Line: 1, Kind: WarnHeader, Msg: `header field "skewsymmetric" read as "skew-symmetric"`}}, w)
+
+	w = nil
+	h, err = scanHeader(sts(`%% MatrixMarket matrix array double Skew_Symmetric`), o)
+	assert.Nil(t, err)
+	assert.True(t, h.isArray() && h.isReal() && h.isSkew())
+	assert.Len(t, w, 3)
+}
+
+func TestUnmarshalTextFromCanonical(t *testing.T) {
+
+	text := "\uFEFF%%matrixmarket Matrix Coordinate Real Symmetric\r\n%\r\n \t\r\n2 2 1\r\n2 1 3\r\n"
+
+	var m COO
+	_, err := m.UnmarshalTextFrom(strings.NewReader(text))
+	assert.Nil(t, err)
+
+	assert.Equal(t, SymmetrySymmetric, m.Symmetry)
+	assert.Equal(t, mtxFieldReal, m.Field)
+	assert.Equal(t, 3.0, m.ToCOO().At(0, 1))
+}
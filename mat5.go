@@ -0,0 +1,833 @@
+package market
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/james-bowman/sparse"
+	"gonum.org/v1/gonum/mat"
+)
+
+// data types of the elements of a MAT-file
+const (
+	miInt8       = 1
+	miUInt8      = 2
+	miInt16      = 3
+	miUInt16     = 4
+	miInt32      = 5
+	miUInt32     = 6
+	miSingle     = 7
+	miDouble     = 9
+	miInt64      = 12
+	miUInt64     = 13
+	miMatrix     = 14
+	miCompressed = 15
+)
+
+// classes of the arrays of a MAT-file
+const (
+	mxSparseClass = 5
+	mxDoubleClass = 6
+	mxSingleClass = 7
+	mxInt8Class   = 8
+	mxUInt64Class = 15
+)
+
+// flags of the arrays of a MAT-file
+const (
+	mxComplexFlag = 0x08
+	mxLogicalFlag = 0x02
+)
+
+// matHeaderText identifies the files written.
+const matHeaderText = "MATLAB 5.0 MAT-file, Platform: GLNXA64, Created by: github.com/wamuir/matrix-market"
+
+// matNameRE matches a valid MATLAB variable name.
+var matNameRE = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,62}$`)
+
+// matVar describes an array of a MAT-file, per its array flags, dimensions
+// and name.
+type matVar struct {
+	class   byte
+	complex bool
+	logical bool
+	nzmax   int
+	dims    []int
+	name    string
+}
+
+// isNumeric reports whether the array is of a numeric class.
+func (v *matVar) isNumeric() bool {
+	return v.class >= mxDoubleClass && v.class <= mxUInt64Class
+}
+
+// isInteger reports whether the array is of an integer or logical class.
+func (v *matVar) isInteger() bool {
+	return v.logical || (v.class >= mxInt8Class && v.class <= mxUInt64Class)
+}
+
+// matReader reads the data elements of a MAT-file.
+type matReader struct {
+	r     io.Reader
+	order binary.ByteOrder
+}
+
+// tag reads the tag of the next data element, returning its data type and
+// size and, if of the small data element format, its data.
+func (mr *matReader) tag() (uint32, int, []byte, error) {
+
+	var b [8]byte
+
+	if _, err := io.ReadFull(mr.r, b[:]); err != nil {
+		return 0, 0, nil, err
+	}
+
+	typ, size := mr.order.Uint32(b[:4]), mr.order.Uint32(b[4:])
+
+	// data of at most four bytes may be packed in the tag, of which the
+	// size is then held in the upper bytes of the type
+	if typ>>16 != 0 {
+		return typ & 0xffff, int(typ >> 16), b[4 : 4+min(4, int(typ>>16))], nil
+	}
+
+	return typ, int(size), nil, nil
+}
+
+// bytes reads the next data element, of at most max bytes.
+func (mr *matReader) bytes(max int) (uint32, []byte, error) {
+
+	typ, size, small, err := mr.tag()
+	if err != nil {
+		return 0, nil, mr.unexpected(err)
+	}
+
+	if small != nil {
+		return typ, small, nil
+	}
+
+	if size > max {
+		return 0, nil, fmt.Errorf("%w: data element of %d bytes", ErrInputScanError, size)
+	}
+
+	b := make([]byte, size+pad8(size))
+	if _, err := io.ReadFull(mr.r, b); err != nil {
+		return 0, nil, mr.unexpected(err)
+	}
+
+	return typ, b[:size], nil
+}
+
+// numbers reads the next data element, being of at most max numbers,
+// calling fn with each, and returns the number read.
+func (mr *matReader) numbers(max int, fn func(k int, v float64) error) (int, error) {
+
+	typ, size, small, err := mr.tag()
+	if err != nil {
+		return 0, mr.unexpected(err)
+	}
+
+	var w int
+	switch typ {
+	case miInt8, miUInt8:
+		w = 1
+	case miInt16, miUInt16:
+		w = 2
+	case miInt32, miUInt32, miSingle:
+		w = 4
+	case miDouble, miInt64, miUInt64:
+		w = 8
+	default:
+		return 0, fmt.Errorf("%w: numeric data of type %d", ErrUnsupportedType, typ)
+	}
+
+	n := size / w
+	if n*w != size || n > max {
+		return 0, fmt.Errorf("%w: %d bytes of numeric data", ErrInvalidSize, size)
+	}
+
+	r := mr.r
+	if small != nil {
+		r = bytes.NewReader(small)
+	}
+
+	// numbers are read in blocks of at most 64 KiB
+	block := maxScanTokenSize / w
+	buf := make([]byte, min(n, block)*w)
+
+	for k := 0; k < n; {
+
+		b := buf[:min(n-k, block)*w]
+		if _, err := io.ReadFull(r, b); err != nil {
+			return k, mr.unexpected(err)
+		}
+
+		for ; len(b) > 0; b = b[w:] {
+
+			var v float64
+
+			switch typ {
+			case miInt8:
+				v = float64(int8(b[0]))
+			case miUInt8:
+				v = float64(b[0])
+			case miInt16:
+				v = float64(int16(mr.order.Uint16(b)))
+			case miUInt16:
+				v = float64(mr.order.Uint16(b))
+			case miInt32:
+				v = float64(int32(mr.order.Uint32(b)))
+			case miUInt32:
+				v = float64(mr.order.Uint32(b))
+			case miSingle:
+				v = float64(math.Float32frombits(mr.order.Uint32(b)))
+			case miDouble:
+				v = math.Float64frombits(mr.order.Uint64(b))
+			case miInt64:
+				v = float64(int64(mr.order.Uint64(b)))
+			case miUInt64:
+				v = float64(mr.order.Uint64(b))
+			}
+
+			if err := fn(k, v); err != nil {
+				return k, err
+			}
+
+			k++
+		}
+	}
+
+	if small == nil {
+		if _, err := io.CopyN(io.Discard, mr.r, int64(pad8(size))); err != nil {
+			return n, mr.unexpected(err)
+		}
+	}
+
+	return n, nil
+}
+
+// unexpected reports the end of the input within a data element as
+// ErrTruncated.
+func (mr *matReader) unexpected(err error) error {
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: data element is incomplete", ErrTruncated)
+	}
+
+	return err
+}
+
+// header reads the array flags, dimensions and name of an array.
+func (mr *matReader) header() (*matVar, error) {
+
+	var v matVar
+
+	_, flags, err := mr.bytes(8)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(flags) != 8 {
+		return nil, fmt.Errorf("%w: array flags of %d bytes", ErrInputScanError, len(flags))
+	}
+
+	// the flags and class are the low bytes of the first word, and the
+	// maximum number of nonzeros of a sparse array is the second word
+	word := mr.order.Uint32(flags)
+	v.class = byte(word)
+	v.complex = word>>8&mxComplexFlag != 0
+	v.logical = word>>8&mxLogicalFlag != 0
+	v.nzmax = int(mr.order.Uint32(flags[4:]))
+
+	_, err = mr.numbers(32, func(k int, d float64) error {
+		if d < 0 {
+			return fmt.Errorf("%w: negative dimension", ErrInvalidSize)
+		}
+		v.dims = append(v.dims, int(d))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, name, err := mr.bytes(maxScanTokenSize)
+	if err != nil {
+		return nil, err
+	}
+	v.name = string(name)
+
+	return &v, nil
+}
+
+// findMAT reads the header of the MAT-file read from r, and returns a
+// reader over the data of the variable named name or, if name is empty,
+// of the first variable for which ok reports true.
+func findMAT(r io.Reader, name string, ok func(v *matVar) bool) (*matReader, *matVar, error) {
+
+	var hdr [128]byte
+
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, nil, ErrNotMAT
+	}
+
+	mr := matReader{r: r}
+
+	// the endian indicator is written as "IM" by a little-endian writer
+	switch string(hdr[126:]) {
+	case "IM":
+		mr.order = binary.LittleEndian
+	case "MI":
+		mr.order = binary.BigEndian
+	default:
+		return nil, nil, ErrNotMAT
+	}
+
+	if mr.order.Uint16(hdr[124:]) != 0x0100 {
+		return nil, nil, fmt.Errorf("%w: unsupported MAT-file version %#04x", ErrNotMAT, mr.order.Uint16(hdr[124:]))
+	}
+
+	for {
+
+		typ, size, small, err := mr.tag()
+		if err == io.EOF {
+			if name == "" {
+				return nil, nil, ErrNoVariable
+			}
+			return nil, nil, fmt.Errorf("%w: %q", ErrNoVariable, name)
+		}
+		if err != nil {
+			return nil, nil, mr.unexpected(err)
+		}
+
+		if small != nil {
+			continue
+		}
+
+		elem := io.LimitReader(r, int64(size))
+
+		body := &matReader{r: elem, order: mr.order}
+
+		switch typ {
+
+		case miMatrix:
+
+		case miCompressed:
+			zr, err := zlib.NewReader(elem)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w: %v", ErrInputScanError, err)
+			}
+
+			typ, size, _, err := (&matReader{r: zr, order: mr.order}).tag()
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w: %v", ErrInputScanError, err)
+			}
+
+			if typ != miMatrix {
+				if _, err := io.Copy(io.Discard, elem); err != nil {
+					return nil, nil, err
+				}
+				continue
+			}
+
+			body.r = io.LimitReader(zr, int64(size))
+
+		default:
+			if _, err := io.Copy(io.Discard, elem); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		v, err := body.header()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if (name == "" && ok(v)) || (name != "" && v.name == name) {
+			return body, v, nil
+		}
+
+		// the remaining data of the element are discarded, without being
+		// decompressed
+		if _, err := io.Copy(io.Discard, elem); err != nil {
+			return nil, nil, mr.unexpected(err)
+		}
+	}
+}
+
+// readMATDense reads the data of a numeric array, checking its size
+// against the limits, calling alloc with its dimensions as transformed
+// once its elements are read and then set with each of its elements as
+// transformed. The imaginary
+// part of a complex array is read only if T is complex.
+func readMATDense[T Scalar](mr *matReader, v *matVar, o *readOptions, alloc func(M, N int), set func(i, j int, v T)) error {
+
+	if !v.isNumeric() || len(v.dims) != 2 || v.complex != isComplex[T]() {
+		return ErrUnsupportedType
+	}
+
+	M, N := v.dims[0], v.dims[1]
+
+	L, err := elements(M, N)
+	if err != nil {
+		return err
+	}
+
+	if err := o.limits.checkSize(M, N, L); err != nil {
+		return err
+	}
+
+	if err := o.xform.check(M, N); err != nil {
+		return err
+	}
+
+	// dense storage cannot be allocated for an empty matrix
+	Mx, Nx := o.xform.dims(M, N)
+	if Mx == 0 || Nx == 0 {
+		return ErrInvalidSize
+	}
+
+	// elements are read prior to allocating dense storage, such that the
+	// storage is not allocated for the dimensions of a header alone. The
+	// real and imaginary parts of a complex array are held separately.
+	vals := make([]T, 0, prealloc(L))
+
+	n, err := mr.numbers(L, func(_ int, x float64) error {
+		vals = append(vals, narrow[T](complex(x, 0)))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if n != L {
+		return fmt.Errorf("%w: %d of %d elements", ErrInvalidSize, n, L)
+	}
+
+	if v.complex {
+
+		n, err := mr.numbers(L, func(k int, x float64) error {
+			vals[k] += narrow[T](complex(0, x))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if n != L {
+			return fmt.Errorf("%w: %d of %d elements", ErrInvalidSize, n, L)
+		}
+	}
+
+	alloc(Mx, Nx)
+
+	// elements are in column major order
+	for k, z := range vals {
+		if i, j, z, ok := apply(o.xform, k%M, k/M, z); ok {
+			set(i, j, z)
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalMATFrom deserializes the variable named name from the MATLAB
+// Level 5 MAT-file read from r into the receiver, as configured by opts,
+// and returns the number of bytes read. If name is empty, the first
+// sparse, real-valued variable is read. The variable must be a real or
+// logical sparse array, of which a logical array is read as a pattern
+// matrix. Compressed variables are read, and other variables are skipped
+// without being decompressed.
+func (m *COO) UnmarshalMATFrom(r io.Reader, name string, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(o.limits.reader(r), &n)
+
+	mr, v, err := findMAT(r, name, func(v *matVar) bool {
+		return v.class == mxSparseClass && !v.complex && len(v.dims) == 2
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	if v.class != mxSparseClass || v.complex || len(v.dims) != 2 {
+		return n.total, ErrUnsupportedType
+	}
+
+	M, N := v.dims[0], v.dims[1]
+
+	if _, err := elements(M, N); err != nil {
+		return n.total, err
+	}
+
+	if err := o.limits.checkSize(M, N, v.nzmax); err != nil {
+		return n.total, err
+	}
+
+	if err := o.xform.check(M, N); err != nil {
+		return n.total, err
+	}
+
+	if err := o.checkRows(N); err != nil {
+		return n.total, err
+	}
+
+	// row indices (ir) and column pointers (jc) are of compressed sparse
+	// column form, and are accumulated as read, such that storage is
+	// bounded by the input rather than by the header
+	ir := make([]int, 0, prealloc(v.nzmax))
+	_, err = mr.numbers(v.nzmax, func(k int, i float64) error {
+		if i < 0 || int(i) >= M {
+			return ErrIndexOutOfRange
+		}
+		ir = append(ir, int(i))
+		return nil
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	jc := make([]int, 0, prealloc(N+1))
+	_, err = mr.numbers(N+1, func(k int, p float64) error {
+		if p < 0 || int(p) > len(ir) || (k > 0 && int(p) < jc[k-1]) {
+			return fmt.Errorf("%w: invalid column pointer %v", ErrInputScanError, p)
+		}
+		jc = append(jc, int(p))
+		return nil
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	if len(jc) != N+1 || jc[0] != 0 {
+		return n.total, fmt.Errorf("%w: invalid column pointers", ErrInputScanError)
+	}
+
+	L := jc[N]
+
+	c := newTriplets[int, float64](L, o.duplicates, false)
+	c.x = o.xform
+	if v.logical {
+		c.x = o.xform.indices()
+	}
+
+	// the column of each entry
+	cols := make([]int, 0, prealloc(L))
+	for j := 0; j < N; j++ {
+		for p := jc[j]; p < jc[j+1]; p++ {
+			cols = append(cols, j)
+		}
+	}
+
+	_, err = mr.numbers(v.nzmax, func(k int, x float64) error {
+
+		if k >= L || (x == 0 && o.zeros == ZeroDrop) {
+			return nil
+		}
+
+		_, err := c.add(ir[k], cols[k], x)
+		return err
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	Mx, Nx := o.xform.dims(M, N)
+	d := sparse.NewCOO(Mx, Nx, c.rows, c.cols, c.data)
+	if o.coo != nil {
+		*o.coo = *d
+		d = o.coo
+	}
+
+	// apply header fields
+	m.Object = mtxObjectMatrix
+	m.Format = mtxFormatCoordinate
+	m.Field = mtxFieldReal
+	if v.logical {
+		m.Field = mtxFieldPattern
+	}
+	m.Symmetry = mtxSymmetryGeneral
+	m.mat = d
+
+	return n.total, nil
+}
+
+// UnmarshalMATFrom deserializes the variable named name from the MATLAB
+// Level 5 MAT-file read from r into the receiver, as configured by opts,
+// and returns the number of bytes read. If name is empty, the first
+// real-valued numeric variable is read. The variable must be a real or
+// logical two-dimensional numeric array, of which an integer or logical
+// array is read as an integer matrix.
+func (m *Dense) UnmarshalMATFrom(r io.Reader, name string, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(o.limits.reader(r), &n)
+
+	mr, v, err := findMAT(r, name, func(v *matVar) bool {
+		return v.isNumeric() && !v.complex && len(v.dims) == 2
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	var d *mat.Dense
+
+	err = readMATDense(mr, v, o,
+		func(M, N int) { d = o.newDense(M, N) },
+		func(i, j int, x float64) { d.Set(i, j, x) },
+	)
+	if err != nil {
+		return n.total, err
+	}
+
+	*m = *NewDense(d)
+	if v.isInteger() {
+		m.Field = mtxFieldInteger
+	}
+
+	return n.total, nil
+}
+
+// UnmarshalMATFrom deserializes the variable named name from the MATLAB
+// Level 5 MAT-file read from r into the receiver, as configured by opts,
+// and returns the number of bytes read. If name is empty, the first
+// complex-valued numeric variable is read. The variable must be a complex
+// two-dimensional numeric array.
+func (m *CDense) UnmarshalMATFrom(r io.Reader, name string, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(o.limits.reader(r), &n)
+
+	mr, v, err := findMAT(r, name, func(v *matVar) bool {
+		return v.isNumeric() && v.complex && len(v.dims) == 2
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	var d *mat.CDense
+
+	err = readMATDense(mr, v, o,
+		func(M, N int) { d = o.newCDense(M, N) },
+		func(i, j int, x complex128) { d.Set(i, j, x) },
+	)
+	if err != nil {
+		return n.total, err
+	}
+
+	*m = *NewCDense(d)
+
+	return n.total, nil
+}
+
+// pad8 returns the number of bytes padding n bytes to a multiple of
+// eight.
+func pad8(n int) int { return (8 - n%8) % 8 }
+
+// matWriter builds the data elements of a MAT-file, which are written in
+// little-endian byte order.
+type matWriter struct {
+	bytes.Buffer
+}
+
+// element writes a data element of type typ, in the small data element
+// format if of at most four bytes.
+func (mw *matWriter) element(typ uint32, data []byte) {
+
+	var tag [8]byte
+
+	if len(data) <= 4 {
+		binary.LittleEndian.PutUint32(tag[:4], uint32(len(data))<<16|typ)
+		copy(tag[4:], data)
+		mw.Write(tag[:])
+		return
+	}
+
+	binary.LittleEndian.PutUint32(tag[:4], typ)
+	binary.LittleEndian.PutUint32(tag[4:], uint32(len(data)))
+
+	mw.Write(tag[:])
+	mw.Write(data)
+	mw.Write(make([]byte, pad8(len(data))))
+}
+
+// int32s writes a data element of the n integers returned by fn.
+func (mw *matWriter) int32s(n int, fn func(k int) int) {
+
+	b := make([]byte, 0, 4*n)
+	for k := 0; k < n; k++ {
+		b = binary.LittleEndian.AppendUint32(b, uint32(fn(k)))
+	}
+
+	mw.element(miInt32, b)
+}
+
+// doubles writes a data element of the n numbers returned by fn.
+func (mw *matWriter) doubles(n int, fn func(k int) float64) {
+
+	b := make([]byte, 0, 8*n)
+	for k := 0; k < n; k++ {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(fn(k)))
+	}
+
+	mw.element(miDouble, b)
+}
+
+// writeMAT writes a MAT-file to w holding the M×N array of name, of class
+// and flags, the data of which are written by data, and returns the
+// number of bytes written. The array is compressed per o.
+func writeMAT(w io.Writer, name string, class, flags byte, nzmax, M, N int, o *writeOptions, data func(mw *matWriter)) (int, error) {
+
+	var total int
+
+	if !matNameRE.MatchString(name) {
+		return total, fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+
+	// dimensions and indices are of 32 bits
+	if M > math.MaxInt32 || N > math.MaxInt32 || nzmax > math.MaxInt32 {
+		return total, fmt.Errorf("%w: %d×%d matrix exceeds MAT-file dimensions", ErrInvalidSize, M, N)
+	}
+
+	var body matWriter
+
+	var af [8]byte
+	binary.LittleEndian.PutUint32(af[:4], uint32(flags)<<8|uint32(class))
+	binary.LittleEndian.PutUint32(af[4:], uint32(nzmax))
+
+	body.element(miUInt32, af[:])
+	body.int32s(2, func(k int) int { return []int{M, N}[k] })
+	body.element(miInt8, []byte(name))
+	data(&body)
+
+	var file matWriter
+
+	// header text, subsystem data offset, version and endian indicator
+	hdr := make([]byte, 128)
+	copy(hdr, matHeaderText+strings.Repeat(" ", 116-len(matHeaderText)))
+	binary.LittleEndian.PutUint16(hdr[124:], 0x0100)
+	copy(hdr[126:], "IM")
+	file.Write(hdr)
+
+	if o.compressed {
+
+		var z matWriter
+
+		z.element(miMatrix, body.Bytes())
+
+		var b bytes.Buffer
+
+		zw := zlib.NewWriter(&b)
+		zw.Write(z.Bytes())
+		zw.Close()
+
+		// compressed elements are not padded
+		var tag [8]byte
+		binary.LittleEndian.PutUint32(tag[:4], miCompressed)
+		binary.LittleEndian.PutUint32(tag[4:], uint32(b.Len()))
+		file.Write(tag[:])
+		file.Write(b.Bytes())
+
+	} else {
+		file.element(miMatrix, body.Bytes())
+	}
+
+	n, err := w.Write(file.Bytes())
+	total += n
+	if err != nil {
+		return total, ErrUnwritable
+	}
+
+	return total, nil
+}
+
+// MarshalMATTo serializes the receiver to w as a MATLAB Level 5 MAT-file
+// holding a sparse array named name, as configured by opts, and returns
+// the number of bytes written. Duplicate entries are merged, as sparse
+// arrays hold each entry once, and the matrix is compressed given
+// WriteCompressed.
+func (m *COO) MarshalMATTo(w io.Writer, name string, opts ...WriteOption) (int, error) {
+
+	o := newWriteOptions(opts)
+
+	c := newTriplets[int, float64](m.mat.NNZ(), o.duplicates, false)
+
+	var err error
+	m.Do(func(i, j int, v float64) {
+		if err == nil {
+			_, err = c.add(i, j, v)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	c.sort(OrderColMajor)
+
+	if o.zeros == ZeroDrop {
+		c.dropZeros()
+	}
+
+	M, N := m.mat.Dims()
+	L := len(c.data)
+
+	// column pointers
+	jc := make([]int, N+1)
+	for _, j := range c.cols {
+		jc[j+1]++
+	}
+	for j := 0; j < N; j++ {
+		jc[j+1] += jc[j]
+	}
+
+	return writeMAT(w, name, mxSparseClass, 0, max(L, 1), M, N, o, func(mw *matWriter) {
+		mw.int32s(L, func(k int) int { return c.rows[k] })
+		mw.int32s(N+1, func(k int) int { return jc[k] })
+		mw.doubles(L, func(k int) float64 { return c.data[k] })
+	})
+}
+
+// MarshalMATTo serializes the receiver to w as a MATLAB Level 5 MAT-file
+// holding a double array named name, as configured by opts, and returns
+// the number of bytes written. The matrix is compressed given
+// WriteCompressed.
+func (m *Dense) MarshalMATTo(w io.Writer, name string, opts ...WriteOption) (int, error) {
+
+	o := newWriteOptions(opts)
+
+	M, N := m.mat.Dims()
+
+	return writeMAT(w, name, mxDoubleClass, 0, 0, M, N, o, func(mw *matWriter) {
+		mw.doubles(M*N, func(k int) float64 { return m.mat.At(k%M, k/M) })
+	})
+}
+
+// MarshalMATTo serializes the receiver to w as a MATLAB Level 5 MAT-file
+// holding a complex double array named name, as configured by opts, and
+// returns the number of bytes written. The matrix is compressed given
+// WriteCompressed.
+func (m *CDense) MarshalMATTo(w io.Writer, name string, opts ...WriteOption) (int, error) {
+
+	o := newWriteOptions(opts)
+
+	M, N := m.mat.Dims()
+
+	return writeMAT(w, name, mxDoubleClass, mxComplexFlag, 0, M, N, o, func(mw *matWriter) {
+		mw.doubles(M*N, func(k int) float64 { return real(m.mat.At(k%M, k/M)) })
+		mw.doubles(M*N, func(k int) float64 { return imag(m.mat.At(k%M, k/M)) })
+	})
+}
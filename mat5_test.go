@@ -0,0 +1,291 @@
+package market
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// matOrder is the byte order of a MAT-file.
+type matOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// matFile returns a MAT-file of the data elements, which are appended to
+// a header as written by MATLAB.
+func matFile(order matOrder, elements ...[]byte) []byte {
+
+	b := make([]byte, 128)
+	copy(b, "MATLAB 5.0 MAT-file, Platform: GLNXA64, Created on: Mon Jan  1 00:00:00 2024")
+	order.PutUint16(b[124:], 0x0100)
+	order.PutUint16(b[126:], 'M'<<8|'I')
+
+	for _, e := range elements {
+		b = append(b, e...)
+	}
+
+	return b
+}
+
+// matElement returns a data element of type typ, in the small data
+// element format if of at most four bytes.
+func matElement(order matOrder, typ uint32, data []byte) []byte {
+
+	var b []byte
+
+	if len(data) <= 4 {
+		b = order.AppendUint32(b, uint32(len(data))<<16|typ)
+		return append(b, append(data, make([]byte, 4-len(data))...)...)
+	}
+
+	b = order.AppendUint32(b, typ)
+	b = order.AppendUint32(b, uint32(len(data)))
+	b = append(b, data...)
+
+	return append(b, make([]byte, pad8(len(data)))...)
+}
+
+// matArray returns a miMATRIX element of the subelements.
+func matArray(order matOrder, class, flags byte, nzmax, M, N int, name string, data ...[]byte) []byte {
+
+	var af, dims []byte
+	af = order.AppendUint32(af, uint32(flags)<<8|uint32(class))
+	af = order.AppendUint32(af, uint32(nzmax))
+	dims = order.AppendUint32(dims, uint32(M))
+	dims = order.AppendUint32(dims, uint32(N))
+
+	body := append(matElement(order, miUInt32, af), matElement(order, miInt32, dims)...)
+	body = append(body, matElement(order, miInt8, []byte(name))...)
+	for _, d := range data {
+		body = append(body, d...)
+	}
+
+	return matElement(order, miMatrix, body)
+}
+
+func TestDenseUnmarshalMATFrom(t *testing.T) {
+
+	// MATLAB stores the double array [1 2 3; 4 5 6] as uint8 data, and a
+	// short name in the small data element format
+	for _, order := range []matOrder{binary.LittleEndian, binary.BigEndian} {
+
+		file := matFile(order,
+			matArray(order, mxDoubleClass, 0, 0, 2, 3, "A",
+				matElement(order, miUInt8, []byte{1, 4, 2, 5, 3, 6}),
+			),
+		)
+
+		var m Dense
+
+		n, err := m.UnmarshalMATFrom(bytes.NewReader(file), "A")
+		if !assert.NoError(t, err, order) {
+			continue
+		}
+
+		assert.Equal(t, len(file), n)
+		assert.Equal(t, mtxFieldReal, m.Field)
+		assert.True(t, mat.Equal(mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6}), m.ToDense()), order)
+	}
+}
+
+func TestMATRoundTrip(t *testing.T) {
+
+	d := mat.NewDense(2, 3, []float64{1, -2.5, 0, 4e-300, 5, 6})
+	z := mat.NewCDense(2, 2, []complex128{1 + 2i, -3i, 4, 5 - 1i})
+
+	for _, opts := range [][]WriteOption{nil, {WriteCompressed()}} {
+
+		var b bytes.Buffer
+
+		// COO
+		_, err := NewCOO(mtx01).MarshalMATTo(&b, "S", opts...)
+		if assert.NoError(t, err) {
+
+			var m COO
+
+			_, err = m.UnmarshalMATFrom(&b, "S")
+			if assert.NoError(t, err) {
+				assert.Equal(t, mtxFieldReal, m.Field)
+				assert.True(t, mat.Equal(mtx01, m.ToCOO()))
+			}
+		}
+
+		// Dense
+		b.Reset()
+		_, err = NewDense(d).MarshalMATTo(&b, "D", opts...)
+		if assert.NoError(t, err) {
+
+			var m Dense
+
+			_, err = m.UnmarshalMATFrom(&b, "")
+			if assert.NoError(t, err) {
+				assert.True(t, mat.Equal(d, m.ToDense()))
+			}
+		}
+
+		// CDense
+		b.Reset()
+		_, err = NewCDense(z).MarshalMATTo(&b, "Z", opts...)
+		if assert.NoError(t, err) {
+
+			var m CDense
+
+			_, err = m.UnmarshalMATFrom(&b, "Z")
+			if assert.NoError(t, err) {
+				assert.True(t, mat.CEqual(z, m.ToCDense()))
+			}
+		}
+	}
+}
+
+func TestCOOMarshalMATTo(t *testing.T) {
+
+	var b bytes.Buffer
+
+	// duplicates are merged, and entries are of compressed sparse column
+	// form
+	c := sparse.NewCOO(2, 2, []int{1, 0, 1}, []int{1, 0, 1}, []float64{2, 1, 3})
+
+	_, err := NewCOO(c).MarshalMATTo(&b, "A")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	order := binary.LittleEndian
+
+	var ir, jc, pr []byte
+	ir = order.AppendUint32(order.AppendUint32(ir, 0), 1)
+	jc = order.AppendUint32(order.AppendUint32(order.AppendUint32(jc, 0), 1), 2)
+	pr = order.AppendUint64(order.AppendUint64(pr, 0x3ff0000000000000), 0x4014000000000000)
+
+	want := matArray(order, mxSparseClass, 0, 2, 2, 2, "A",
+		matElement(order, miInt32, ir),
+		matElement(order, miInt32, jc),
+		matElement(order, miDouble, pr),
+	)
+
+	assert.Equal(t, "MATLAB 5.0 MAT-file", b.String()[:19])
+	assert.Equal(t, "IM", b.String()[126:128])
+	assert.Equal(t, want, b.Bytes()[128:])
+}
+
+func TestUnmarshalMATFromVariables(t *testing.T) {
+
+	order := binary.LittleEndian
+
+	// a file of a logical sparse array, a double array and a complex array
+	var ir, jc []byte
+	ir = order.AppendUint32(ir, 1)
+	jc = order.AppendUint32(order.AppendUint32(order.AppendUint32(jc, 0), 0), 1)
+
+	file := matFile(order,
+		matArray(order, mxDoubleClass, mxComplexFlag, 0, 2, 1, "zvec",
+			matElement(order, miUInt8, []byte{1, 2}),
+			matElement(order, miUInt8, []byte{3, 4}),
+		),
+		matArray(order, mxSparseClass, mxLogicalFlag, 1, 2, 2, "mask",
+			matElement(order, miInt32, ir),
+			matElement(order, miInt32, jc),
+			matElement(order, miUInt8, []byte{1}),
+		),
+		matArray(order, mxDoubleClass, 0, 0, 1, 1, "x",
+			matElement(order, miDouble, order.AppendUint64(nil, 0x4000000000000000)),
+		),
+	)
+
+	var s COO
+	_, err := s.UnmarshalMATFrom(bytes.NewReader(file), "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, mtxFieldPattern, s.Field)
+		assert.True(t, mat.Equal(mat.NewDense(2, 2, []float64{0, 0, 0, 1}), s.ToCOO()))
+	}
+
+	var d Dense
+	_, err = d.UnmarshalMATFrom(bytes.NewReader(file), "")
+	if assert.NoError(t, err) {
+		assert.True(t, mat.Equal(mat.NewDense(1, 1, []float64{2}), d.ToDense()))
+	}
+
+	var z CDense
+	_, err = z.UnmarshalMATFrom(bytes.NewReader(file), "zvec")
+	if assert.NoError(t, err) {
+		assert.True(t, mat.CEqual(mat.NewCDense(2, 1, []complex128{1 + 3i, 2 + 4i}), z.ToCDense()))
+	}
+
+	// named variables must be of the class of the receiver
+	_, err = d.UnmarshalMATFrom(bytes.NewReader(file), "mask")
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = d.UnmarshalMATFrom(bytes.NewReader(file), "y")
+	assert.ErrorIs(t, err, ErrNoVariable)
+
+	_, err = d.UnmarshalMATFrom(bytes.NewReader(file[:len(file)-4]), "x")
+	assert.ErrorIs(t, err, ErrTruncated)
+
+	_, err = d.UnmarshalMATFrom(bytes.NewReader(file), "x", WithLimits(Limits{MaxBytes: 200}))
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	_, err = d.UnmarshalMATFrom(bytes.NewReader([]byte("%%MatrixMarket matrix array real general\n")), "")
+	assert.ErrorIs(t, err, ErrNotMAT)
+}
+
+func TestUnmarshalMATFromHostile(t *testing.T) {
+
+	order := binary.LittleEndian
+
+	var ir, jc []byte
+	ir = order.AppendUint32(ir, 1)
+	jc = order.AppendUint32(order.AppendUint32(order.AppendUint32(jc, 0), 0), 1)
+
+	// storage is bounded by the data read rather than by nzmax
+	var s COO
+
+	_, err := s.UnmarshalMATFrom(bytes.NewReader(matFile(order,
+		matArray(order, mxSparseClass, 0, 50000000, 2, 2, "s",
+			matElement(order, miInt32, ir),
+			matElement(order, miInt32, jc),
+			matElement(order, miUInt8, []byte{1}),
+		),
+	)), "")
+	if assert.NoError(t, err) {
+		assert.True(t, mat.Equal(mat.NewDense(2, 2, []float64{0, 0, 0, 1}), s.ToCOO()))
+	}
+
+	// column pointers are held for every column
+	_, err = s.UnmarshalMATFrom(bytes.NewReader(matFile(order,
+		matArray(order, mxSparseClass, 0, 1, 2, 50000000, "s",
+			matElement(order, miInt32, ir),
+			matElement(order, miInt32, jc),
+			matElement(order, miUInt8, []byte{1}),
+		),
+	)), "")
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	// dense storage is not allocated for the dimensions alone
+	var d Dense
+
+	_, err = d.UnmarshalMATFrom(bytes.NewReader(matFile(order,
+		matArray(order, mxDoubleClass, 0, 0, 7000, 7000, "d",
+			matElement(order, miUInt8, []byte{1}),
+		),
+	)), "")
+	assert.ErrorIs(t, err, ErrInvalidSize)
+}
+
+func TestMarshalMATToErrors(t *testing.T) {
+
+	d := NewDense(mat.NewDense(1, 1, []float64{1}))
+
+	for _, name := range []string{"", "1x", "a b", "a-b", string(make([]byte, 64))} {
+		_, err := d.MarshalMATTo(&bytes.Buffer{}, name)
+		assert.ErrorIs(t, err, ErrInvalidName, name)
+	}
+
+	_, err := d.MarshalMATTo(failWriter{}, "A")
+	assert.ErrorIs(t, err, ErrUnwritable)
+}
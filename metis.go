@@ -0,0 +1,470 @@
+package market
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/james-bowman/sparse"
+)
+
+// METISInfo holds the vertex weights and sizes of a METIS graph file,
+// which are read and written alongside the adjacency matrix of the
+// graph.
+type METISInfo struct {
+	VertexWeights [][]int // weights of each vertex, one per constraint, or nil
+	VertexSizes   []int   // sizes of each vertex (communication volumes), or nil
+}
+
+// metisHeader is the header line of a METIS graph file.
+type metisHeader struct {
+	n, m     int  // number of vertices and of (undirected) edges
+	sizes    bool // whether vertex sizes are given
+	vweights bool // whether vertex weights are given
+	eweights bool // whether edge weights are given
+	ncon     int  // number of weights of each vertex
+}
+
+// scanMETISHeader scans the header line of a METIS graph file, of the
+// form "n m [fmt [ncon]]", skipping any preceding comments.
+func scanMETISHeader(scanner *lineScanner, o *readOptions) (*metisHeader, error) {
+
+	var fields []string
+
+	for len(fields) == 0 {
+
+		if !scanner.Scan() {
+			if err := scanError(scanner.Scanner); err != nil {
+				return nil, err
+			}
+			return nil, ErrPrematureEOF
+		}
+
+		if line := scanner.Text(); !strings.HasPrefix(line, "%") {
+			fields = appendFields(fields, line)
+		}
+	}
+
+	if len(fields) < 2 || len(fields) > 4 {
+		return nil, scanner.errorf(scanner.line, fmt.Errorf("%w: invalid header", ErrInputScanError))
+	}
+
+	var h metisHeader
+
+	ints := make([]int, len(fields))
+	for k, tok := range fields {
+
+		v, err := parseInt(tok)
+		if err != nil {
+			return nil, scanner.errorf(scanner.line, err)
+		}
+
+		if v < 0 {
+			return nil, scanner.errorf(scanner.line, ErrInvalidSize)
+		}
+
+		ints[k] = v
+	}
+
+	h.n, h.m = ints[0], ints[1]
+
+	// the digits of fmt flag vertex sizes, vertex weights and edge weights
+	if len(fields) > 2 {
+
+		f := fields[2]
+		if len(f) > 3 || strings.Trim(f, "01") != "" {
+			return nil, scanner.errorf(scanner.line, fmt.Errorf("%w: invalid format %q", ErrInputScanError, f))
+		}
+
+		f = strings.Repeat("0", 3-len(f)) + f
+		h.sizes, h.vweights, h.eweights = f[0] == '1', f[1] == '1', f[2] == '1'
+	}
+
+	if h.vweights {
+		h.ncon = 1
+	}
+
+	if len(fields) > 3 {
+
+		if !h.vweights || ints[3] < 1 {
+			return nil, scanner.errorf(scanner.line, fmt.Errorf("%w: invalid number of constraints %d", ErrInputScanError, ints[3]))
+		}
+
+		h.ncon = ints[3]
+	}
+
+	// each edge is listed in the adjacency of both of its vertices
+	if h.m > math.MaxInt/2 {
+		return nil, scanner.errorf(scanner.line, ErrIndexOverflow)
+	}
+
+	if err := o.limits.checkSize(h.n, h.n, 2*h.m); err != nil {
+		return nil, err
+	}
+
+	if err := o.xform.check(h.n, h.n); err != nil {
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+// checkUndirected returns an error wrapping ErrInvalidGraph unless the
+// triplets, of which duplicates are merged, are those of a symmetric
+// matrix without diagonal entries, being the adjacency matrix of an
+// undirected graph. Values are compared only if weights is true.
+func checkUndirected(c *triplets[int, float64], weights bool) error {
+
+	t := newTriplets[int, float64](len(c.data), DuplicateSum, false)
+	for k, v := range c.data {
+		t.add(c.cols[k], c.rows[k], v)
+	}
+
+	c.sort(OrderRowMajor)
+	t.sort(OrderRowMajor)
+
+	for k := range c.data {
+
+		i, j := c.rows[k], c.cols[k]
+
+		if i == j {
+			return fmt.Errorf("%w: self-loop at vertex %d", ErrInvalidGraph, i+1)
+		}
+
+		if k >= len(t.data) || t.rows[k] != i || t.cols[k] != j || (weights && t.data[k] != c.data[k]) {
+			return fmt.Errorf("%w: edge (%d, %d) has no matching edge (%d, %d)", ErrInvalidGraph, i+1, j+1, j+1, i+1)
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalMETISFrom deserializes the adjacency matrix of a graph from
+// METIS graph format into the receiver, as configured by opts, and returns
+// the number of bytes read. Vertex weights and sizes, if any, are read
+// into info, if non-nil. The matrix is symmetric, without diagonal
+// entries, and of the integer field if edge weights are given, and
+// otherwise of the pattern field. Chaco graph files, which differ only in
+// giving vertex numbers in place of vertex sizes, are likewise read. An
+// error wrapping ErrInvalidGraph is returned if the adjacency lists are
+// not those of an undirected graph of m edges.
+func (m *COO) UnmarshalMETISFrom(r io.Reader, info *METISInfo, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	scanner := newScanner(r, o)
+
+	h, err := scanMETISHeader(scanner, o)
+	if err != nil {
+		return n.total, err
+	}
+
+	if err := o.checkRows(h.n); err != nil {
+		return n.total, scanner.errorf(scanner.line, err)
+	}
+
+	// vertex sizes and weights are accumulated as each vertex is read,
+	// such that storage is bounded by the input rather than by the header
+	var (
+		c      = newTriplets[int, float64](2*h.m, o.duplicates, false)
+		sizes  []int
+		wgts   [][]int
+		fields []string
+	)
+
+	if h.sizes {
+		sizes = make([]int, 0, prealloc(h.n))
+	}
+
+	if h.vweights {
+		wgts = make([][]int, 0, prealloc(h.n))
+	}
+
+	// each vertex is a line, such that blank lines are of vertices without
+	// neighbours, whereas comments are skipped
+	for i := 0; i < h.n; {
+
+		if !scanner.Scan() {
+			if err := scanError(scanner.Scanner); err != nil {
+				return n.total, err
+			}
+			return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: %d of %d vertices", ErrTruncated, i, h.n))
+		}
+
+		line := scanner.Text()
+		if strings.HasPrefix(line, "%") {
+			continue
+		}
+
+		fields = appendFields(fields[:0], line)
+
+		ints := make([]int, len(fields))
+		for k, tok := range fields {
+			v, err := parseInt(tok)
+			if err != nil {
+				return n.total, scanner.errorf(scanner.line, err)
+			}
+			ints[k] = v
+		}
+
+		if h.sizes {
+			if len(ints) < 1 {
+				return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: missing vertex size", ErrInputScanError))
+			}
+			sizes, ints = append(sizes, ints[0]), ints[1:]
+		}
+
+		if h.vweights {
+			if len(ints) < h.ncon {
+				return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: missing vertex weights", ErrInputScanError))
+			}
+			wgts, ints = append(wgts, ints[:h.ncon:h.ncon]), ints[h.ncon:]
+		}
+
+		stride := 1
+		if h.eweights {
+			stride = 2
+		}
+
+		if len(ints)%stride != 0 {
+			return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: missing edge weight", ErrInputScanError))
+		}
+
+		for k := 0; k < len(ints); k += stride {
+
+			j := ints[k]
+			if j < 1 || j > h.n {
+				return n.total, scanner.errorf(scanner.line, ErrIndexOutOfRange)
+			}
+
+			v := 1.0
+			if h.eweights {
+				v = float64(ints[k+1])
+			}
+
+			if len(c.data) == 2*h.m {
+				return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: more than %d edges", ErrInvalidGraph, h.m))
+			}
+
+			if _, err := c.add(i, j-1, v); err != nil {
+				return n.total, scanner.errorf(scanner.line, err)
+			}
+		}
+
+		i++
+	}
+
+	if len(c.data) != 2*h.m {
+		return n.total, fmt.Errorf("%w: %d of %d edges", ErrInvalidGraph, len(c.data)/2, h.m)
+	}
+
+	if err := checkUndirected(c, h.eweights); err != nil {
+		return n.total, err
+	}
+
+	// entries are transformed once validated
+	x := o.xform
+	if !h.eweights {
+		x = o.xform.indices()
+	}
+
+	d := newTriplets[int, float64](len(c.data), DuplicateSum, false)
+	d.x = x
+
+	for k, v := range c.data {
+		if v == 0 && o.zeros == ZeroDrop {
+			continue
+		}
+		d.add(c.rows[k], c.cols[k], v)
+	}
+
+	Mx, Nx := o.xform.dims(h.n, h.n)
+	a := sparse.NewCOO(Mx, Nx, d.rows, d.cols, d.data)
+	if o.coo != nil {
+		*o.coo = *a
+		a = o.coo
+	}
+
+	// apply header fields
+	m.Object = mtxObjectMatrix
+	m.Format = mtxFormatCoordinate
+	m.Field = mtxFieldPattern
+	if h.eweights {
+		m.Field = mtxFieldInteger
+	}
+	m.Symmetry = o.xform.symmetry(mtxSymmetrySymm)
+	m.mat = a
+
+	if info != nil {
+		info.VertexSizes = selectRows(o.xform, sizes)
+		info.VertexWeights = selectRows(o.xform, wgts)
+	}
+
+	return n.total, nil
+}
+
+// MarshalMETISTo serializes the receiver, as the adjacency matrix of a
+// graph, to w in METIS graph format, as configured by opts, and returns
+// the number of bytes written. Vertex weights and sizes are written from
+// info, if non-nil. The matrix must be square and, once its diagonal is
+// dropped, pattern-symmetric, or else an error wrapping ErrInvalidGraph
+// is returned. Entries are written as edge weights if of the integer
+// field, in which case they must be positive and symmetric, or if
+// writing WriteEdgeWeights.
+func (m *COO) MarshalMETISTo(w io.Writer, info *METISInfo, opts ...WriteOption) (int, error) {
+
+	var n counter
+
+	o := newWriteOptions(opts)
+
+	M, N := m.mat.Dims()
+	if M != N {
+		return 0, fmt.Errorf("%w: %d×%d matrix is not square", ErrInvalidGraph, M, N)
+	}
+
+	var (
+		sizes []int
+		wgts  [][]int
+		ncon  int
+	)
+
+	if info != nil {
+		sizes, wgts = info.VertexSizes, info.VertexWeights
+	}
+
+	if sizes != nil && len(sizes) != M {
+		return 0, fmt.Errorf("%w: %d vertex sizes for %d vertices", ErrInvalidSize, len(sizes), M)
+	}
+
+	if wgts != nil {
+
+		if len(wgts) != M {
+			return 0, fmt.Errorf("%w: %d vertex weights for %d vertices", ErrInvalidSize, len(wgts), M)
+		}
+
+		if M > 0 {
+			ncon = len(wgts[0])
+		}
+
+		for _, v := range wgts {
+			if len(v) != ncon || ncon == 0 {
+				return 0, fmt.Errorf("%w: vertex weights of differing numbers of constraints", ErrInvalidSize)
+			}
+		}
+	}
+
+	weights := o.edgeScale != 0 || m.Field == mtxFieldInteger
+
+	// the diagonal is dropped, and values are mapped to edge weights
+	c := newTriplets[int, float64](m.mat.NNZ(), o.duplicates, false)
+
+	var err error
+	m.Do(func(i, j int, v float64) {
+
+		if err != nil || i == j {
+			return
+		}
+
+		switch {
+
+		case o.edgeScale != 0:
+			v = math.Max(1, math.Round(math.Abs(v)*o.edgeScale))
+
+		case weights && (v < 1 || v != math.Trunc(v)):
+			err = fmt.Errorf("%w: edge (%d, %d) of weight %v", ErrInvalidGraph, i+1, j+1, v)
+			return
+		}
+
+		_, err = c.add(i, j, v)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	c.sort(OrderRowMajor)
+
+	if o.zeros == ZeroDrop {
+		c.dropZeros()
+	}
+
+	if err := checkUndirected(c, weights); err != nil {
+		return 0, err
+	}
+
+	bw := bufio.NewWriterSize(io.MultiWriter(w, &n), maxScanTokenSize)
+
+	var b []byte
+
+	// header
+	b = strconv.AppendInt(b, int64(M), 10)
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, int64(len(c.data)/2), 10)
+
+	flags := 0
+	if sizes != nil {
+		flags += 100
+	}
+	if wgts != nil {
+		flags += 10
+	}
+	if weights {
+		flags++
+	}
+
+	if flags != 0 {
+		b = append(b, ' ')
+		b = strconv.AppendInt(b, int64(flags), 10)
+	}
+
+	if ncon > 1 {
+		b = append(b, ' ')
+		b = strconv.AppendInt(b, int64(ncon), 10)
+	}
+
+	bw.Write(append(b, '\n'))
+
+	// adjacency lists, being the rows of the matrix
+	k := 0
+	for i := 0; i < M; i++ {
+
+		var fields []int
+
+		if sizes != nil {
+			fields = append(fields, sizes[i])
+		}
+
+		if wgts != nil {
+			fields = append(fields, wgts[i]...)
+		}
+
+		for ; k < len(c.data) && c.rows[k] == i; k++ {
+			fields = append(fields, c.cols[k]+1)
+			if weights {
+				fields = append(fields, int(c.data[k]))
+			}
+		}
+
+		b = b[:0]
+		for f, v := range fields {
+			if f > 0 {
+				b = append(b, ' ')
+			}
+			b = strconv.AppendInt(b, int64(v), 10)
+		}
+
+		bw.Write(append(b, '\n'))
+	}
+
+	if err := bw.Flush(); err != nil {
+		return n.total, ErrUnwritable
+	}
+
+	return n.total, nil
+}
@@ -0,0 +1,144 @@
+package market
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// metis01 is the unweighted graph of seven vertices and eleven edges of
+// the METIS manual.
+const metis01 = `% graph of the METIS manual
+7 11
+5 3 2
+1 3 4
+5 4 2 1
+2 3 6 7
+1 3 6
+5 4 7
+6 4
+`
+
+// metis02 is a weighted graph of three vertices, in which the second
+// vertex has no neighbours, with vertex sizes, two vertex weights and edge
+// weights.
+const metis02 = "3 1 111 2\n1 4 5 3 7\n2 0 1\n3 6 0 1 7\n"
+
+func TestCOOUnmarshalMETISFrom(t *testing.T) {
+
+	var m COO
+
+	n, err := m.UnmarshalMETISFrom(strings.NewReader(metis01), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, len(metis01), n)
+	assert.Equal(t, mtxFieldPattern, m.Field)
+	assert.Equal(t, mtxSymmetrySymm, m.Symmetry)
+	assert.Equal(t, 22, m.ToCOO().NNZ())
+	assert.Equal(t, 1.0, m.ToCOO().At(0, 4))
+	assert.Equal(t, 1.0, m.ToCOO().At(4, 0))
+	assert.Equal(t, 0.0, m.ToCOO().At(0, 3))
+
+	var info METISInfo
+
+	_, err = m.UnmarshalMETISFrom(strings.NewReader(metis02), &info)
+	if assert.NoError(t, err) {
+		assert.Equal(t, mtxFieldInteger, m.Field)
+		assert.True(t, mat.Equal(mat.NewDense(3, 3, []float64{0, 0, 7, 0, 0, 0, 7, 0, 0}), m.ToCOO()))
+		assert.Equal(t, []int{1, 2, 3}, info.VertexSizes)
+		assert.Equal(t, [][]int{{4, 5}, {0, 1}, {6, 0}}, info.VertexWeights)
+	}
+}
+
+func TestCOOUnmarshalMETISFromErrors(t *testing.T) {
+
+	for _, test := range []struct {
+		name string
+		file string
+		err  error
+	}{
+		{"empty", "% comment only\n", ErrPrematureEOF},
+		{"header", "3\n", ErrInputScanError},
+		{"format", "3 1 2\n", ErrInputScanError},
+		{"index", "2 1\n3\n1\n", ErrIndexOutOfRange},
+		{"vertices", "3 1\n2\n1\n", ErrTruncated},
+		{"edges", "2 2\n2\n1\n", ErrInvalidGraph},
+		{"asymmetric", "3 1\n2\n3\n\n", ErrInvalidGraph},
+		{"loop", "2 1\n1\n2\n", ErrInvalidGraph},
+		{"weights", "2 1 1\n2 3\n1 4\n", ErrInvalidGraph},
+		{"weight", "2 1 1\n2\n1 4\n", ErrInputScanError},
+		{"hostile", "10000000 0 110\n", ErrTruncated},
+		{"rows", "50000000 0 110\n", ErrLimitExceeded},
+	} {
+		var m COO
+
+		_, err := m.UnmarshalMETISFrom(strings.NewReader(test.file), nil)
+		assert.ErrorIs(t, err, test.err, test.name)
+	}
+
+	var m COO
+
+	_, err := m.UnmarshalMETISFrom(strings.NewReader(metis01), nil, WithLimits(Limits{MaxEntries: 20}))
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+}
+
+func TestCOOMarshalMETISTo(t *testing.T) {
+
+	// round trip
+	var m COO
+
+	_, err := m.UnmarshalMETISFrom(strings.NewReader(metis02), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var b bytes.Buffer
+
+	n, err := m.MarshalMETISTo(&b, &METISInfo{VertexSizes: []int{1, 2, 3}, VertexWeights: [][]int{{4, 5}, {0, 1}, {6, 0}}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, b.Len(), n)
+		assert.Equal(t, metis02, b.String())
+	}
+
+	// the diagonal is dropped, and real values are written as edge weights
+	// only if requested
+	c := sparse.NewCOO(3, 3, []int{0, 0, 1, 1, 2}, []int{0, 1, 0, 2, 1}, []float64{9, 0.5, 0.5, 2.4, 2.4})
+	a := &COO{mtxObjectMatrix, mtxFormatCoordinate, mtxFieldReal, mtxSymmetrySymm, c}
+
+	b.Reset()
+	_, err = a.MarshalMETISTo(&b, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "3 2\n2\n1 3\n2\n", b.String())
+	}
+
+	b.Reset()
+	_, err = a.MarshalMETISTo(&b, nil, WriteEdgeWeights(1))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "3 2 1\n2 1\n1 1 3 2\n2 2\n", b.String())
+	}
+
+	for _, test := range []struct {
+		name string
+		m    *COO
+		info *METISInfo
+		err  error
+	}{
+		{"square", NewCOO(sparse.NewCOO(2, 3, nil, nil, nil)), nil, ErrInvalidGraph},
+		{"asymmetric", NewCOO(sparse.NewCOO(2, 2, []int{0}, []int{1}, []float64{1})), nil, ErrInvalidGraph},
+		{"weight", &COO{mtxObjectMatrix, mtxFormatCoordinate, mtxFieldInteger, mtxSymmetryGeneral, sparse.NewCOO(2, 2, []int{0, 1}, []int{1, 0}, []float64{-1, -1})}, nil, ErrInvalidGraph},
+		{"sizes", a, &METISInfo{VertexSizes: []int{1}}, ErrInvalidSize},
+		{"constraints", a, &METISInfo{VertexWeights: [][]int{{1}, {1, 2}, {1}}}, ErrInvalidSize},
+	} {
+		_, err := test.m.MarshalMETISTo(&bytes.Buffer{}, test.info)
+		assert.ErrorIs(t, err, test.err, test.name)
+	}
+
+	_, err = a.MarshalMETISTo(failWriter{}, nil)
+	assert.ErrorIs(t, err, ErrUnwritable)
+}
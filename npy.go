@@ -0,0 +1,517 @@
+package market
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// npyMagic begins each file in NumPy .npy format.
+const npyMagic = "\x93NUMPY"
+
+// maxNPYHeader bounds the length of the header of a .npy file, which is
+// typically of 118 bytes, as a guard against corrupt input.
+const maxNPYHeader = 64 * 1024
+
+// npyHeader is the header of a file in NumPy .npy format.
+type npyHeader struct {
+	order   binary.ByteOrder
+	dtype   string // kind and size of the data type, such as "f8"
+	kind    byte   // data type: f, c, i, u, b or S
+	size    int    // bytes per element
+	fortran bool   // whether elements are in column major order
+	shape   []int
+}
+
+var (
+	npyDescrRE   = regexp.MustCompile(`'descr'\s*:\s*'([<>|=]?)([fciubS])(\d+)'`)
+	npyFortranRE = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	npyShapeRE   = regexp.MustCompile(`'shape'\s*:\s*\(([^)]*)\)`)
+)
+
+// readNPYHeader reads the header of a file in NumPy .npy format.
+func readNPYHeader(r io.Reader) (*npyHeader, error) {
+
+	var pre [10]byte
+
+	if _, err := io.ReadFull(r, pre[:8]); err != nil || string(pre[:6]) != npyMagic {
+		return nil, ErrNotNPY
+	}
+
+	// the header length is of two bytes in version 1, and of four bytes in
+	// versions 2 and 3
+	var L int
+	switch pre[6] {
+
+	case 1:
+		if _, err := io.ReadFull(r, pre[8:10]); err != nil {
+			return nil, ErrNotNPY
+		}
+		L = int(binary.LittleEndian.Uint16(pre[8:10]))
+
+	case 2, 3:
+		var b [4]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return nil, ErrNotNPY
+		}
+		L = int(binary.LittleEndian.Uint32(b[:]))
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported .npy version %d.%d", ErrNotNPY, pre[6], pre[7])
+	}
+
+	if L > maxNPYHeader {
+		return nil, fmt.Errorf("%w: .npy header of %d bytes", ErrLimitExceeded, L)
+	}
+
+	b := make([]byte, L)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, ErrNotNPY
+	}
+
+	dict := string(b)
+
+	var h npyHeader
+
+	// data type, such as '<f8'
+	m := npyDescrRE.FindStringSubmatch(dict)
+	if m == nil {
+		return nil, ErrUnsupportedType
+	}
+
+	h.order = binary.LittleEndian
+	if m[1] == ">" {
+		h.order = binary.BigEndian
+	}
+
+	h.kind = m[2][0]
+	h.size, _ = strconv.Atoi(m[3])
+	h.dtype = m[2] + m[3]
+
+	switch h.dtype {
+	case "f4", "f8", "c8", "c16", "b1":
+	case "i1", "i2", "i4", "i8":
+	case "u1", "u2", "u4", "u8":
+	default:
+		if h.kind != 'S' || h.size < 1 {
+			return nil, ErrUnsupportedType
+		}
+	}
+
+	// order of elements
+	m = npyFortranRE.FindStringSubmatch(dict)
+	if m == nil {
+		return nil, fmt.Errorf("%w: missing fortran_order in .npy header", ErrInputScanError)
+	}
+	h.fortran = m[1] == "True"
+
+	// shape, such as (3, 4) or (3,)
+	m = npyShapeRE.FindStringSubmatch(dict)
+	if m == nil {
+		return nil, fmt.Errorf("%w: missing shape in .npy header", ErrInputScanError)
+	}
+
+	for _, tok := range strings.Split(m[1], ",") {
+
+		if tok = strings.TrimSpace(tok); tok == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(strings.TrimSuffix(tok, "L"))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%w: shape (%s)", ErrInvalidSize, m[1])
+		}
+
+		h.shape = append(h.shape, n)
+	}
+
+	return &h, nil
+}
+
+// dims returns the dimensions of the matrix held by the array, of which a
+// scalar is 1×1 and a vector is a column vector.
+func (h *npyHeader) dims() (int, int, error) {
+
+	switch len(h.shape) {
+	case 0:
+		return 1, 1, nil
+	case 1:
+		return h.shape[0], 1, nil
+	case 2:
+		return h.shape[0], h.shape[1], nil
+	}
+
+	return 0, 0, fmt.Errorf("%w: %d-dimensional array", ErrUnsupportedType, len(h.shape))
+}
+
+// isComplex reports whether elements are complex.
+func (h *npyHeader) isComplex() bool { return h.kind == 'c' }
+
+// isInteger reports whether elements are integers or booleans.
+func (h *npyHeader) isInteger() bool { return strings.IndexByte("iub", h.kind) >= 0 }
+
+// value returns the element encoded by b.
+func (h *npyHeader) value(b []byte) complex128 {
+
+	switch h.dtype {
+	case "f4":
+		return complex(float64(math.Float32frombits(h.order.Uint32(b))), 0)
+	case "f8":
+		return complex(math.Float64frombits(h.order.Uint64(b)), 0)
+	case "c8":
+		return complex(float64(math.Float32frombits(h.order.Uint32(b))), float64(math.Float32frombits(h.order.Uint32(b[4:]))))
+	case "c16":
+		return complex(math.Float64frombits(h.order.Uint64(b)), math.Float64frombits(h.order.Uint64(b[8:])))
+	}
+
+	return complex(float64(h.int(b)), 0)
+}
+
+// int returns the integer element encoded by b.
+func (h *npyHeader) int(b []byte) int64 {
+
+	switch h.dtype {
+	case "i1":
+		return int64(int8(b[0]))
+	case "i2":
+		return int64(int16(h.order.Uint16(b)))
+	case "i4":
+		return int64(int32(h.order.Uint32(b)))
+	case "i8":
+		return int64(h.order.Uint64(b))
+	case "u1", "b1":
+		return int64(b[0])
+	case "u2":
+		return int64(h.order.Uint16(b))
+	case "u4":
+		return int64(h.order.Uint32(b))
+	case "u8":
+		return int64(min(h.order.Uint64(b), math.MaxInt64))
+	}
+
+	return 0
+}
+
+// readBlocks reads n elements of size bytes from r, calling fn with each
+// of their encodings, in the order read. Elements are read in blocks,
+// such that r is not read beyond the last element.
+func readBlocks(r io.Reader, n, size int, fn func(k int, b []byte) error) error {
+
+	// elements are read in blocks of at most 64 KiB
+	block := max(1, maxScanTokenSize/size)
+	buf := make([]byte, min(n, block)*size)
+
+	for k := 0; k < n; {
+
+		b := buf[:min(n-k, block)*size]
+		if _, err := io.ReadFull(r, b); err != nil {
+
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return fmt.Errorf("%w: %d of %d elements read", ErrTruncated, k, n)
+			}
+
+			return err
+		}
+
+		for ; len(b) > 0; b = b[size:] {
+
+			if err := fn(k, b[:size]); err != nil {
+				return err
+			}
+
+			k++
+		}
+	}
+
+	return nil
+}
+
+// readNPYMatrix reads a matrix from a .npy file, checking its size
+// against the limits, calling alloc with its dimensions as transformed
+// once its elements are read and then set with each of its elements as
+// transformed. A complex matrix
+// is read only if T is complex.
+func readNPYMatrix[T Scalar](r io.Reader, o *readOptions, alloc func(h *npyHeader, M, N int) error, set func(i, j int, v T)) error {
+
+	h, err := readNPYHeader(r)
+	if err != nil {
+		return err
+	}
+
+	if h.kind == 'S' || (h.isComplex() && !isComplex[T]()) {
+		return ErrUnsupportedType
+	}
+
+	M, N, err := h.dims()
+	if err != nil {
+		return err
+	}
+
+	L, err := elements(M, N)
+	if err != nil {
+		return err
+	}
+
+	if err := o.limits.checkSize(M, N, L); err != nil {
+		return err
+	}
+
+	if err := o.xform.check(M, N); err != nil {
+		return err
+	}
+
+	// dense storage cannot be allocated for an empty matrix
+	Mx, Nx := o.xform.dims(M, N)
+	if Mx == 0 || Nx == 0 {
+		return ErrInvalidSize
+	}
+
+	// elements are read prior to allocating dense storage, such that the
+	// storage is not allocated for the shape of a header alone
+	vals := make([]T, 0, prealloc(L))
+	err = readBlocks(r, L, h.size, func(k int, b []byte) error {
+		vals = append(vals, narrow[T](h.value(b)))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := alloc(h, Mx, Nx); err != nil {
+		return err
+	}
+
+	for k, v := range vals {
+
+		// elements are in row major order, unless in fortran order
+		i, j := k/N, k%N
+		if h.fortran {
+			i, j = k%M, k/M
+		}
+
+		if i, j, v, ok := apply(o.xform, i, j, v); ok {
+			set(i, j, v)
+		}
+	}
+
+	return nil
+}
+
+// npyDescr returns the descriptor of the little-endian data type of kind
+// and size, such as "<f8".
+func npyDescr(kind byte, size int) string {
+
+	if size == 1 || kind == 'S' {
+		return fmt.Sprintf("|%c%d", kind, size)
+	}
+
+	return fmt.Sprintf("<%c%d", kind, size)
+}
+
+// writeNPY writes an array of shape in .npy format, of n elements of the
+// little-endian data type of kind and size, each of which is encoded
+// into b by put, and returns the number of bytes written.
+func writeNPY(w io.Writer, kind byte, size int, fortran bool, shape []int, n int, put func(k int, b []byte)) (int, error) {
+
+	var total int
+
+	dims := make([]string, len(shape))
+	for k, d := range shape {
+		dims[k] = strconv.Itoa(d)
+	}
+
+	// a tuple of one element has a trailing comma
+	tuple := strings.Join(dims, ", ")
+	if len(shape) == 1 {
+		tuple += ","
+	}
+
+	order := "False"
+	if fortran {
+		order = "True"
+	}
+
+	dict := fmt.Sprintf("{'descr': '%s', 'fortran_order': %s, 'shape': (%s), }", npyDescr(kind, size), order, tuple)
+
+	// the header is padded with spaces and terminated by a newline, such
+	// that the data are aligned to 64 bytes
+	pre := []byte(npyMagic + "\x01\x00\x00\x00")
+	if len(pre)+len(dict)+1 > math.MaxUint16 {
+		pre = []byte(npyMagic + "\x02\x00\x00\x00\x00\x00")
+	}
+
+	pad := 63 - (len(pre)+len(dict))%64
+	dict += strings.Repeat(" ", pad) + "\n"
+
+	if len(pre) == 10 {
+		binary.LittleEndian.PutUint16(pre[8:], uint16(len(dict)))
+	} else {
+		binary.LittleEndian.PutUint32(pre[8:], uint32(len(dict)))
+	}
+
+	if n, err := w.Write(append(pre, dict...)); err == nil {
+		total += n
+	} else {
+		return total, ErrUnwritable
+	}
+
+	// elements are written in blocks of at most 64 KiB
+	block := max(1, maxScanTokenSize/size)
+	buf := make([]byte, min(n, block)*size)
+
+	for k := 0; k < n; {
+
+		b := buf[:min(n-k, block)*size]
+		for p := 0; p < len(b); p += size {
+			put(k, b[p:p+size])
+			k++
+		}
+
+		if n, err := w.Write(b); err == nil {
+			total += n
+		} else {
+			return total, ErrUnwritable
+		}
+	}
+
+	return total, nil
+}
+
+// putFloat64 encodes v into b as a little-endian float64.
+func putFloat64(b []byte, v float64) {
+	binary.LittleEndian.PutUint64(b, math.Float64bits(v))
+}
+
+// UnmarshalNPYFrom deserializes r from NumPy .npy format into the
+// receiver, as configured by opts, and returns the number of bytes read.
+// Arrays of real, integer and boolean data types are read, of which a
+// one-dimensional array is read as a column vector. Elements are read in
+// either C (row major) or Fortran (column major) order, per the header of
+// the file.
+func (m *Dense) UnmarshalNPYFrom(r io.Reader, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(o.limits.reader(r), &n)
+
+	var d *Dense
+
+	err := readNPYMatrix(r, o,
+		func(h *npyHeader, M, N int) error {
+
+			d = NewDense(o.newDense(M, N))
+			if h.isInteger() {
+				d.Field = mtxFieldInteger
+			}
+
+			return nil
+		},
+		func(i, j int, v float64) { d.mat.Set(i, j, v) },
+	)
+	if err != nil {
+		return n.total, err
+	}
+
+	*m = *d
+
+	return n.total, nil
+}
+
+// MarshalNPYTo serializes the receiver to w in NumPy .npy format, as
+// configured by opts, and returns the number of bytes written. Elements
+// are written as float64, or as int64 if the field of the receiver is
+// integer, in C (row major) order or, given WriteOrder(OrderColMajor), in
+// Fortran (column major) order.
+func (m *Dense) MarshalNPYTo(w io.Writer, opts ...WriteOption) (int, error) {
+
+	o := newWriteOptions(opts)
+
+	M, N := m.mat.Dims()
+	fortran := o.order == OrderColMajor
+
+	kind := byte('f')
+	if strings.EqualFold(m.Field, mtxFieldInteger) {
+		kind = 'i'
+	}
+
+	return writeNPY(w, kind, 8, fortran, []int{M, N}, M*N, func(k int, b []byte) {
+
+		i, j := k/N, k%N
+		if fortran {
+			i, j = k%M, k/M
+		}
+
+		if kind == 'i' {
+			binary.LittleEndian.PutUint64(b, uint64(int64(m.mat.At(i, j))))
+		} else {
+			putFloat64(b, m.mat.At(i, j))
+		}
+	})
+}
+
+// UnmarshalNPYFrom deserializes r from NumPy .npy format into the
+// receiver, as configured by opts, and returns the number of bytes read.
+// Arrays of complex data types are read, of which a one-dimensional array
+// is read as a column vector. Elements are read in either C (row major)
+// or Fortran (column major) order, per the header of the file.
+func (m *CDense) UnmarshalNPYFrom(r io.Reader, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(o.limits.reader(r), &n)
+
+	var d *CDense
+
+	err := readNPYMatrix(r, o,
+		func(h *npyHeader, M, N int) error {
+
+			if !h.isComplex() {
+				return ErrUnsupportedType
+			}
+
+			d = NewCDense(o.newCDense(M, N))
+
+			return nil
+		},
+		func(i, j int, v complex128) { d.mat.Set(i, j, v) },
+	)
+	if err != nil {
+		return n.total, err
+	}
+
+	*m = *d
+
+	return n.total, nil
+}
+
+// MarshalNPYTo serializes the receiver to w in NumPy .npy format, as
+// configured by opts, and returns the number of bytes written. Elements
+// are written as complex128, in C (row major) order or, given
+// WriteOrder(OrderColMajor), in Fortran (column major) order.
+func (m *CDense) MarshalNPYTo(w io.Writer, opts ...WriteOption) (int, error) {
+
+	o := newWriteOptions(opts)
+
+	M, N := m.mat.Dims()
+	fortran := o.order == OrderColMajor
+
+	return writeNPY(w, 'c', 16, fortran, []int{M, N}, M*N, func(k int, b []byte) {
+
+		i, j := k/N, k%N
+		if fortran {
+			i, j = k%M, k/M
+		}
+
+		v := m.mat.At(i, j)
+		putFloat64(b, real(v))
+		putFloat64(b[8:], imag(v))
+	})
+}
@@ -0,0 +1,197 @@
+package market
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// npyFile returns a .npy file of the header dict and data, as written by
+// numpy.save.
+func npyFile(dict string, data []byte) []byte {
+
+	dict += strings.Repeat(" ", 63-(10+len(dict))%64) + "\n"
+
+	b := []byte(npyMagic + "\x01\x00")
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(dict)))
+	b = append(b, dict...)
+
+	return append(b, data...)
+}
+
+func TestDenseUnmarshalNPYFrom(t *testing.T) {
+
+	want := mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6})
+
+	var f8, f8be, f8f, f4, i2, b1 []byte
+	for _, v := range []float64{1, 2, 3, 4, 5, 6} {
+		f8 = binary.LittleEndian.AppendUint64(f8, math.Float64bits(v))
+		f8be = binary.BigEndian.AppendUint64(f8be, math.Float64bits(v))
+		f4 = binary.LittleEndian.AppendUint32(f4, math.Float32bits(float32(v)))
+		i2 = binary.LittleEndian.AppendUint16(i2, uint16(v))
+		b1 = append(b1, byte(int(v)%2))
+	}
+	for _, v := range []float64{1, 4, 2, 5, 3, 6} {
+		f8f = binary.LittleEndian.AppendUint64(f8f, math.Float64bits(v))
+	}
+
+	for _, test := range []struct {
+		name  string
+		file  []byte
+		field string
+		want  *mat.Dense
+	}{
+		{"f8", npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (2, 3), }", f8), mtxFieldReal, want},
+		{"big-endian", npyFile("{'descr': '>f8', 'fortran_order': False, 'shape': (2, 3), }", f8be), mtxFieldReal, want},
+		{"fortran", npyFile("{'descr': '<f8', 'fortran_order': True, 'shape': (2, 3), }", f8f), mtxFieldReal, want},
+		{"f4", npyFile("{'descr': '<f4', 'fortran_order': False, 'shape': (2, 3), }", f4), mtxFieldReal, want},
+		{"i2", npyFile("{'descr': '<i2', 'fortran_order': False, 'shape': (2, 3), }", i2), mtxFieldInteger, want},
+		{"b1", npyFile("{'descr': '|b1', 'fortran_order': False, 'shape': (2, 3), }", b1), mtxFieldInteger, mat.NewDense(2, 3, []float64{1, 0, 1, 0, 1, 0})},
+		{"vector", npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (6,), }", f8), mtxFieldReal, mat.NewDense(6, 1, []float64{1, 2, 3, 4, 5, 6})},
+		{"scalar", npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (), }", f8[:8]), mtxFieldReal, mat.NewDense(1, 1, []float64{1})},
+	} {
+
+		var m Dense
+
+		n, err := m.UnmarshalNPYFrom(bytes.NewReader(test.file))
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+
+		assert.Equal(t, len(test.file), n, test.name)
+		assert.Equal(t, test.field, m.Field, test.name)
+		assert.Equal(t, mtxFormatArray, m.Format, test.name)
+		assert.True(t, mat.Equal(test.want, m.ToDense()), test.name)
+	}
+}
+
+func TestDenseUnmarshalNPYFromErrors(t *testing.T) {
+
+	f8 := make([]byte, 48)
+
+	for _, test := range []struct {
+		name string
+		file []byte
+		err  error
+	}{
+		{"magic", []byte("%%MatrixMarket matrix array real general\n"), ErrNotNPY},
+		{"complex", npyFile("{'descr': '<c16', 'fortran_order': False, 'shape': (2, 3), }", make([]byte, 96)), ErrUnsupportedType},
+		{"object", npyFile("{'descr': '|O', 'fortran_order': False, 'shape': (2, 3), }", f8), ErrUnsupportedType},
+		{"3-d", npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (1, 2, 3), }", f8), ErrUnsupportedType},
+		{"empty", npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (0, 3), }", nil), ErrInvalidSize},
+		{"truncated", npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (2, 3), }", f8[:40]), ErrTruncated},
+		{"hostile", npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (100000000, 100000000), }", f8), ErrTruncated},
+	} {
+		var m Dense
+
+		_, err := m.UnmarshalNPYFrom(bytes.NewReader(test.file))
+		assert.ErrorIs(t, err, test.err, test.name)
+	}
+
+	var m Dense
+
+	file := npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (2, 3), }", f8)
+	_, err := m.UnmarshalNPYFrom(bytes.NewReader(file), WithLimits(Limits{MaxCols: 2}))
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+}
+
+func TestDenseMarshalNPYTo(t *testing.T) {
+
+	d := mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6})
+
+	var b bytes.Buffer
+
+	n, err := NewDense(d).MarshalNPYTo(&b)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the header is as written by numpy.save
+	assert.Equal(t, 128+48, n)
+	assert.Equal(t, npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (2, 3), }", nil), b.Bytes()[:128])
+
+	for _, opts := range [][]WriteOption{nil, {WriteOrder(OrderColMajor)}} {
+
+		b.Reset()
+
+		_, err := NewDense(d).MarshalNPYTo(&b, opts...)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		var m Dense
+
+		_, err = m.UnmarshalNPYFrom(&b)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		assert.True(t, mat.Equal(d, m.ToDense()))
+	}
+
+	// integer matrices are written as int64
+	b.Reset()
+
+	m := NewDense(d)
+	m.Field = mtxFieldInteger
+
+	_, err = m.MarshalNPYTo(&b)
+	if assert.NoError(t, err) {
+		assert.Contains(t, b.String(), "'descr': '<i8'")
+	}
+
+	_, err = NewDense(d).MarshalNPYTo(failWriter{})
+	assert.ErrorIs(t, err, ErrUnwritable)
+}
+
+func TestCDenseNPY(t *testing.T) {
+
+	d := mat.NewCDense(2, 2, []complex128{1 + 2i, -3i, 4, 5 - 1i})
+
+	for _, opts := range [][]WriteOption{nil, {WriteOrder(OrderColMajor)}} {
+
+		var b bytes.Buffer
+
+		_, err := NewCDense(d).MarshalNPYTo(&b, opts...)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		assert.Contains(t, b.String(), "'descr': '<c16'")
+
+		var m CDense
+
+		_, err = m.UnmarshalNPYFrom(&b)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		assert.True(t, mat.CEqual(d, m.ToCDense()))
+	}
+
+	// complex64 elements are widened
+	var c8 []byte
+	for _, v := range []float32{1, 2, 0, -3, 4, 0, 5, -1} {
+		c8 = binary.LittleEndian.AppendUint32(c8, math.Float32bits(v))
+	}
+
+	var m CDense
+
+	_, err := m.UnmarshalNPYFrom(bytes.NewReader(npyFile("{'descr': '<c8', 'fortran_order': False, 'shape': (2, 2), }", c8)))
+	if assert.NoError(t, err) {
+		assert.True(t, mat.CEqual(d, m.ToCDense()))
+	}
+
+	// real arrays are not read into complex storage
+	_, err = m.UnmarshalNPYFrom(bytes.NewReader(npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (1,), }", make([]byte, 8))))
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	// a header alone does not allocate dense storage
+	_, err = m.UnmarshalNPYFrom(bytes.NewReader(npyFile("{'descr': '<c16', 'fortran_order': False, 'shape': (100000000, 100000000), }", c8)))
+	assert.ErrorIs(t, err, ErrTruncated)
+}
@@ -0,0 +1,444 @@
+package market
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/james-bowman/sparse"
+)
+
+// npzArchive is a .npz archive of arrays in NumPy .npy format, as
+// written by scipy.sparse.save_npz.
+type npzArchive map[string]*zip.File
+
+// open opens the array of name, and reads its header.
+func (z npzArchive) open(name string) (io.ReadCloser, *npyHeader, error) {
+
+	f, ok := z[name+".npy"]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: missing %s.npy in .npz archive", ErrInputScanError, name)
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s.npy: %v", ErrInputScanError, name, err)
+	}
+
+	h, err := readNPYHeader(r)
+	if err != nil {
+		r.Close()
+		return nil, nil, err
+	}
+
+	return r, h, nil
+}
+
+// vector opens the one-dimensional array of name, of n elements.
+func (z npzArchive) vector(name string, n int) (io.ReadCloser, *npyHeader, error) {
+
+	r, h, err := z.open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(h.shape) != 1 || h.shape[0] != n {
+		r.Close()
+		return nil, nil, fmt.Errorf("%w: %s.npy has shape %v, not (%d,)", ErrInvalidSize, name, h.shape, n)
+	}
+
+	return r, h, nil
+}
+
+// maxNPZFormat is the maximum length of the string of the format array.
+const maxNPZFormat = 16
+
+// format returns the string held by the format array.
+func (z npzArchive) format() (string, error) {
+
+	r, h, err := z.open("format")
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	// formats are of a few characters, such as "csr", such that a longer
+	// string is not read
+	if h.kind != 'S' || len(h.shape) != 0 || h.size > maxNPZFormat {
+		return "", ErrUnsupportedType
+	}
+
+	b := make([]byte, h.size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", fmt.Errorf("%w: format.npy is incomplete", ErrInputScanError)
+	}
+
+	return string(bytes.TrimRight(b, "\x00")), nil
+}
+
+// ints returns the n integers of the array of name, each of which must be
+// less than bound.
+func (z npzArchive) ints(name string, n, bound int) ([]int, error) {
+
+	r, h, err := z.vector(name, n)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	if h.kind != 'i' && h.kind != 'u' {
+		return nil, ErrUnsupportedType
+	}
+
+	// indices are accumulated as read, such that storage is bounded by
+	// the input rather than by the shape of the header
+	v := make([]int, 0, prealloc(n))
+	err = readBlocks(r, n, h.size, func(k int, b []byte) error {
+
+		x := h.int(b)
+		if x < 0 || x >= int64(bound) {
+			return fmt.Errorf("%w: %s[%d] = %d", ErrIndexOutOfRange, name, k, x)
+		}
+		v = append(v, int(x))
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// UnmarshalNPZFrom deserializes r from SciPy .npz format, as written by
+// scipy.sparse.save_npz, into the receiver, as configured by opts, and
+// returns the number of bytes read. Matrices in CSR, CSC and COO formats
+// of real, integer and boolean data types are read. As the archive is
+// read whole prior to decompression, WithLimits should be used to bound
+// the size of untrusted input.
+func (m *COO) UnmarshalNPZFrom(r io.Reader, opts ...ReadOption) (int, error) {
+
+	o := newReadOptions(opts)
+
+	b, err := io.ReadAll(o.limits.reader(r))
+	if err != nil {
+		return len(b), err
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return len(b), fmt.Errorf("%w: %v", ErrNotNPY, err)
+	}
+
+	z := make(npzArchive, len(zr.File))
+	for _, f := range zr.File {
+		z[f.Name] = f
+	}
+
+	c, t, err := z.triplets(o)
+	if err != nil {
+		return len(b), err
+	}
+
+	d := sparse.NewCOO(t.M, t.N, c.rows, c.cols, c.data)
+	if o.coo != nil {
+		*o.coo = *d
+		d = o.coo
+	}
+
+	// apply header fields
+	m.Object = mtxObjectMatrix
+	m.Format = mtxFormatCoordinate
+	m.Field = t.field
+	m.Symmetry = mtxSymmetryGeneral
+	m.mat = d
+
+	return len(b), nil
+}
+
+// npzMatrix describes the matrix of a .npz archive, as transformed.
+type npzMatrix struct {
+	M, N  int
+	field string
+}
+
+// triplets reads the entries of the matrix of the archive.
+func (z npzArchive) triplets(o *readOptions) (*triplets[int, float64], *npzMatrix, error) {
+
+	format, err := z.format()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	shape, err := z.ints("shape", 2, math.MaxInt)
+	if err != nil {
+		return nil, nil, err
+	}
+	M, N := shape[0], shape[1]
+
+	if _, err := elements(M, N); err != nil {
+		return nil, nil, err
+	}
+
+	rd, h, err := z.open("data")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rd.Close()
+
+	if len(h.shape) != 1 {
+		return nil, nil, fmt.Errorf("%w: data.npy has shape %v", ErrInvalidSize, h.shape)
+	}
+
+	if h.kind == 'S' || h.isComplex() {
+		return nil, nil, ErrUnsupportedType
+	}
+
+	L := h.shape[0]
+
+	if err := o.limits.checkSize(M, N, L); err != nil {
+		return nil, nil, err
+	}
+
+	if err := o.xform.check(M, N); err != nil {
+		return nil, nil, err
+	}
+
+	// rows and columns of each entry, of which those of compressed formats
+	// are expanded from the index pointers
+	var rows, cols []int
+
+	switch format {
+
+	case "coo":
+		if rows, err = z.ints("row", L, M); err != nil {
+			return nil, nil, err
+		}
+		if cols, err = z.ints("col", L, N); err != nil {
+			return nil, nil, err
+		}
+
+	case "csr":
+		if cols, err = z.ints("indices", L, N); err != nil {
+			return nil, nil, err
+		}
+		if rows, err = z.expand(M, L); err != nil {
+			return nil, nil, err
+		}
+
+	case "csc":
+		if rows, err = z.ints("indices", L, M); err != nil {
+			return nil, nil, err
+		}
+		if cols, err = z.expand(N, L); err != nil {
+			return nil, nil, err
+		}
+
+	default:
+		return nil, nil, fmt.Errorf("%w: %q sparse format", ErrUnsupportedType, format)
+	}
+
+	c := newTriplets[int, float64](L, o.duplicates, false)
+	c.x = o.xform
+
+	err = readBlocks(rd, L, h.size, func(k int, b []byte) error {
+
+		v := real(h.value(b))
+		if v == 0 && o.zeros == ZeroDrop {
+			return nil
+		}
+
+		_, err := c.add(rows[k], cols[k], v)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := npzMatrix{field: mtxFieldReal}
+	t.M, t.N = o.xform.dims(M, N)
+	if h.isInteger() {
+		t.field = mtxFieldInteger
+	}
+
+	return c, &t, nil
+}
+
+// expand returns the major index of each of the L entries of a matrix in
+// a compressed format having n rows (CSR) or columns (CSC), per the index
+// pointers of the archive.
+func (z npzArchive) expand(n, L int) ([]int, error) {
+
+	ptr, err := z.ints("indptr", n+1, L+1)
+	if err != nil {
+		return nil, err
+	}
+
+	if ptr[0] != 0 || ptr[n] != L {
+		return nil, fmt.Errorf("%w: index pointers do not span %d entries", ErrInputScanError, L)
+	}
+
+	idx := make([]int, 0, prealloc(L))
+	for k := 0; k < n; k++ {
+
+		if ptr[k] > ptr[k+1] {
+			return nil, fmt.Errorf("%w: index pointers are decreasing", ErrInputScanError)
+		}
+
+		for p := ptr[k]; p < ptr[k+1]; p++ {
+			idx = append(idx, k)
+		}
+	}
+
+	return idx, nil
+}
+
+// MarshalNPZTo serializes the receiver to w in SciPy .npz format, as read
+// by scipy.sparse.load_npz, as configured by opts, and returns the number
+// of bytes written. The matrix is written in COO format or, given
+// WriteOrder(OrderRowMajor), in CSR format or, given
+// WriteOrder(OrderColMajor), in CSC format. Values are written as
+// float64, or as int64 if the field of the receiver is integer.
+func (m *COO) MarshalNPZTo(w io.Writer, opts ...WriteOption) (int, error) {
+
+	var n counter
+
+	o := newWriteOptions(opts)
+
+	c := newTriplets[int, float64](m.mat.NNZ(), o.duplicates, false)
+
+	var err error
+	m.Do(func(i, j int, v float64) {
+		if err == nil {
+			_, err = c.add(i, j, v)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if o.order != OrderUnsorted {
+		c.sort(o.order)
+	}
+
+	if o.zeros == ZeroDrop {
+		c.dropZeros()
+	}
+
+	M, N := m.mat.Dims()
+	L := len(c.data)
+
+	// indices are written as int32 where they fit, as by scipy
+	size := 8
+	if max(max(M, N), L) <= math.MaxInt32 {
+		size = 4
+	}
+
+	putInt := func(b []byte, v int) {
+		if size == 4 {
+			binary.LittleEndian.PutUint32(b, uint32(v))
+		} else {
+			binary.LittleEndian.PutUint64(b, uint64(v))
+		}
+	}
+
+	// index pointers of the n rows (CSR) or columns (CSC) of idx
+	indptr := func(n int, idx []int) func(k int, b []byte) {
+
+		ptr := make([]int, n+1)
+		for _, i := range idx {
+			ptr[i+1]++
+		}
+		for k := 0; k < n; k++ {
+			ptr[k+1] += ptr[k]
+		}
+
+		return func(k int, b []byte) { putInt(b, ptr[k]) }
+	}
+
+	index := func(idx []int) func(k int, b []byte) {
+		return func(k int, b []byte) { putInt(b, idx[k]) }
+	}
+
+	type array struct {
+		name  string
+		kind  byte
+		size  int
+		shape []int
+		put   func(k int, b []byte)
+	}
+
+	var (
+		arrays []array
+		format string
+	)
+
+	switch o.order {
+
+	case OrderRowMajor:
+		format = "csr"
+		arrays = []array{
+			{"indices", 'i', size, []int{L}, index(c.cols)},
+			{"indptr", 'i', size, []int{M + 1}, indptr(M, c.rows)},
+		}
+
+	case OrderColMajor:
+		format = "csc"
+		arrays = []array{
+			{"indices", 'i', size, []int{L}, index(c.rows)},
+			{"indptr", 'i', size, []int{N + 1}, indptr(N, c.cols)},
+		}
+
+	default:
+		format = "coo"
+		arrays = []array{
+			{"row", 'i', size, []int{L}, index(c.rows)},
+			{"col", 'i', size, []int{L}, index(c.cols)},
+		}
+	}
+
+	data := array{"data", 'f', 8, []int{L}, func(k int, b []byte) { putFloat64(b, c.data[k]) }}
+	if strings.EqualFold(m.Field, mtxFieldInteger) {
+		data = array{"data", 'i', 8, []int{L}, func(k int, b []byte) {
+			binary.LittleEndian.PutUint64(b, uint64(int64(c.data[k])))
+		}}
+	}
+
+	arrays = append(arrays,
+		array{"format", 'S', len(format), nil, func(k int, b []byte) { copy(b, format) }},
+		array{"shape", 'i', 8, []int{2}, func(k int, b []byte) {
+			binary.LittleEndian.PutUint64(b, uint64([]int{M, N}[k]))
+		}},
+		data,
+	)
+
+	zw := zip.NewWriter(io.MultiWriter(w, &n))
+
+	for _, a := range arrays {
+
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: a.name + ".npy", Method: zip.Deflate})
+		if err != nil {
+			return n.total, ErrUnwritable
+		}
+
+		// the number of elements is the product of the shape
+		L := 1
+		for _, d := range a.shape {
+			L *= d
+		}
+
+		if _, err := writeNPY(f, a.kind, a.size, false, a.shape, L, a.put); err != nil {
+			return n.total, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return n.total, ErrUnwritable
+	}
+
+	return n.total, nil
+}
@@ -0,0 +1,242 @@
+package market
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// npzFile returns a .npz archive of the named .npy files.
+func npzFile(files map[string][]byte) []byte {
+
+	var b bytes.Buffer
+
+	zw := zip.NewWriter(&b)
+	for name, data := range files {
+		f, _ := zw.Create(name + ".npy")
+		f.Write(data)
+	}
+	zw.Close()
+
+	return b.Bytes()
+}
+
+// npyInts returns a .npy file of a vector of int32.
+func npyInts(v ...int) []byte {
+
+	var b []byte
+	for _, x := range v {
+		b = binary.LittleEndian.AppendUint32(b, uint32(x))
+	}
+
+	return npyFile("{'descr': '<i4', 'fortran_order': False, 'shape': ("+strconv.Itoa(len(v))+",), }", b)
+}
+
+// npyFloats returns a .npy file of a vector of float64.
+func npyFloats(v ...float64) []byte {
+
+	var b []byte
+	for _, x := range v {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(x))
+	}
+
+	return npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': ("+strconv.Itoa(len(v))+",), }", b)
+}
+
+// npyShape returns the shape.npy file of an M×N matrix.
+func npyShape(M, N int) []byte {
+
+	var b []byte
+	b = binary.LittleEndian.AppendUint64(b, uint64(M))
+	b = binary.LittleEndian.AppendUint64(b, uint64(N))
+
+	return npyFile("{'descr': '<i8', 'fortran_order': False, 'shape': (2,), }", b)
+}
+
+// npyFormat returns the format.npy file of a sparse format.
+func npyFormat(format string) []byte {
+	return npyFile("{'descr': '|S3', 'fortran_order': False, 'shape': (), }", []byte(format))
+}
+
+func TestCOOUnmarshalNPZFrom(t *testing.T) {
+
+	// the 2×3 matrix [1 0 2; 0 3 0]
+	want := mat.NewDense(2, 3, []float64{1, 0, 2, 0, 3, 0})
+
+	for _, test := range []struct {
+		name  string
+		files map[string][]byte
+	}{
+		{"csr", map[string][]byte{
+			"format":  npyFormat("csr"),
+			"shape":   npyShape(2, 3),
+			"indptr":  npyInts(0, 2, 3),
+			"indices": npyInts(0, 2, 1),
+			"data":    npyFloats(1, 2, 3),
+		}},
+		{"csc", map[string][]byte{
+			"format":  npyFormat("csc"),
+			"shape":   npyShape(2, 3),
+			"indptr":  npyInts(0, 1, 2, 3),
+			"indices": npyInts(0, 1, 0),
+			"data":    npyFloats(1, 3, 2),
+		}},
+		{"coo", map[string][]byte{
+			"format": npyFormat("coo"),
+			"shape":  npyShape(2, 3),
+			"row":    npyInts(1, 0, 0),
+			"col":    npyInts(1, 2, 0),
+			"data":   npyFloats(3, 2, 1),
+		}},
+	} {
+
+		var m COO
+
+		file := npzFile(test.files)
+
+		n, err := m.UnmarshalNPZFrom(bytes.NewReader(file))
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+
+		assert.Equal(t, len(file), n, test.name)
+		assert.Equal(t, mtxFieldReal, m.Field, test.name)
+		assert.True(t, mat.Equal(want, m.ToCOO()), test.name)
+	}
+}
+
+func TestCOOUnmarshalNPZFromErrors(t *testing.T) {
+
+	csr := func(key string, v []byte) []byte {
+
+		files := map[string][]byte{
+			"format":  npyFormat("csr"),
+			"shape":   npyShape(2, 3),
+			"indptr":  npyInts(0, 2, 3),
+			"indices": npyInts(0, 2, 1),
+			"data":    npyFloats(1, 2, 3),
+		}
+
+		if v == nil {
+			delete(files, key)
+		} else {
+			files[key] = v
+		}
+
+		return npzFile(files)
+	}
+
+	for _, test := range []struct {
+		name string
+		file []byte
+		err  error
+	}{
+		{"zip", []byte("not a zip archive"), ErrNotNPY},
+		{"missing", csr("indptr", nil), ErrInputScanError},
+		{"format", csr("format", npyFormat("dia")), ErrUnsupportedType},
+		{"long", csr("format", npyFile("{'descr': '|S400000000', 'fortran_order': False, 'shape': (), }", []byte("csr"))), ErrUnsupportedType},
+		{"short", csr("format", npyFile("{'descr': '|S3', 'fortran_order': False, 'shape': (), }", []byte("cs"))), ErrInputScanError},
+		{"index", csr("indices", npyInts(0, 3, 1)), ErrIndexOutOfRange},
+		{"indptr", csr("indptr", npyInts(0, 2, 2)), ErrInputScanError},
+		{"decreasing", csr("indptr", npyInts(0, 3, 2)), ErrInputScanError},
+		{"length", csr("indices", npyInts(0, 2)), ErrInvalidSize},
+		{"truncated", csr("data", npyFloats(1, 2, 3)[:140]), ErrTruncated},
+		{"hostile", npzFile(map[string][]byte{
+			"format":  npyFormat("csr"),
+			"shape":   npyShape(1<<30, 1<<30),
+			"indptr":  npyFile("{'descr': '<i4', 'fortran_order': False, 'shape': (1099511627777,), }", nil),
+			"indices": npyFile("{'descr': '<i4', 'fortran_order': False, 'shape': (1099511627776,), }", nil),
+			"data":    npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (1099511627776,), }", nil),
+		}), ErrTruncated},
+	} {
+		var m COO
+
+		_, err := m.UnmarshalNPZFrom(bytes.NewReader(test.file))
+		assert.ErrorIs(t, err, test.err, test.name)
+	}
+
+	var m COO
+
+	_, err := m.UnmarshalNPZFrom(bytes.NewReader(csr("format", npyFormat("csr"))), WithLimits(Limits{MaxEntries: 2}))
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+}
+
+func TestCOOMarshalNPZTo(t *testing.T) {
+
+	for _, test := range []struct {
+		order  Order
+		format string
+	}{
+		{OrderUnsorted, "coo"},
+		{OrderRowMajor, "csr"},
+		{OrderColMajor, "csc"},
+	} {
+
+		var b bytes.Buffer
+
+		n, err := NewCOO(mtx01).MarshalNPZTo(&b, WriteOrder(test.order))
+		if !assert.NoError(t, err, test.format) {
+			continue
+		}
+
+		assert.Equal(t, b.Len(), n, test.format)
+
+		zr, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+		if !assert.NoError(t, err, test.format) {
+			continue
+		}
+
+		z := make(npzArchive)
+		for _, f := range zr.File {
+			z[f.Name] = f
+		}
+
+		format, err := z.format()
+		assert.NoError(t, err, test.format)
+		assert.Equal(t, test.format, format)
+
+		var m COO
+
+		_, err = m.UnmarshalNPZFrom(&b)
+		if !assert.NoError(t, err, test.format) {
+			continue
+		}
+
+		assert.True(t, mat.Equal(mtx01, m.ToCOO()), test.format)
+	}
+}
+
+func TestCOOMarshalNPZToInteger(t *testing.T) {
+
+	var b bytes.Buffer
+
+	c := sparse.NewCOO(2, 2, []int{0, 1, 1}, []int{0, 1, 1}, []float64{-4, 2, 3})
+	m := &COO{mtxObjectMatrix, mtxFormatCoordinate, mtxFieldInteger, mtxSymmetryGeneral, c}
+
+	_, err := m.MarshalNPZTo(&b, WriteOrder(OrderRowMajor), WriteZeros(ZeroDrop))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var got COO
+
+	_, err = got.UnmarshalNPZFrom(&b)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// duplicates are merged in compressed formats
+	assert.Equal(t, mtxFieldInteger, got.Field)
+	assert.Equal(t, 2, got.ToCOO().NNZ())
+	assert.True(t, mat.Equal(mat.NewDense(2, 2, []float64{-4, 0, 0, 5}), got.ToCOO()))
+
+	_, err = m.MarshalNPZTo(failWriter{})
+	assert.ErrorIs(t, err, ErrUnwritable)
+}
@@ -0,0 +1,639 @@
+package market
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/james-bowman/sparse"
+)
+
+// octaveCreatedBy is the comment line written at the head of an Octave
+// text file.
+const octaveCreatedBy = "# Created by github.com/wamuir/matrix-market"
+
+// types of variables of an Octave text file which are read and written
+const (
+	octaveScalar        = "scalar"
+	octaveComplexScalar = "complex scalar"
+	octaveMatrix        = "matrix"
+	octaveComplexMatrix = "complex matrix"
+	octaveBoolMatrix    = "bool matrix"
+	octaveSparse        = "sparse matrix"
+	octaveSparseComplex = "sparse complex matrix"
+	octaveSparseBool    = "sparse bool matrix"
+)
+
+// octaveVar is the header of a variable of an Octave text file.
+type octaveVar struct {
+	name    string
+	typ     string
+	rows    int
+	columns int
+	nnz     int // number of nonzeros of a sparse matrix
+}
+
+// isSparse reports whether the variable is a sparse matrix.
+func (v *octaveVar) isSparse() bool {
+	return v.typ == octaveSparse || v.typ == octaveSparseComplex || v.typ == octaveSparseBool
+}
+
+// isComplex reports whether the variable is complex.
+func (v *octaveVar) isComplex() bool {
+	return v.typ == octaveComplexScalar || v.typ == octaveComplexMatrix || v.typ == octaveSparseComplex
+}
+
+// isBool reports whether the variable is logical.
+func (v *octaveVar) isBool() bool {
+	return v.typ == octaveBoolMatrix || v.typ == octaveSparseBool
+}
+
+// isSupported reports whether the variable is of a type which is read.
+func (v *octaveVar) isSupported() bool {
+	switch v.typ {
+	case octaveScalar, octaveComplexScalar, octaveMatrix, octaveComplexMatrix, octaveBoolMatrix:
+		return true
+	}
+	return v.isSparse()
+}
+
+// isComplete reports whether the header of the variable has been read in
+// full, such that its data follow.
+func (v *octaveVar) isComplete() bool {
+
+	switch {
+
+	case !v.isSupported():
+		return false
+
+	case v.isSparse():
+		return v.rows >= 0 && v.columns >= 0 && v.nnz >= 0
+
+	default:
+		return v.rows >= 0 && v.columns >= 0
+	}
+}
+
+// check checks the size of the variable against the limits and the
+// transform of o.
+func (v *octaveVar) check(o *readOptions) error {
+
+	L, err := elements(v.rows, v.columns)
+	if err != nil {
+		return err
+	}
+
+	if v.isSparse() {
+		L = v.nnz
+	}
+
+	if err := o.limits.checkSize(v.rows, v.columns, L); err != nil {
+		return err
+	}
+
+	return o.xform.check(v.rows, v.columns)
+}
+
+// octaveKeyword returns the keyword and value of a header line of an
+// Octave text file, of the form "# keyword: value".
+func octaveKeyword(line string) (string, string, bool) {
+
+	line, ok := strings.CutPrefix(strings.TrimSpace(line), "#")
+	if !ok {
+		return "", "", false
+	}
+
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", "", false
+	}
+
+	return strings.TrimSpace(key), strings.TrimSpace(value), true
+}
+
+// findOctave reads the Octave text file of scanner up to the data of the
+// variable named name or, if name is empty, of the first variable for
+// which ok reports true, and returns its header. Variables of other
+// types, such as strings or cell arrays, are skipped.
+func findOctave(scanner *lineScanner, name string, ok func(v *octaveVar) bool) (*octaveVar, error) {
+
+	var v *octaveVar
+
+	for scanner.Scan() {
+
+		key, value, header := octaveKeyword(scanner.Text())
+
+		// the elements of a cell array are named <cell-element>, and are
+		// skipped along with the cell array
+		if header && key == "name" {
+			v = nil
+			if !strings.HasPrefix(value, "<") {
+				v = &octaveVar{name: value, rows: -1, columns: -1, nnz: -1}
+			}
+			continue
+		}
+
+		// comments prior to the first variable, and the data of skipped
+		// variables
+		if v == nil {
+			continue
+		}
+
+		if header {
+
+			switch key {
+
+			case "type":
+				v.typ = value
+				if v.typ == octaveScalar || v.typ == octaveComplexScalar {
+					v.rows, v.columns = 1, 1
+				}
+
+			case "rows", "columns", "nnz":
+				n, err := parseInt(value)
+				if err != nil {
+					return nil, scanner.errorf(scanner.line, err)
+				}
+				if n < 0 {
+					return nil, scanner.errorf(scanner.line, ErrInvalidSize)
+				}
+
+				switch key {
+				case "rows":
+					v.rows = n
+				case "columns":
+					v.columns = n
+				case "nnz":
+					v.nnz = n
+				}
+			}
+		}
+
+		// the header of a variable ends where its data begin
+		if header && !v.isComplete() {
+			continue
+		}
+
+		switch {
+
+		case name != "" && v.name == name:
+			if !v.isComplete() || !ok(v) {
+				return nil, fmt.Errorf("%w: %q is of type %q", ErrUnsupportedType, name, v.typ)
+			}
+			return v, nil
+
+		case name == "" && v.isComplete() && ok(v):
+			return v, nil
+		}
+
+		v = nil
+	}
+
+	if err := scanError(scanner.Scanner); err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		return nil, ErrNoVariable
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrNoVariable, name)
+}
+
+// parseOctaveFloat parses a real value, or either part of a complex
+// value, of an Octave text file, in which missing values are written NA.
+func parseOctaveFloat(tok string) (float64, error) {
+
+	if tok == "NA" {
+		return math.NaN(), nil
+	}
+
+	return parseFloat(tok)
+}
+
+// parseOctaveValue parses a value of an Octave text file, of which a
+// complex value is written as (re,im).
+func parseOctaveValue[T float64 | complex128](tok string) (T, error) {
+
+	var v T
+
+	if !isComplex[T]() {
+		x, err := parseOctaveFloat(tok)
+		return narrow[T](complex(x, 0)), err
+	}
+
+	re, im, ok := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(tok, "("), ")"), ",")
+	if !ok || tok[0] != '(' || tok[len(tok)-1] != ')' {
+		return v, fmt.Errorf("%w: invalid complex number %q", ErrInputScanError, tok)
+	}
+
+	x, err := parseOctaveFloat(re)
+	if err != nil {
+		return v, err
+	}
+
+	y, err := parseOctaveFloat(im)
+	if err != nil {
+		return v, err
+	}
+
+	return narrow[T](complex(x, y)), nil
+}
+
+// scanOctaveData scans the data of the variable v, calling fn with each
+// (zero-indexed) entry of a sparse matrix, or with each element of a
+// dense matrix in row major order.
+func scanOctaveData[T float64 | complex128](scanner *lineScanner, v *octaveVar, fn func(i, j int, x T) error) error {
+
+	if !v.isSparse() {
+
+		for k := 0; k < v.rows*v.columns; k++ {
+
+			toks, line, err := scanner.entry(1)
+			if err != nil {
+				return err
+			}
+
+			x, err := parseOctaveValue[T](toks[0])
+			if err != nil {
+				return scanner.errorf(line, err)
+			}
+
+			if err := fn(k/v.columns, k%v.columns, x); err != nil {
+				return scanner.errorf(line, err)
+			}
+		}
+
+		return nil
+	}
+
+	for k := 0; k < v.nnz; k++ {
+
+		toks, line, err := scanner.entry(3)
+		if err != nil {
+			return err
+		}
+
+		i, err := parseInt(toks[0])
+		if err != nil {
+			return scanner.errorf(line, err)
+		}
+
+		j, err := parseInt(toks[1])
+		if err != nil {
+			return scanner.errorf(line, err)
+		}
+
+		if i < 1 || i > v.rows || j < 1 || j > v.columns {
+			return scanner.errorf(line, ErrIndexOutOfRange)
+		}
+
+		x, err := parseOctaveValue[T](toks[2])
+		if err != nil {
+			return scanner.errorf(line, err)
+		}
+
+		if err := fn(i-1, j-1, x); err != nil {
+			return scanner.errorf(line, err)
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalOctaveFrom deserializes a sparse matrix from Octave text
+// format, as written by save -text, into the receiver, as configured by
+// opts, and returns the number of bytes read. The variable named name is
+// read or, if name is empty, the first real or logical sparse matrix of
+// the file. A logical sparse matrix is read as of the pattern field.
+func (m *COO) UnmarshalOctaveFrom(r io.Reader, name string, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	scanner := newScanner(r, o)
+
+	v, err := findOctave(scanner, name, func(v *octaveVar) bool {
+		return v.isSparse() && !v.isComplex()
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	if err := v.check(o); err != nil {
+		return n.total, err
+	}
+
+	c := newTriplets[int, float64](v.nnz, o.duplicates, false)
+	c.x = o.xform
+	if v.isBool() {
+		c.x = o.xform.indices()
+	}
+
+	err = scanOctaveData(scanner, v, func(i, j int, x float64) error {
+
+		if x == 0 && o.zeros == ZeroDrop {
+			return nil
+		}
+
+		_, err := c.add(i, j, x)
+		return err
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	Mx, Nx := o.xform.dims(v.rows, v.columns)
+	d := sparse.NewCOO(Mx, Nx, c.rows, c.cols, c.data)
+	if o.coo != nil {
+		*o.coo = *d
+		d = o.coo
+	}
+
+	// apply header fields
+	m.Object = mtxObjectMatrix
+	m.Format = mtxFormatCoordinate
+	m.Field = mtxFieldReal
+	if v.isBool() {
+		m.Field = mtxFieldPattern
+	}
+	m.Symmetry = mtxSymmetryGeneral
+	m.mat = d
+
+	return n.total, nil
+}
+
+// UnmarshalOctaveFrom deserializes a matrix from Octave text format, as
+// written by save -text, into the receiver, as configured by opts, and
+// returns the number of bytes read. The variable named name is read or,
+// if name is empty, the first real or logical matrix or scalar of the
+// file. A logical matrix is read as of the integer field.
+func (m *Dense) UnmarshalOctaveFrom(r io.Reader, name string, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	scanner := newScanner(r, o)
+
+	v, err := findOctave(scanner, name, func(v *octaveVar) bool {
+		return !v.isSparse() && !v.isComplex()
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	if err := v.check(o); err != nil {
+		return n.total, err
+	}
+
+	// dense storage cannot be allocated for an empty matrix
+	Mx, Nx := o.xform.dims(v.rows, v.columns)
+	if Mx == 0 || Nx == 0 {
+		return n.total, ErrInvalidSize
+	}
+
+	d := o.newDense(Mx, Nx)
+
+	err = scanOctaveData(scanner, v, func(i, j int, x float64) error {
+		if i, j, x, ok := apply(o.xform, i, j, x); ok {
+			d.Set(i, j, x)
+		}
+		return nil
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	*m = *NewDense(d)
+	if v.isBool() {
+		m.Field = mtxFieldInteger
+	}
+
+	return n.total, nil
+}
+
+// UnmarshalOctaveFrom deserializes a complex matrix from Octave text
+// format, as written by save -text, into the receiver, as configured by
+// opts, and returns the number of bytes read. The variable named name is
+// read or, if name is empty, the first complex matrix or scalar of the
+// file, whether dense or sparse.
+func (m *CDense) UnmarshalOctaveFrom(r io.Reader, name string, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	scanner := newScanner(r, o)
+
+	v, err := findOctave(scanner, name, func(v *octaveVar) bool {
+		return v.isComplex()
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	if err := v.check(o); err != nil {
+		return n.total, err
+	}
+
+	// dense storage cannot be allocated for an empty matrix
+	Mx, Nx := o.xform.dims(v.rows, v.columns)
+	if Mx == 0 || Nx == 0 {
+		return n.total, ErrInvalidSize
+	}
+
+	c := newCDenseEntries(Mx, Nx, o.duplicates, false)
+	c.x = o.xform
+
+	err = scanOctaveData(scanner, v, func(i, j int, x complex128) error {
+		_, err := c.add(i, j, x)
+		return err
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	d, err := c.dense(o)
+	if err != nil {
+		return n.total, err
+	}
+
+	*m = *NewCDense(d)
+
+	return n.total, nil
+}
+
+// appendOctaveFloat appends v to dst in the compact number format, with
+// infinities and NaN as written by Octave, and without an exponent if
+// integer is true.
+func appendOctaveFloat(dst []byte, v float64, integer bool) []byte {
+
+	switch {
+
+	case math.IsNaN(v):
+		return append(dst, "NaN"...)
+
+	case math.IsInf(v, 1):
+		return append(dst, "Inf"...)
+
+	case math.IsInf(v, -1):
+		return append(dst, "-Inf"...)
+	}
+
+	return appendCompact(dst, v, integer, 64)
+}
+
+// appendOctaveComplex appends v to dst as (re,im).
+func appendOctaveComplex(dst []byte, v complex128) []byte {
+
+	dst = append(dst, '(')
+	dst = appendOctaveFloat(dst, real(v), false)
+	dst = append(dst, ',')
+	dst = appendOctaveFloat(dst, imag(v), false)
+
+	return append(dst, ')')
+}
+
+// writeOctave writes a variable of type typ to w, of which the header
+// lines are followed by the data lines written by data, and returns the
+// number of bytes written. For a sparse matrix, nnz is the number of
+// nonzeros.
+func writeOctave(w io.Writer, name, typ string, M, N, nnz int, data func(bw *bufio.Writer)) (int, error) {
+
+	var n counter
+
+	if !matNameRE.MatchString(name) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+
+	bw := bufio.NewWriterSize(io.MultiWriter(w, &n), maxScanTokenSize)
+
+	fmt.Fprintf(bw, "%s\n# name: %s\n# type: %s\n", octaveCreatedBy, name, typ)
+	if nnz >= 0 {
+		fmt.Fprintf(bw, "# nnz: %d\n", nnz)
+	}
+	fmt.Fprintf(bw, "# rows: %d\n# columns: %d\n", M, N)
+
+	data(bw)
+
+	// variables are separated by blank lines
+	bw.WriteString("\n\n")
+
+	if err := bw.Flush(); err != nil {
+		return n.total, ErrUnwritable
+	}
+
+	return n.total, nil
+}
+
+// MarshalOctaveTo serializes the receiver to w as a sparse matrix named
+// name in Octave text format, as read by load, as configured by opts, and
+// returns the number of bytes written. Entries are written in column
+// major order, with duplicates merged, and a matrix of the pattern field
+// is written as a logical sparse matrix.
+func (m *COO) MarshalOctaveTo(w io.Writer, name string, opts ...WriteOption) (int, error) {
+
+	o := newWriteOptions(opts)
+
+	c := newTriplets[int, float64](m.mat.NNZ(), o.duplicates, false)
+
+	var err error
+	m.Do(func(i, j int, v float64) {
+		if err == nil {
+			_, err = c.add(i, j, v)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	c.sort(OrderColMajor)
+
+	if o.zeros == ZeroDrop {
+		c.dropZeros()
+	}
+
+	typ := octaveSparse
+	if m.Field == mtxFieldPattern {
+		typ = octaveSparseBool
+	}
+
+	integer := m.Field == mtxFieldInteger || m.Field == mtxFieldPattern
+
+	M, N := m.mat.Dims()
+
+	return writeOctave(w, name, typ, M, N, len(c.data), func(bw *bufio.Writer) {
+
+		var b []byte
+
+		for k, v := range c.data {
+			b = appendCompactIndex(b[:0], c.rows[k], c.cols[k])
+			b = append(b, ' ')
+			b = appendOctaveFloat(b, v, integer)
+			b = append(b, '\n')
+			bw.Write(b)
+		}
+	})
+}
+
+// MarshalOctaveTo serializes the receiver to w as a matrix named name in
+// Octave text format, as read by load, and returns the number of bytes
+// written.
+func (m *Dense) MarshalOctaveTo(w io.Writer, name string, opts ...WriteOption) (int, error) {
+
+	integer := m.Field == mtxFieldInteger
+
+	M, N := m.mat.Dims()
+
+	return writeOctave(w, name, octaveMatrix, M, N, -1, func(bw *bufio.Writer) {
+
+		var b []byte
+
+		for i := 0; i < M; i++ {
+
+			b = b[:0]
+			for j := 0; j < N; j++ {
+				b = append(b, ' ')
+				b = appendOctaveFloat(b, m.mat.At(i, j), integer)
+			}
+
+			b = append(b, '\n')
+			bw.Write(b)
+		}
+	})
+}
+
+// MarshalOctaveTo serializes the receiver to w as a complex matrix named
+// name in Octave text format, as read by load, and returns the number of
+// bytes written.
+func (m *CDense) MarshalOctaveTo(w io.Writer, name string, opts ...WriteOption) (int, error) {
+
+	M, N := m.mat.Dims()
+
+	return writeOctave(w, name, octaveComplexMatrix, M, N, -1, func(bw *bufio.Writer) {
+
+		var b []byte
+
+		for i := 0; i < M; i++ {
+
+			b = b[:0]
+			for j := 0; j < N; j++ {
+				b = append(b, ' ')
+				b = appendOctaveComplex(b, m.mat.At(i, j))
+			}
+
+			b = append(b, '\n')
+			bw.Write(b)
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package market
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// octave01 is an Octave text file, as written by save -text, of a string,
+// a complex scalar, a sparse matrix, a logical sparse matrix, a matrix and
+// a complex matrix.
+const octave01 = `# Created by Octave 8.4.0, Mon Jan 01 00:00:00 2024 UTC <user@host>
+# name: s
+# type: string
+# elements: 1
+# length: 5
+# name
+
+# name: z
+# type: complex scalar
+(1,-2)
+
+
+# name: A
+# type: sparse matrix
+# nnz: 3
+# rows: 2
+# columns: 3
+1 1 1
+2 2 3
+1 3 2
+
+
+# name: B
+# type: sparse bool matrix
+# nnz: 1
+# rows: 2
+# columns: 2
+2 1 1
+
+
+# name: D
+# type: matrix
+# rows: 2
+# columns: 3
+ 1 -2.5 Inf
+ 4e-300 NaN NA
+
+
+# name: C
+# type: complex matrix
+# rows: 1
+# columns: 2
+ (1,2) (0,-1)
+
+
+`
+
+func TestCOOUnmarshalOctaveFrom(t *testing.T) {
+
+	var m COO
+
+	n, err := m.UnmarshalOctaveFrom(strings.NewReader(octave01), "")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Less(t, 0, n)
+	assert.Equal(t, mtxFieldReal, m.Field)
+	assert.True(t, mat.Equal(mat.NewDense(2, 3, []float64{1, 0, 2, 0, 3, 0}), m.ToCOO()))
+
+	_, err = m.UnmarshalOctaveFrom(strings.NewReader(octave01), "B")
+	if assert.NoError(t, err) {
+		assert.Equal(t, mtxFieldPattern, m.Field)
+		assert.True(t, mat.Equal(mat.NewDense(2, 2, []float64{0, 0, 1, 0}), m.ToCOO()))
+	}
+
+	// named variables must be of the class of the receiver
+	_, err = m.UnmarshalOctaveFrom(strings.NewReader(octave01), "D")
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = m.UnmarshalOctaveFrom(strings.NewReader(octave01), "s")
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = m.UnmarshalOctaveFrom(strings.NewReader(octave01), "y")
+	assert.ErrorIs(t, err, ErrNoVariable)
+
+	_, err = m.UnmarshalOctaveFrom(strings.NewReader(octave01), "A", WithLimits(Limits{MaxEntries: 2}))
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+}
+
+func TestCOOUnmarshalOctaveFromErrors(t *testing.T) {
+
+	hdr := "# name: A\n# type: sparse matrix\n# nnz: 2\n# rows: 2\n# columns: 2\n"
+
+	for _, test := range []struct {
+		name string
+		file string
+		line int
+		err  error
+	}{
+		{"index", hdr + "1 1 1\n3 1 1\n", 7, ErrIndexOutOfRange},
+		{"value", hdr + "1 1 1\n2 1 x\n", 7, ErrInputScanError},
+		{"short", hdr + "1 1 1\n", 6, ErrInputScanError},
+		{"rows", "# name: A\n# type: sparse matrix\n# nnz: 2\n# rows: -2\n", 4, ErrInvalidSize},
+	} {
+		var m COO
+
+		_, err := m.UnmarshalOctaveFrom(strings.NewReader(test.file), "A")
+		assert.ErrorIs(t, err, test.err, test.name)
+
+		var pe *ParseError
+		if assert.ErrorAs(t, err, &pe, test.name) {
+			assert.Equal(t, test.line, pe.Line, test.name)
+		}
+	}
+}
+
+func TestDenseUnmarshalOctaveFrom(t *testing.T) {
+
+	var m Dense
+
+	_, err := m.UnmarshalOctaveFrom(strings.NewReader(octave01), "")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// infinities and missing values are read
+	d := m.ToDense()
+	assert.Equal(t, 2, d.RawMatrix().Rows)
+	assert.Equal(t, []float64{1, -2.5, math.Inf(1), 4e-300}, append(d.RawRowView(0), d.At(1, 0)))
+	assert.True(t, math.IsNaN(d.At(1, 1)))
+	assert.True(t, math.IsNaN(d.At(1, 2)))
+
+	var z CDense
+
+	_, err = z.UnmarshalOctaveFrom(strings.NewReader(octave01), "")
+	if assert.NoError(t, err) {
+		assert.True(t, mat.CEqual(mat.NewCDense(1, 1, []complex128{1 - 2i}), z.ToCDense()))
+	}
+
+	_, err = z.UnmarshalOctaveFrom(strings.NewReader(octave01), "C")
+	if assert.NoError(t, err) {
+		assert.True(t, mat.CEqual(mat.NewCDense(1, 2, []complex128{1 + 2i, -1i}), z.ToCDense()))
+	}
+}
+
+func TestOctaveRoundTrip(t *testing.T) {
+
+	var b bytes.Buffer
+
+	// COO, with variables appended to one file
+	_, err := NewCOO(mtx01).MarshalOctaveTo(&b, "S")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	d := mat.NewDense(2, 3, []float64{1, -2.5, 0, 4e-300, math.Inf(-1), 6})
+	_, err = NewDense(d).MarshalOctaveTo(&b, "D")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	z := mat.NewCDense(2, 2, []complex128{1 + 2i, -3i, 4, 5 - 1i})
+	_, err = NewCDense(z).MarshalOctaveTo(&b, "Z")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var s COO
+
+	_, err = s.UnmarshalOctaveFrom(bytes.NewReader(b.Bytes()), "S")
+	if assert.NoError(t, err) {
+		assert.True(t, mat.Equal(mtx01, s.ToCOO()))
+	}
+
+	var m Dense
+
+	_, err = m.UnmarshalOctaveFrom(bytes.NewReader(b.Bytes()), "D")
+	if assert.NoError(t, err) {
+		assert.True(t, mat.Equal(d, m.ToDense()))
+	}
+
+	var c CDense
+
+	_, err = c.UnmarshalOctaveFrom(bytes.NewReader(b.Bytes()), "Z")
+	if assert.NoError(t, err) {
+		assert.True(t, mat.CEqual(z, c.ToCDense()))
+	}
+}
+
+func TestCOOMarshalOctaveTo(t *testing.T) {
+
+	var b bytes.Buffer
+
+	// duplicates are merged, and entries are in column major order
+	c := sparse.NewCOO(2, 2, []int{1, 0, 1}, []int{1, 0, 1}, []float64{2, 1, 3})
+	m := &COO{mtxObjectMatrix, mtxFormatCoordinate, mtxFieldInteger, mtxSymmetryGeneral, c}
+
+	n, err := m.MarshalOctaveTo(&b, "A")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, b.Len(), n)
+	assert.Equal(t, octaveCreatedBy+"\n# name: A\n# type: sparse matrix\n# nnz: 2\n# rows: 2\n# columns: 2\n1 1 1\n2 2 5\n\n\n", b.String())
+
+	for _, name := range []string{"", "1x", "a b"} {
+		_, err = m.MarshalOctaveTo(&bytes.Buffer{}, name)
+		assert.ErrorIs(t, err, ErrInvalidName, name)
+	}
+
+	_, err = m.MarshalOctaveTo(failWriter{}, "A")
+	assert.ErrorIs(t, err, ErrUnwritable)
+}
@@ -0,0 +1,662 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/james-bowman/sparse"
+	"gonum.org/v1/gonum/mat"
+)
+
+// ReadOption configures the reading of a Matrix Market file.
+type ReadOption func(*readOptions)
+
+// readOptions holds the configuration applied by a set of ReadOptions.
+type readOptions struct {
+	limits     Limits
+	duplicates DuplicatePolicy
+	zeros      ZeroPolicy
+	lenient    bool
+	partial    bool
+	base       int
+	baseSet    bool
+	symmetrize bool
+	warn       func(Warning)
+	dense      *mat.Dense
+	cdense     *mat.CDense
+	coo        *sparse.COO
+	bufs       *buffers
+	xform      *transform
+}
+
+// newReadOptions applies opts over the default reader configuration.
+func newReadOptions(opts []ReadOption) *readOptions {
+	var o readOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &o
+}
+
+// warnf reports a Warning of kind for line, if a warning function is
+// set.
+func (o *readOptions) warnf(line int, kind WarningKind, format string, args ...any) {
+	if o.warn != nil {
+		o.warn(Warning{Line: line, Kind: kind, Msg: fmt.Sprintf(format, args...)})
+	}
+}
+
+// addWarn adds fn to the functions called with each Warning.
+func (o *readOptions) addWarn(fn func(Warning)) {
+
+	prev := o.warn
+	if prev == nil {
+		o.warn = fn
+		return
+	}
+
+	o.warn = func(w Warning) {
+		prev(w)
+		fn(w)
+	}
+}
+
+// WarningKind classifies a Warning.
+type WarningKind int
+
+const (
+	// WarnHeader is a misspelt or malformed header, accepted only when
+	// reading in lenient mode.
+	WarnHeader WarningKind = iota
+
+	// WarnTrailingTokens is a header or size line having superfluous
+	// trailing tokens, which are discarded.
+	WarnTrailingTokens
+
+	// WarnDuplicate is an entry sharing coordinates with a prior entry,
+	// resolved per the DuplicatePolicy.
+	WarnDuplicate
+
+	// WarnUpperTriangle is an entry above the diagonal of a symmetric,
+	// skew-symmetric or hermitian matrix in coordinate format, where the
+	// specification stores only the lower triangle.
+	WarnUpperTriangle
+
+	// WarnExplicitZero is an entry of coordinate data having a value of
+	// zero, resolved per the ZeroPolicy.
+	WarnExplicitZero
+
+	// WarnHermitianDiagonal is an entry on the diagonal of a hermitian
+	// matrix having a non-zero imaginary part.
+	WarnHermitianDiagonal
+)
+
+var warningKinds = [...]string{
+	WarnHeader:            "header",
+	WarnTrailingTokens:    "trailing-tokens",
+	WarnDuplicate:         "duplicate",
+	WarnUpperTriangle:     "upper-triangle",
+	WarnExplicitZero:      "explicit-zero",
+	WarnHermitianDiagonal: "hermitian-diagonal",
+}
+
+// String returns the name of the kind.
+func (k WarningKind) String() string {
+	if k < 0 || int(k) >= len(warningKinds) {
+		return fmt.Sprintf("WarningKind(%d)", int(k))
+	}
+	return warningKinds[k]
+}
+
+// Warning describes input which was accepted despite departing from the
+// Matrix Market specification, or which is otherwise questionable.
+type Warning struct {
+	Line int         // one-indexed line number
+	Kind WarningKind // classification
+	Msg  string      // description
+}
+
+// String returns the warning prefixed by its line number.
+func (w Warning) String() string {
+	return fmt.Sprintf("line %d: %s", w.Line, w.Msg)
+}
+
+// WithLenient accepts common departures from the Matrix Market
+// specification, such as misspelt header fields (e.g., "skewsymmetric"),
+// each of which is reported as a Warning.
+func WithLenient() ReadOption {
+	return func(o *readOptions) {
+		o.lenient = true
+	}
+}
+
+// WithPartial recovers a partial matrix from truncated input. If the
+// input ends before all entries of the data section have been read, the
+// receiver holds the entries read, with the remainder zero, and a
+// *TruncatedError is returned. Without this option, truncated input is an
+// error and the receiver is not populated.
+func WithPartial() ReadOption {
+	return func(o *readOptions) {
+		o.partial = true
+	}
+}
+
+// WithIndexBase sets the index of the first row or column in formats
+// which do not fix it: the feature indices of a LIBSVM file, which are
+// one-based by default, and the node ids of an edge list, which are
+// zero-based by default. It has no effect on other formats.
+func WithIndexBase(base int) ReadOption {
+	return func(o *readOptions) {
+		o.base, o.baseSet = base, true
+	}
+}
+
+// indexBase returns the index base set by WithIndexBase, or def if none.
+func (o *readOptions) indexBase(def int) int {
+	if o.baseSet {
+		return o.base
+	}
+	return def
+}
+
+// WithSymmetrize reads the edges of an edge list or DIMACS file as those
+// of an undirected graph, such that the matrix is symmetric and each edge
+// (u, v) is stored at both (u, v) and (v, u). An edge listed in both
+// directions is then a duplicate, resolved per the DuplicatePolicy. It has
+// no effect on other formats.
+func WithSymmetrize() ReadOption {
+	return func(o *readOptions) {
+		o.symmetrize = true
+	}
+}
+
+// WithDense reads a Dense into dst, rather than into newly allocated
+// storage, reusing the backing data of dst if it has sufficient capacity.
+// The prior contents of dst are discarded, and are undefined if reading
+// fails. dst must not be a view of another matrix.
+func WithDense(dst *mat.Dense) ReadOption {
+	return func(o *readOptions) {
+		o.dense = dst
+	}
+}
+
+// WithCDense reads a CDense into dst, rather than into newly allocated
+// storage, reusing the backing data of dst if it has sufficient capacity.
+// The prior contents of dst are discarded, and are undefined if reading
+// fails. dst must not be a view of another matrix.
+func WithCDense(dst *mat.CDense) ReadOption {
+	return func(o *readOptions) {
+		o.cdense = dst
+	}
+}
+
+// WithCOO reads a COO into dst, rather than into a newly allocated
+// sparse.COO. The prior contents of dst are discarded, and are undefined
+// if reading fails. As sparse.COO does not expose its storage, the
+// storage of dst is reused only if dst was last read by the same Decoder.
+func WithCOO(dst *sparse.COO) ReadOption {
+	return func(o *readOptions) {
+		o.coo = dst
+	}
+}
+
+// newDense returns an M×N zero matrix, which is the destination set by
+// WithDense if any.
+func (o *readOptions) newDense(M, N int) *mat.Dense {
+
+	if o.dense == nil {
+		return mat.NewDense(M, N, nil)
+	}
+
+	o.dense.Reset()
+	o.dense.ReuseAs(M, N)
+
+	return o.dense
+}
+
+// newCDense returns an M×N zero matrix, which is the destination set by
+// WithCDense if any.
+func (o *readOptions) newCDense(M, N int) *mat.CDense {
+
+	if o.cdense == nil {
+		return mat.NewCDense(M, N, nil)
+	}
+
+	o.cdense.Reset()
+	o.cdense.ReuseAs(M, N)
+
+	return o.cdense
+}
+
+// transform returns the transform applied to entries as read, creating
+// it if need be.
+func (o *readOptions) transform() *transform {
+	if o.xform == nil {
+		o.xform = &transform{}
+	}
+	return o.xform
+}
+
+// WithRows selects the (zero-indexed) rows lo through hi-1 of the matrix,
+// such that row lo is the first row of the matrix read. Selection applies
+// to the rows once permuted by any WithRowPermutation. Selecting rows or
+// columns of a symmetric, skew-symmetric or hermitian matrix reads it as
+// a general matrix.
+func WithRows(lo, hi int) ReadOption {
+	return func(o *readOptions) {
+		o.transform().rows = span{lo, hi, true}
+	}
+}
+
+// WithCols selects the (zero-indexed) columns lo through hi-1 of the
+// matrix, as does WithRows for rows.
+func WithCols(lo, hi int) ReadOption {
+	return func(o *readOptions) {
+		o.transform().cols = span{lo, hi, true}
+	}
+}
+
+// WithTranspose reads the transpose of the matrix, once any rows and
+// columns have been permuted and selected.
+func WithTranspose() ReadOption {
+	return func(o *readOptions) {
+		o.transform().trans = true
+	}
+}
+
+// WithRowPermutation permutes the rows of the matrix, such that the
+// (zero-indexed) row i of the file is read as row p[i]. An error wrapping
+// ErrInvalidPermutation is returned if p is not a permutation of the rows
+// of the matrix. Permuting a symmetric, skew-symmetric or hermitian
+// matrix reads it as a general matrix. See ReadPermutation.
+func WithRowPermutation(p []int) ReadOption {
+	return func(o *readOptions) {
+		o.transform().rowPerm = p
+	}
+}
+
+// WithColPermutation permutes the columns of the matrix, such that the
+// (zero-indexed) column j of the file is read as column p[j], as does
+// WithRowPermutation for rows.
+func WithColPermutation(p []int) ReadOption {
+	return func(o *readOptions) {
+		o.transform().colPerm = p
+	}
+}
+
+// WithScale multiplies the value of each entry by s as it is read. The
+// values of pattern matrices are not scaled.
+func WithScale(s float64) ReadOption {
+	return func(o *readOptions) {
+		o.transform().scale = s
+		o.transform().scaled = true
+	}
+}
+
+// WithDropTolerance drops each entry having an absolute value less than
+// tol, once scaled, as it is read. Duplicate entries are compared with tol
+// individually, prior to being resolved. The entries of pattern matrices
+// are not dropped.
+func WithDropTolerance(tol float64) ReadOption {
+	return func(o *readOptions) {
+		o.transform().dropTol = tol
+	}
+}
+
+// WithWarnings sets a function to be called with each Warning. Where
+// more than one of WithWarnings, WithDiagnostics and WithLogHandler are
+// given, each receives all warnings.
+func WithWarnings(fn func(Warning)) ReadOption {
+	return func(o *readOptions) {
+		o.addWarn(fn)
+	}
+}
+
+// WithDiagnostics appends each Warning to dst.
+func WithDiagnostics(dst *[]Warning) ReadOption {
+	return WithWarnings(func(w Warning) {
+		*dst = append(*dst, w)
+	})
+}
+
+// WithLogHandler logs each Warning to h, at slog.LevelWarn and with the
+// line number and kind as attributes.
+func WithLogHandler(h slog.Handler) ReadOption {
+	l := slog.New(h)
+	return WithWarnings(func(w Warning) {
+		l.LogAttrs(
+			context.Background(),
+			slog.LevelWarn,
+			w.Msg,
+			slog.Int("line", w.Line),
+			slog.String("kind", w.Kind.String()),
+		)
+	})
+}
+
+// WriteOption configures the writing of a Matrix Market file.
+type WriteOption func(*writeOptions)
+
+// writeOptions holds the configuration applied by a set of WriteOptions.
+type writeOptions struct {
+	duplicates DuplicatePolicy
+	zeros      ZeroPolicy
+	tolerance  float64
+	order      Order
+	compact    bool
+	compressed bool
+	base       int
+	baseSet    bool
+	edgeScale  float64
+}
+
+// newWriteOptions applies opts over the default writer configuration.
+func newWriteOptions(opts []WriteOption) *writeOptions {
+	var o writeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &o
+}
+
+// DuplicatePolicy determines the handling of duplicate entries in
+// coordinate format data; that is, of multiple entries sharing the same
+// (i, j) coordinates. For symmetric, skew-symmetric and hermitian
+// matrices, an entry and its transpose are duplicates.
+type DuplicatePolicy int
+
+const (
+	// DuplicateSum sums duplicate entries, and is the default policy.
+	// Duplicates are retained as distinct entries in a sparse.COO,
+	// which sums them on access.
+	DuplicateSum DuplicatePolicy = iota
+
+	// DuplicateKeepLast retains the last of any duplicate entries.
+	DuplicateKeepLast
+
+	// DuplicateKeepFirst retains the first of any duplicate entries.
+	DuplicateKeepFirst
+
+	// DuplicateError reports duplicate entries as ErrDuplicateEntry.
+	DuplicateError
+)
+
+// ZeroPolicy determines the handling of explicit zeros in coordinate
+// format data; that is, of stored entries having a value of zero.
+type ZeroPolicy int
+
+const (
+	// ZeroPreserve retains explicit zeros as structural entries, and is
+	// the default policy.
+	ZeroPreserve ZeroPolicy = iota
+
+	// ZeroDrop discards entries having a value of zero, after any
+	// duplicates have been resolved.
+	ZeroDrop
+)
+
+// WithDuplicates sets the policy for duplicate entries read from
+// coordinate format data.
+func WithDuplicates(p DuplicatePolicy) ReadOption {
+	return func(o *readOptions) {
+		o.duplicates = p
+	}
+}
+
+// WithZeros sets the policy for explicit zeros read from coordinate
+// format data. Explicit zeros are not retained by dense storage, and
+// thus this option has no effect on reading into a CDense.
+func WithZeros(p ZeroPolicy) ReadOption {
+	return func(o *readOptions) {
+		o.zeros = p
+	}
+}
+
+// WriteDuplicates sets the policy for duplicate entries written as
+// coordinate format data. Under DuplicateSum, duplicates are written as
+// stored.
+func WriteDuplicates(p DuplicatePolicy) WriteOption {
+	return func(o *writeOptions) {
+		o.duplicates = p
+	}
+}
+
+// WriteZeros sets the policy for explicit zeros written as coordinate
+// format data.
+func WriteZeros(p ZeroPolicy) WriteOption {
+	return func(o *writeOptions) {
+		o.zeros = p
+	}
+}
+
+// Order determines the order in which the entries of coordinate format
+// data are written.
+type Order int
+
+const (
+	// OrderUnsorted writes entries in the order held by the matrix, and
+	// is the default order. Duplicates are written per the
+	// DuplicatePolicy.
+	OrderUnsorted Order = iota
+
+	// OrderRowMajor writes entries sorted by row, and then by column,
+	// merging duplicates.
+	OrderRowMajor
+
+	// OrderColMajor writes entries sorted by column, and then by row,
+	// merging duplicates.
+	OrderColMajor
+)
+
+// WriteOrder sets the order in which the entries of coordinate format
+// data are written. Where entries are sorted, any duplicates remaining
+// once resolved per the DuplicatePolicy are summed, such that each entry
+// is written once. Array format data are always written in column major
+// order.
+func WriteOrder(ord Order) WriteOption {
+	return func(o *writeOptions) {
+		o.order = ord
+	}
+}
+
+// WriteCompact writes values in a compact number format, being the
+// shortest representation which reads back as the value written, with
+// single spaces separating the fields of each line rather than padding to
+// align them. Values of integer matrices are written without exponents,
+// and negative zero is written as zero.
+func WriteCompact() WriteOption {
+	return func(o *writeOptions) {
+		o.compact = true
+	}
+}
+
+// WriteCanonical writes a matrix in a canonical form, with entries in
+// column major order and in the compact number format, such that
+// matrices having equal entries are written identically. It is equivalent
+// to WriteOrder(OrderColMajor) and WriteCompact.
+func WriteCanonical() WriteOption {
+	return func(o *writeOptions) {
+		o.order = OrderColMajor
+		o.compact = true
+	}
+}
+
+// sizeLine returns the format of the comment line separating the header
+// and size line, and of the size line, of which the number of entries is
+// written only for the coordinate format.
+func (o *writeOptions) sizeLine(coordinate bool) string {
+
+	switch {
+	case o.compact && coordinate:
+		return "%%\n%d %d %d\n"
+	case o.compact:
+		return "%%\n%d %d\n"
+	case coordinate:
+		return "%%\n %d  %d  %d\n"
+	}
+
+	return "%%\n %d  %d\n"
+}
+
+// WriteCompressed compresses the variables of a MATLAB MAT-file, as
+// MATLAB does by default. It has no effect on other formats.
+func WriteCompressed() WriteOption {
+	return func(o *writeOptions) {
+		o.compressed = true
+	}
+}
+
+// WriteIndexBase sets the index of the first row or column in formats
+// which do not fix it, as does WithIndexBase when reading. It has no
+// effect on other formats.
+func WriteIndexBase(base int) WriteOption {
+	return func(o *writeOptions) {
+		o.base, o.baseSet = base, true
+	}
+}
+
+// indexBase returns the index base set by WriteIndexBase, or def if none.
+func (o *writeOptions) indexBase(def int) int {
+	if o.baseSet {
+		return o.base
+	}
+	return def
+}
+
+// WriteEdgeWeights writes the entries of a matrix as the edge weights of
+// a METIS graph file, as their magnitudes multiplied by scale and rounded
+// to the nearest positive integer, as METIS requires. Without this option
+// edge weights are written only for matrices of the integer field. It has
+// no effect on other formats.
+func WriteEdgeWeights(scale float64) WriteOption {
+	return func(o *writeOptions) {
+		o.edgeScale = scale
+	}
+}
+
+// WriteTolerance sets the absolute tolerance used to detect symmetry,
+// when writing a matrix with SymmetryAuto. The default tolerance is zero,
+// requiring exact symmetry.
+func WriteTolerance(tol float64) WriteOption {
+	return func(o *writeOptions) {
+		o.tolerance = tol
+	}
+}
+
+// Limits bounds the resources that may be consumed while reading a
+// Matrix Market file, as a guard against hostile or corrupt input. A
+// zero value for any field means that the corresponding quantity is
+// unbounded. Storage for entries is allocated as they are read, rather
+// than as counted by a header, regardless of the limits. Dense storage
+// for entries read in coordinate form, which is bounded only by the
+// dimensions of the header, is of at most 1<<24 elements if no limits
+// are set, as are the rows of a Pattern, of which the compressed storage
+// holds a pointer for each row.
+type Limits struct {
+	MaxRows    int   // maximum number of rows (M)
+	MaxCols    int   // maximum number of columns (N)
+	MaxEntries int   // maximum number of stored entries (L, or M*N for arrays)
+	MaxBytes   int64 // maximum number of bytes read from the input
+}
+
+// WithLimits bounds the size of matrices that may be read. Limits are
+// checked immediately after the size line is read, prior to allocating
+// storage for the matrix.
+func WithLimits(l Limits) ReadOption {
+	return func(o *readOptions) {
+		o.limits = l
+	}
+}
+
+// checkSize reports an error wrapping ErrLimitExceeded if a matrix with
+// M rows, N columns and L stored entries would exceed the limits.
+func (l *Limits) checkSize(M, N, L int) error {
+
+	if l.MaxRows > 0 && M > l.MaxRows {
+		return fmt.Errorf("%w: %d rows exceeds maximum of %d", ErrLimitExceeded, M, l.MaxRows)
+	}
+
+	if l.MaxCols > 0 && N > l.MaxCols {
+		return fmt.Errorf("%w: %d columns exceeds maximum of %d", ErrLimitExceeded, N, l.MaxCols)
+	}
+
+	if l.MaxEntries > 0 && L > l.MaxEntries {
+		return fmt.Errorf("%w: %d entries exceeds maximum of %d", ErrLimitExceeded, L, l.MaxEntries)
+	}
+
+	return nil
+}
+
+// maxDenseElements bounds dense storage for entries read in coordinate
+// form, when reading without WithLimits.
+const maxDenseElements = 1 << 24
+
+// checkDense reports an error wrapping ErrLimitExceeded if dense storage
+// of M×N elements, for entries read in coordinate form, would exceed the
+// limits, or would exceed maxDenseElements if no limits are set.
+func (o *readOptions) checkDense(M, N int) error {
+
+	L, err := elements(M, N)
+	if err != nil {
+		return err
+	}
+
+	if o.limits == (Limits{}) && L > maxDenseElements {
+		return fmt.Errorf("%w: %d×%d dense matrix exceeds %d elements without limits", ErrLimitExceeded, M, N, maxDenseElements)
+	}
+
+	return o.limits.checkSize(M, N, L)
+}
+
+// checkRows reports an error wrapping ErrLimitExceeded if compressed
+// storage of M rows, which holds a pointer for each row regardless of the
+// entries read, would exceed maxDenseElements when reading without
+// WithLimits.
+func (o *readOptions) checkRows(M int) error {
+
+	if o.limits == (Limits{}) && M > maxDenseElements {
+		return fmt.Errorf("%w: %d rows exceeds %d without limits", ErrLimitExceeded, M, maxDenseElements)
+	}
+
+	return nil
+}
+
+// reader wraps r such that reads beyond the byte limit fail with an
+// error wrapping ErrLimitExceeded.
+func (l *Limits) reader(r io.Reader) io.Reader {
+	if l.MaxBytes <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, n: l.MaxBytes}
+}
+
+// limitedReader is an io.Reader that errors, rather than reporting EOF,
+// once more than n bytes have been read.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements the io.Reader interface.
+func (l *limitedReader) Read(p []byte) (int, error) {
+
+	if l.n < 0 {
+		return 0, fmt.Errorf("%w: input exceeds maximum size", ErrLimitExceeded)
+	}
+
+	// read at most one byte past the limit, to detect overrun
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+
+	if l.n < 0 {
+		return n + int(l.n), fmt.Errorf("%w: input exceeds maximum size", ErrLimitExceeded)
+	}
+
+	return n, err
+}
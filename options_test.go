@@ -0,0 +1,328 @@
+package market
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestWithLimits(t *testing.T) {
+
+	c := []struct {
+		name   string
+		text   string
+		limits Limits
+		want   error
+	}{
+		{
+			"rows",
+			"%%MatrixMarket matrix array real general\n100000000 1\n",
+			Limits{MaxRows: 1000},
+			ErrLimitExceeded,
+		},
+		{
+			"cols",
+			"%%MatrixMarket matrix array real general\n1 100000000\n",
+			Limits{MaxCols: 1000},
+			ErrLimitExceeded,
+		},
+		{
+			"entries",
+			"%%MatrixMarket matrix array real general\n100000000 100000000\n",
+			Limits{MaxEntries: 1 << 20},
+			ErrLimitExceeded,
+		},
+		{
+			"overflow",
+			"%%MatrixMarket matrix array real general\n4294967296 4294967296\n",
+			Limits{},
+			ErrLimitExceeded,
+		},
+		{
+			"bytes",
+			"%%MatrixMarket matrix array real general\n2 1\n1\n2\n",
+			Limits{MaxBytes: 48},
+			ErrLimitExceeded,
+		},
+		{
+			"within",
+			"%%MatrixMarket matrix array real general\n2 1\n1\n2\n",
+			Limits{MaxRows: 2, MaxCols: 1, MaxEntries: 2, MaxBytes: 64},
+			nil,
+		},
+	}
+
+	for _, v := range c {
+
+		var mm Dense
+		_, err := mm.UnmarshalTextFrom(strings.NewReader(v.text), WithLimits(v.limits))
+
+		if v.want == nil {
+			assert.Nil(t, err, v.name)
+			continue
+		}
+
+		assert.True(t, errors.Is(err, v.want), "%s: %v", v.name, err)
+	}
+}
+
+func TestWithLimitsCoordinate(t *testing.T) {
+
+	var (
+		coo COO
+		cd  CDense
+	)
+
+	text := "%%MatrixMarket matrix coordinate real general\n10 10 100000000000\n"
+	_, err := coo.UnmarshalTextFrom(strings.NewReader(text), WithLimits(Limits{MaxEntries: 1000}))
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+
+	// complex coordinate data are stored densely, and thus limited by
+	// the number of matrix elements
+	text = "%%MatrixMarket matrix coordinate complex general\n1000 1000 1\n1 1 1 1\n"
+	_, err = cd.UnmarshalTextFrom(strings.NewReader(text), WithLimits(Limits{MaxEntries: 1000}))
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+
+	f, err := os.Open(filepath.Join("testdata", "mmtype-01.mtx"))
+	assert.Nil(t, err)
+	defer f.Close()
+
+	_, err = coo.UnmarshalTextFrom(f, WithLimits(Limits{MaxRows: 4, MaxCols: 5, MaxEntries: 15}))
+	assert.Nil(t, err)
+}
+
+func TestWithoutLimits(t *testing.T) {
+
+	// hostile size lines neither allocate storage nor panic
+	for _, text := range []string{
+		"%%MatrixMarket matrix array real general\n100000000 100000000\n1\n",
+		"%%MatrixMarket matrix array complex general\n100000000 100000000\n1 1\n",
+		"%%MatrixMarket matrix coordinate real general\n10 10 100000000000000\n1 1 1\n",
+		"%%MatrixMarket matrix coordinate pattern general\n10 10 100000000000000\n1 1\n",
+		"%%MatrixMarket matrix coordinate complex general\n100000000 100000000 1\n1 1 1 1\n",
+	} {
+		for _, m := range []interface {
+			UnmarshalTextFrom(io.Reader, ...ReadOption) (int, error)
+		}{&COO{}, &Dense{}, &CDense{}, &Coordinate[int, float64]{}, &Pattern[int32]{}, &Array[float64]{}} {
+			assert.NotPanics(t, func() {
+				_, err := m.UnmarshalTextFrom(strings.NewReader(text))
+				assert.Error(t, err)
+			}, text)
+		}
+	}
+
+	// nor are entries read partially stored densely beyond a bound
+	var d Dense
+	_, err := d.UnmarshalTextFrom(strings.NewReader("%%MatrixMarket matrix array real general\n100000000 100000000\n1\n"), WithPartial())
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	var cd CDense
+	_, err = cd.UnmarshalTextFrom(strings.NewReader("%%MatrixMarket matrix coordinate complex general\n100000000 100000000 1\n1 1 1 1\n"))
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	// without duplicates, no more entries are stored than elements
+	var coo COO
+	_, err = coo.UnmarshalTextFrom(strings.NewReader("%%MatrixMarket matrix coordinate real general\n2 2 5\n"), WithDuplicates(DuplicateError))
+	assert.ErrorIs(t, err, ErrInvalidSize)
+}
+
+func TestLimitedReader(t *testing.T) {
+
+	l := Limits{MaxBytes: 4}
+
+	b, err := io.ReadAll(l.reader(strings.NewReader("abcd")))
+	assert.Nil(t, err)
+	assert.Equal(t, "abcd", string(b))
+
+	b, err = io.ReadAll(l.reader(strings.NewReader("abcde")))
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+	assert.Equal(t, "abcd", string(b))
+}
+
+func TestWithDiagnostics(t *testing.T) {
+
+	text := `%%MatrixMarket matrix coordinate real symmetric extra
+3 3 4 extra
+1 1 0
+2 1 1
+1 2 1
+3 3 1
+`
+
+	var w []Warning
+
+	var m COO
+	_, err := m.UnmarshalTextFrom(strings.NewReader(text), WithDiagnostics(&w))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []Warning{
+		{Line: 1, Kind: WarnTrailingTokens, Msg: "header has 1 trailing tokens"},
+		{Line: 2, Kind: WarnTrailingTokens, Msg: "size line has 1 trailing tokens"},
+		{Line: 3, Kind: WarnExplicitZero, Msg: "explicit zero at (1, 1)"},
+		{Line: 5, Kind: WarnUpperTriangle, Msg: "entry (1, 2) is above the diagonal of a symmetric matrix"},
+		{Line: 5, Kind: WarnDuplicate, Msg: "duplicate entry at (1, 2)"},
+	}, w)
+
+	// hermitian testdata have non-zero imaginary parts on the diagonal
+	for _, k := range []string{"mmtype-19.mtx", "mmtype-20.mtx"} {
+
+		f, err := os.Open(filepath.Join("testdata", k))
+		assert.Nil(t, err)
+		defer f.Close()
+
+		w = nil
+
+		var cd CDense
+		_, err = cd.UnmarshalTextFrom(f, WithDiagnostics(&w))
+		assert.Nil(t, err)
+
+		assert.Len(t, w, 5, k)
+		for _, v := range w {
+			assert.Equal(t, WarnHermitianDiagonal, v.Kind)
+		}
+	}
+}
+
+func TestWithLogHandler(t *testing.T) {
+
+	var (
+		b bytes.Buffer
+		w []Warning
+	)
+
+	text := "%%MatrixMarket matrix coordinate real general\n2 2 2\n1 1 1\n1 1 2\n"
+
+	var m COO
+	_, err := m.UnmarshalTextFrom(
+		strings.NewReader(text),
+		WithLogHandler(slog.NewTextHandler(&b, nil)),
+		WithDiagnostics(&w),
+	)
+	assert.Nil(t, err)
+
+	assert.Len(t, w, 1)
+	assert.Contains(t, b.String(), `level=WARN msg="duplicate entry at (1, 1)" line=4 kind=duplicate`)
+}
+
+func TestWithPartial(t *testing.T) {
+
+	// truncated mid-entry, such that the incomplete entry is discarded
+	text := "%%MatrixMarket matrix coordinate real general\n3 3 3\n1 1 1\n2 2 2\n3 3"
+
+	var m COO
+	_, err := m.UnmarshalTextFrom(strings.NewReader(text))
+	assert.True(t, errors.Is(err, ErrInputScanError))
+	assert.False(t, errors.Is(err, ErrTruncated))
+
+	_, err = m.UnmarshalTextFrom(strings.NewReader(text), WithPartial())
+	assert.True(t, errors.Is(err, ErrTruncated))
+	assert.EqualError(t, err, "line 5: "+ErrTruncated.Error()+": read 2 of 3 entries")
+
+	var te *TruncatedError
+	if assert.True(t, errors.As(err, &te)) {
+		assert.Equal(t, TruncatedError{Line: 5, Expected: 3, Actual: 2}, *te)
+	}
+	assert.True(t, mat.Equal(m.ToMatrix(), mat.NewDiagDense(3, []float64{1, 2, 0})))
+
+	// complete input is unaffected
+	_, err = m.UnmarshalTextFrom(strings.NewReader(text+" 3\n"), WithPartial())
+	assert.Nil(t, err)
+
+	// malformed input remains an error
+	_, err = m.UnmarshalTextFrom(strings.NewReader(text+" x\n"), WithPartial())
+	assert.False(t, errors.Is(err, ErrTruncated))
+
+	// symmetric array data, of which 3 entries are stored
+	text = "%%MatrixMarket matrix array real symmetric\n2 2\n1\n2\n"
+
+	var d Dense
+	_, err = d.UnmarshalTextFrom(strings.NewReader(text), WithPartial())
+	if assert.True(t, errors.As(err, &te)) {
+		assert.Equal(t, TruncatedError{Line: 4, Expected: 3, Actual: 2}, *te)
+	}
+	assert.True(t, mat.Equal(d.ToDense(), mat.NewDense(2, 2, []float64{1, 2, 2, 0})))
+
+	text = "%%MatrixMarket matrix array complex general\n2 1\n1 2\n"
+
+	var cd CDense
+	_, err = cd.UnmarshalTextFrom(strings.NewReader(text), WithPartial())
+	if assert.True(t, errors.As(err, &te)) {
+		assert.Equal(t, TruncatedError{Line: 3, Expected: 2, Actual: 1}, *te)
+	}
+	assert.True(t, mat.CEqual(cd.ToCMatrix(), mat.NewCDense(2, 1, []complex128{1 + 2i, 0})))
+
+	text = "%%MatrixMarket matrix coordinate complex general\n2 2 2\n1 1 1 2\n"
+
+	_, err = cd.UnmarshalTextFrom(strings.NewReader(text), WithPartial())
+	if assert.True(t, errors.As(err, &te)) {
+		assert.Equal(t, TruncatedError{Line: 3, Expected: 2, Actual: 1}, *te)
+	}
+	assert.True(t, mat.CEqual(cd.ToCMatrix(), mat.NewCDense(2, 2, []complex128{1 + 2i, 0, 0, 0})))
+}
+
+func TestWithDense(t *testing.T) {
+
+	text := "%%MatrixMarket matrix array real general\n2 2\n1\n2\n3\n4\n"
+
+	// storage of sufficient capacity is reused
+	dst := mat.NewDense(3, 3, []float64{9, 9, 9, 9, 9, 9, 9, 9, 9})
+	data := dst.RawMatrix().Data
+
+	var m Dense
+	_, err := m.UnmarshalTextFrom(strings.NewReader(text), WithDense(dst))
+	assert.Nil(t, err)
+	assert.Same(t, dst, m.ToDense())
+	assert.Same(t, &data[0], &dst.RawMatrix().Data[0])
+	assert.True(t, mat.Equal(dst, mat.NewDense(2, 2, []float64{1, 3, 2, 4})))
+
+	// and otherwise grown
+	dst = mat.NewDense(1, 1, nil)
+	_, err = m.UnmarshalTextFrom(strings.NewReader(text), WithDense(dst))
+	assert.Nil(t, err)
+	assert.Same(t, dst, m.ToDense())
+	assert.True(t, mat.Equal(dst, mat.NewDense(2, 2, []float64{1, 3, 2, 4})))
+}
+
+func TestWithCDense(t *testing.T) {
+
+	dst := mat.NewCDense(2, 2, []complex128{9, 9, 9, 9})
+	data := dst.RawCMatrix().Data
+
+	var m CDense
+
+	// array data
+	text := "%%MatrixMarket matrix array complex general\n2 1\n1 2\n3 4\n"
+	_, err := m.UnmarshalTextFrom(strings.NewReader(text), WithCDense(dst))
+	assert.Nil(t, err)
+	assert.Same(t, &data[0], &dst.RawCMatrix().Data[0])
+	assert.True(t, mat.CEqual(m.ToCMatrix(), mat.NewCDense(2, 1, []complex128{1 + 2i, 3 + 4i})))
+
+	// coordinate data
+	text = "%%MatrixMarket matrix coordinate complex general\n2 2 1\n2 2 1 2\n"
+	_, err = m.UnmarshalTextFrom(strings.NewReader(text), WithCDense(dst))
+	assert.Nil(t, err)
+	assert.Same(t, &data[0], &dst.RawCMatrix().Data[0])
+	assert.True(t, mat.CEqual(m.ToCMatrix(), mat.NewCDense(2, 2, []complex128{0, 0, 0, 1 + 2i})))
+}
+
+func TestWithCOO(t *testing.T) {
+
+	text := "%%MatrixMarket matrix coordinate real general\n2 2 2\n1 1 1\n2 2 2\n"
+
+	dst := sparse.NewCOO(1, 1, nil, nil, nil)
+
+	var m COO
+	_, err := m.UnmarshalTextFrom(strings.NewReader(text), WithCOO(dst))
+	assert.Nil(t, err)
+	assert.Same(t, dst, m.ToCOO())
+	assert.True(t, mat.Equal(dst, mat.NewDiagDense(2, []float64{1, 2})))
+}
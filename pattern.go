@@ -0,0 +1,475 @@
+package market
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Pattern is a sparse matrix holding only structure, for reading and
+// writing Matrix Market coordinate format with a pattern field. Entries
+// are held in compressed sparse row form, without values: the columns of
+// the entries of the (zero-indexed) row i are ColIdx[RowPtr[i]:RowPtr[i+1]],
+// which are sorted and distinct. RowPtr has M+1 elements, and is of type
+// int such that the number of entries is not bounded by I, or is nil for
+// a matrix of no entries, as of the zero value. Both an entry and its
+// transpose are held for symmetric matrices.
+//
+// Pattern implements mat.Matrix, having an element of one at each entry
+// and of zero elsewhere.
+type Pattern[I Integer] struct {
+	Object   string
+	Format   string
+	Field    string
+	Symmetry string
+	M, N     int // number of rows and columns
+	RowPtr   []int
+	ColIdx   []I
+}
+
+// NewPattern initializes a new M×N Pattern matrix from rowPtr and colIdx,
+// which are not copied.
+func NewPattern[I Integer](M, N int, rowPtr []int, colIdx []I) *Pattern[I] {
+	return &Pattern[I]{
+		Object:   mtxObjectMatrix,
+		Format:   mtxFormatCoordinate,
+		Field:    mtxFieldPattern,
+		Symmetry: mtxSymmetryGeneral,
+		M:        M,
+		N:        N,
+		RowPtr:   rowPtr,
+		ColIdx:   colIdx,
+	}
+}
+
+// Dims returns the number of rows and columns of the matrix.
+func (m *Pattern[I]) Dims() (int, int) { return m.M, m.N }
+
+// At returns one if the matrix holds an entry at the (zero-indexed) row i
+// and column j, or otherwise zero.
+func (m *Pattern[I]) At(i, j int) float64 {
+
+	if i < 0 || i >= m.M {
+		panic(mat.ErrRowAccess)
+	}
+
+	if j < 0 || j >= m.N {
+		panic(mat.ErrColAccess)
+	}
+
+	if _, ok := slices.BinarySearch(m.row(i), I(j)); ok {
+		return 1
+	}
+
+	return 0
+}
+
+// row returns the columns of the entries of the (zero-indexed) row i,
+// of which there are none if RowPtr is nil.
+func (m *Pattern[I]) row(i int) []I {
+
+	if m.RowPtr == nil {
+		return nil
+	}
+
+	return m.ColIdx[m.RowPtr[i]:m.RowPtr[i+1]]
+}
+
+// isSymmetric reports whether the matrix is square and holds the
+// transpose of each of its entries. Each transpose is found in the
+// compressed rows, such that no storage is allocated.
+func (m *Pattern[I]) isSymmetric() bool {
+
+	if m.M != m.N {
+		return false
+	}
+
+	for i := 0; i < m.M; i++ {
+		for _, j := range m.row(i) {
+			if _, ok := slices.BinarySearch(m.row(int(j)), I(i)); !ok {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// T returns the transpose of the matrix.
+func (m *Pattern[I]) T() mat.Matrix { return mat.Transpose{Matrix: m} }
+
+// NNZ returns the number of entries.
+func (m *Pattern[I]) NNZ() int { return len(m.ColIdx) }
+
+// Do calls fn for each entry, in row major order.
+func (m *Pattern[I]) Do(fn func(i, j int)) {
+	for i := 0; i < m.M; i++ {
+		for _, j := range m.row(i) {
+			fn(i, int(j))
+		}
+	}
+}
+
+// DoNonZero calls fn for each entry, in row major order, with a value of
+// one.
+func (m *Pattern[I]) DoNonZero(fn func(i, j int, v float64)) {
+	m.Do(func(i, j int) {
+		fn(i, j, 1)
+	})
+}
+
+// MarshalText serializes the receiver to []byte in Matrix Market format
+// and returns the result.
+func (m *Pattern[I]) MarshalText() ([]byte, error) {
+
+	var b strings.Builder
+
+	if _, err := m.MarshalTextTo(&b); err != nil {
+		return nil, err
+	}
+
+	return []byte(b.String()), nil
+}
+
+// MarshalTextTo serializes the receiver to w in Matrix Market format,
+// as configured by opts, and returns the result. Entries are written as
+// row and column indices only, without values, in row major order unless
+// writing with OrderColMajor.
+func (m *Pattern[I]) MarshalTextTo(w io.Writer, opts ...WriteOption) (int, error) {
+
+	var total int
+
+	o := newWriteOptions(opts)
+
+	t := mmType{m.Object, m.Format, m.Field, m.Symmetry}
+	t.canonicalize()
+
+	// a pattern is symmetric or general
+	if t.Symmetry == SymmetryAuto {
+		t.Symmetry = SymmetryGeneral
+		if m.isSymmetric() {
+			t.Symmetry = SymmetrySymmetric
+		}
+	}
+
+	if i := t.index(); i != 21 && i != 22 {
+		return total, ErrUnsupportedType
+	}
+
+	src := m.Do
+	if o.order == OrderColMajor {
+		src = m.doColMajor
+	}
+
+	// only the lower triangle of a symmetric matrix is written
+	do := func(fn func(i, j int)) {
+		src(func(i, j int) {
+			if isStored(t.Symmetry, i, j) {
+				fn(i, j)
+			}
+		})
+	}
+
+	// entries are fit and counted prior to writing the header, which
+	// includes the number of entries
+	var (
+		a   indexAligner
+		nnz int
+	)
+	fit := a.Fit()
+	do(func(i, j int) {
+		if !o.compact {
+			fit(i, j)
+		}
+		nnz++
+	})
+
+	if n, err := w.Write(t.Bytes()); err == nil {
+		total += n
+	} else {
+		return total, ErrUnwritable
+	}
+
+	if n, err := fmt.Fprintf(w, o.sizeLine(true), m.M, m.N, nnz); err == nil {
+		total += n
+	} else {
+		return total, ErrUnwritable
+	}
+
+	var (
+		buf = make([]byte, 0, 64)
+		err error
+		n   int
+	)
+	do(func(i, j int) {
+		if err != nil {
+			return
+		}
+
+		if o.compact {
+			buf = appendCompactIndex(buf[:0], i, j)
+		} else {
+			buf = a.Append(buf[:0], i, j)
+		}
+		buf = append(buf, '\n')
+
+		n, err = w.Write(buf)
+		total += n
+	})
+	if err != nil {
+		return total, ErrUnwritable
+	}
+
+	return total, nil
+}
+
+// doColMajor calls fn for each entry, in column major order.
+func (m *Pattern[I]) doColMajor(fn func(i, j int)) {
+
+	rows := make([]I, 0, len(m.ColIdx))
+	for i := 0; i < m.M; i++ {
+		for range m.row(i) {
+			rows = append(rows, I(i))
+		}
+	}
+
+	// the compressed sparse row form of the transpose is the compressed
+	// sparse column form of the matrix
+	ptr, idx := compress(m.N, slices.Clone(m.ColIdx), rows)
+
+	for j := 0; j < m.N; j++ {
+		for _, i := range idx[ptr[j]:ptr[j+1]] {
+			fn(int(i), j)
+		}
+	}
+}
+
+// UnmarshalText deserializes []byte from Matrix Market format into the
+// receiver.
+func (m *Pattern[I]) UnmarshalText(text []byte) error {
+
+	r := bytes.NewReader(text)
+
+	if _, err := m.UnmarshalTextFrom(r); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnmarshalTextFrom deserializes r from Matrix Market format into the
+// receiver, as configured by opts. The field of the file must be pattern.
+// As entries have no value, duplicate entries are merged, unless reading
+// with DuplicateError. An error wrapping ErrIndexOverflow is returned if
+// the dimensions of the matrix are not representable by I.
+func (m *Pattern[I]) UnmarshalTextFrom(r io.Reader, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	scanner := newScanner(r, o)
+
+	// read header
+	t, err := scanHeader(scanner, o)
+	if err != nil {
+		return n.total, err
+	}
+
+	if i := t.index(); i != 21 && i != 22 {
+		return n.total, ErrUnsupportedType
+	}
+
+	// apply header fields
+	m.Object = t.Object
+	m.Format = t.Format
+	m.Field = t.Field
+	m.Symmetry = o.xform.symmetry(t.Symmetry)
+
+	if err := m.scanPatternData(scanner, t, o); err != nil {
+		return n.total, err
+	}
+
+	if err := scanError(scanner.Scanner); err != nil {
+		return n.total, err
+	}
+
+	return n.total, nil
+}
+
+func (m *Pattern[I]) scanPatternData(scanner *lineScanner, t *mmType, o *readOptions) error {
+
+	M, N, L, err := scanSize(scanner, t, o)
+	if err != nil {
+		return err
+	}
+
+	// indices are held as transformed by any selection or transform
+	// options
+	Mx, Nx := o.xform.dims(M, N)
+	if err := checkIndex[I](Mx, Nx); err != nil {
+		return scanner.errorf(scanner.line, err)
+	}
+
+	if err := o.checkRows(Mx); err != nil {
+		return scanner.errorf(scanner.line, err)
+	}
+
+	var (
+		rows  = make([]I, 0, prealloc(L))
+		cols  = make([]I, 0, prealloc(L))
+		seen  map[[2]int]struct{}
+		trunc *TruncatedError
+	)
+
+	// duplicates are merged when compressing, and are otherwise tracked
+	// only such that they may be reported
+	if o.warn != nil || o.duplicates == DuplicateError {
+		seen = make(map[[2]int]struct{})
+	}
+
+	add := func(i, j int) bool {
+
+		i, j, ok := o.xform.index(i, j)
+		if !ok {
+			return false
+		}
+
+		rows = append(rows, I(i))
+		cols = append(cols, I(j))
+
+		if seen == nil {
+			return false
+		}
+
+		k := [2]int{i, j}
+		if _, ok := seen[k]; ok {
+			return true
+		}
+		seen[k] = struct{}{}
+
+		return false
+	}
+
+	for k := 0; k < L; k++ {
+
+		toks, line, err := scanner.entry(2)
+		if trunc = scanner.truncation(o, err, k, L); trunc != nil {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		i, err := parseInt(toks[0])
+		if err != nil {
+			return scanner.errorf(line, err)
+		}
+
+		j, err := parseInt(toks[1])
+		if err != nil {
+			return scanner.errorf(line, err)
+		}
+
+		if !inBounds(i, j, M, N) {
+			return scanner.errorf(line, ErrIndexOutOfRange)
+		}
+
+		if i < j && !t.isGeneral() {
+			o.warnf(line, WarnUpperTriangle, "entry (%d, %d) is above the diagonal of a %s matrix", i, j, t.Symmetry)
+		}
+
+		// if off diagonal, set symm element
+		var dup bool
+		if i != j && !t.isGeneral() {
+			dup = add(j-1, i-1)
+		}
+		dup = add(i-1, j-1) || dup
+
+		if dup && o.duplicates == DuplicateError {
+			return scanner.errorf(line, ErrDuplicateEntry)
+		}
+
+		if dup {
+			o.warnf(line, WarnDuplicate, "duplicate entry at (%d, %d)", i, j)
+		}
+	}
+
+	// error out if data exceed the expected number of entries
+	if trunc == nil {
+		if err := scanner.end(); err != nil {
+			return err
+		}
+	}
+
+	m.M, m.N = Mx, Nx
+	m.RowPtr, m.ColIdx = compress(Mx, rows, cols)
+
+	if trunc != nil {
+		return trunc
+	}
+
+	return nil
+}
+
+// compress returns the compressed sparse row form of the (zero-indexed)
+// entries of an M-row matrix at rows and cols, in which the columns of
+// each row are sorted and duplicates are merged. The entries are
+// reordered in place, and cols is reused as the column indices.
+func compress[I Integer](M int, rows, cols []I) ([]int, []I) {
+
+	ptr := make([]int, M+1)
+
+	// count entries of each row
+	for _, i := range rows {
+		ptr[i+1]++
+	}
+	for i := 0; i < M; i++ {
+		ptr[i+1] += ptr[i]
+	}
+
+	// sort entries by row, in place, by swapping each entry into the
+	// next free position of its row
+	next := slices.Clone(ptr[:M])
+	for b := 0; b < M; b++ {
+
+		for next[b] < ptr[b+1] {
+
+			p := next[b]
+
+			i := int(rows[p])
+			if i == b {
+				next[b]++
+				continue
+			}
+
+			q := next[i]
+			next[i]++
+
+			rows[p], rows[q] = rows[q], rows[p]
+			cols[p], cols[q] = cols[q], cols[p]
+		}
+	}
+
+	// sort and merge the columns of each row
+	var k int
+	for i := 0; i < M; i++ {
+
+		row := cols[ptr[i]:ptr[i+1]]
+		slices.Sort(row)
+		row = slices.Compact(row)
+
+		ptr[i] = k
+		k += copy(cols[k:], row)
+	}
+	ptr[M] = k
+
+	return ptr, slices.Clip(cols[:k])
+}
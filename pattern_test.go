@@ -0,0 +1,165 @@
+package market
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPatternUnmarshalTextFrom(t *testing.T) {
+
+	c := map[string]mat.Matrix{
+		"mmtype-21.mtx": mtx21, // pattern general
+		"mmtype-22.mtx": mtx22, // pattern symmetric
+	}
+
+	for k, v := range c {
+
+		b, err := os.ReadFile(filepath.Join("testdata", k))
+		assert.Nil(t, err)
+
+		var p Pattern[int32]
+		assert.Nil(t, p.UnmarshalText(b), k)
+		assert.True(t, mat.Equal(&p, v), k)
+		assert.True(t, mat.Equal(p.T(), v.T()), k)
+
+		// round trip, without values
+		text, err := p.MarshalText()
+		assert.Nil(t, err)
+		assert.Equal(t, string(b), string(text), k)
+	}
+
+	var p Pattern[int32]
+	_, err := p.UnmarshalTextFrom(strings.NewReader("%%MatrixMarket matrix coordinate real general\n1 1 1\n1 1 1\n"))
+	assert.Equal(t, ErrUnsupportedType, err)
+
+	_, err = p.UnmarshalTextFrom(strings.NewReader("%%MatrixMarket matrix coordinate pattern general\n3000000000 1 1\n1 1\n"))
+	assert.True(t, errors.Is(err, ErrIndexOverflow))
+}
+
+func TestPatternUnmarshalTextFromDuplicates(t *testing.T) {
+
+	text := `%%MatrixMarket matrix coordinate pattern symmetric
+3 3 5
+3 1
+2 2
+1 3
+3 3
+2 2
+`
+
+	var (
+		p Pattern[int]
+		w []Warning
+	)
+
+	_, err := p.UnmarshalTextFrom(strings.NewReader(text), WithDiagnostics(&w))
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2, 4}, p.RowPtr)
+	assert.Equal(t, []int{2, 1, 0, 2}, p.ColIdx)
+	assert.Equal(t, 4, p.NNZ())
+
+	assert.Equal(t, []Warning{
+		{Line: 5, Kind: WarnUpperTriangle, Msg: "entry (1, 3) is above the diagonal of a symmetric matrix"},
+		{Line: 5, Kind: WarnDuplicate, Msg: "duplicate entry at (1, 3)"},
+		{Line: 7, Kind: WarnDuplicate, Msg: "duplicate entry at (2, 2)"},
+	}, w)
+
+	_, err = p.UnmarshalTextFrom(strings.NewReader(text), WithDuplicates(DuplicateError))
+	assert.EqualError(t, err, "line 5: "+ErrDuplicateEntry.Error())
+}
+
+func TestPatternUnmarshalTextFromPartial(t *testing.T) {
+
+	text := "%%MatrixMarket matrix coordinate pattern general\n2 2 2\n2 1\n"
+
+	var p Pattern[int32]
+	_, err := p.UnmarshalTextFrom(strings.NewReader(text), WithPartial())
+	assert.True(t, errors.Is(err, ErrTruncated))
+	assert.True(t, mat.Equal(&p, mat.NewDense(2, 2, []float64{0, 0, 1, 0})))
+}
+
+func TestPatternMarshalTextTo(t *testing.T) {
+
+	p := NewPattern(3, 3, []int{0, 2, 3, 4}, []int32{0, 2, 1, 0})
+	p.Symmetry = SymmetryAuto
+
+	text, err := p.MarshalText()
+	assert.Nil(t, err)
+	assert.Equal(t, "%%MatrixMarket matrix coordinate pattern symmetric\n%\n 3  3  3\n 1  1\n 2  2\n 3  1\n", string(text))
+
+	// the transpose of each entry is required for symmetry
+	q := NewPattern(3, 3, []int{0, 1, 2, 3}, []int32{2, 1, 1})
+	q.Symmetry = SymmetryAuto
+
+	text, err = q.MarshalText()
+	if assert.NoError(t, err) {
+		assert.True(t, strings.HasPrefix(string(text), "%%MatrixMarket matrix coordinate pattern general\n"))
+	}
+
+	p.Symmetry = SymmetrySkew
+	_, err = p.MarshalText()
+	assert.Equal(t, ErrUnsupportedType, err)
+
+	p.Symmetry = SymmetryGeneral
+	p.Field = mtxFieldReal
+	_, err = p.MarshalText()
+	assert.Equal(t, ErrUnsupportedType, err)
+}
+
+func TestPatternAt(t *testing.T) {
+
+	p := NewPattern(2, 3, []int{0, 2, 2}, []int32{0, 2})
+
+	assert.Equal(t, 1.0, p.At(0, 0))
+	assert.Equal(t, 0.0, p.At(0, 1))
+	assert.Equal(t, 1.0, p.At(0, 2))
+	assert.Equal(t, 0.0, p.At(1, 2))
+
+	assert.Panics(t, func() { p.At(2, 0) })
+	assert.Panics(t, func() { p.At(0, 3) })
+
+	// a nil RowPtr holds no entries
+	p = NewPattern[int32](2, 3, nil, nil)
+
+	assert.Equal(t, 0.0, p.At(1, 2))
+	assert.Equal(t, 0, p.NNZ())
+
+	var b bytes.Buffer
+	_, err := p.MarshalTextTo(&b)
+	if assert.NoError(t, err) {
+		assert.Contains(t, b.String(), " 2  3  0\n")
+	}
+
+	assert.Panics(t, func() { new(Pattern[int32]).At(0, 0) })
+}
+
+func TestCompress(t *testing.T) {
+
+	rows := []int32{2, 0, 2, 1, 0, 2, 0}
+	cols := []int32{1, 3, 0, 2, 0, 1, 3}
+
+	ptr, idx := compress(4, rows, cols)
+	assert.Equal(t, []int{0, 2, 3, 5, 5}, ptr)
+	assert.Equal(t, []int32{0, 3, 2, 0, 1}, idx)
+
+	ptr, idx = compress[int32](2, nil, nil)
+	assert.Equal(t, []int{0, 0, 0}, ptr)
+	assert.Empty(t, idx)
+}
+
+func TestPatternMarshalTextToOrder(t *testing.T) {
+
+	p := NewPattern(3, 3, []int{0, 2, 3, 4}, []int32{1, 2, 0, 0})
+
+	var b strings.Builder
+	_, err := p.MarshalTextTo(&b, WriteCanonical())
+	assert.Nil(t, err)
+	assert.Equal(t, "%%MatrixMarket matrix coordinate pattern general\n%\n3 3 4\n2 1\n3 1\n1 2\n1 3\n", b.String())
+}
@@ -0,0 +1,434 @@
+package market
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/james-bowman/sparse"
+)
+
+// class identifiers of the objects of a PETSc binary file
+const (
+	petscMatClassID = 1211216
+	petscVecClassID = 1211214
+)
+
+// petscDenseFormat is written in place of the number of nonzeros of a
+// matrix in dense format.
+const petscDenseFormat = -1
+
+// petscHeader is the header of a matrix or vector of a PETSc binary file.
+type petscHeader struct {
+	class int
+	M, N  int
+	nz    int // number of nonzeros, or petscDenseFormat
+}
+
+// isVec reports whether the object is a vector.
+func (h *petscHeader) isVec() bool { return h.class == petscVecClassID }
+
+// isDense reports whether the object is a vector or a matrix in dense
+// format.
+func (h *petscHeader) isDense() bool { return h.isVec() || h.nz == petscDenseFormat }
+
+// readPETScInts reads n big-endian 32-bit integers from r, calling fn with
+// each.
+func readPETScInts(r io.Reader, n int, fn func(k, v int) error) error {
+	return readBlocks(r, n, 4, func(k int, b []byte) error {
+		return fn(k, int(int32(binary.BigEndian.Uint32(b))))
+	})
+}
+
+// readPETScFloats reads n big-endian float64 from r, calling fn with
+// each.
+func readPETScFloats(r io.Reader, n int, fn func(k int, v float64) error) error {
+	return readBlocks(r, n, 8, func(k int, b []byte) error {
+		return fn(k, math.Float64frombits(binary.BigEndian.Uint64(b)))
+	})
+}
+
+// readPETScHeader reads the header of a matrix or vector from r, checking
+// its size against the limits.
+func readPETScHeader(r io.Reader, o *readOptions) (*petscHeader, error) {
+
+	var h petscHeader
+
+	err := readPETScInts(r, 2, func(k, v int) error {
+		switch k {
+		case 0:
+			h.class = v
+		case 1:
+			h.M = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, ErrNotPETSc
+	}
+
+	switch h.class {
+
+	case petscVecClassID:
+		h.N, h.nz = 1, petscDenseFormat
+
+	case petscMatClassID:
+		err := readPETScInts(r, 2, func(k, v int) error {
+			switch k {
+			case 0:
+				h.N = v
+			case 1:
+				h.nz = v
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, ErrNotPETSc
+	}
+
+	if h.M < 0 || h.N < 0 || h.nz < petscDenseFormat {
+		return nil, ErrInvalidSize
+	}
+
+	L := h.nz
+	if h.isDense() {
+
+		var err error
+		if L, err = elements(h.M, h.N); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := o.limits.checkSize(h.M, h.N, L); err != nil {
+		return nil, err
+	}
+
+	if err := o.xform.check(h.M, h.N); err != nil {
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+// readPETScData reads the entries of the matrix or vector of h from r,
+// calling fn with each, in row major order. Zeros of a matrix in sparse
+// (AIJ) format are structural entries, whereas those of a vector or a
+// matrix in dense format are not read.
+func readPETScData(r io.Reader, h *petscHeader, fn func(i, j int, v float64) error) error {
+
+	if h.isDense() {
+		return readPETScFloats(r, h.M*h.N, func(k int, v float64) error {
+			if v == 0 {
+				return nil
+			}
+			return fn(k/h.N, k%h.N, v)
+		})
+	}
+
+	// row lengths, of which the sum is the number of nonzeros, and
+	// column indices are accumulated as read, such that storage is
+	// bounded by the input rather than by the header
+	var (
+		lengths = make([]int, 0, prealloc(h.M))
+		sum     int
+	)
+	err := readPETScInts(r, h.M, func(_, n int) error {
+		if n < 0 || n > h.nz-sum {
+			return fmt.Errorf("%w: row lengths exceed %d nonzeros", ErrInputScanError, h.nz)
+		}
+		sum += n
+		lengths = append(lengths, n)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if sum != h.nz {
+		return fmt.Errorf("%w: row lengths sum to %d of %d nonzeros", ErrInputScanError, sum, h.nz)
+	}
+
+	cols := make([]int, 0, prealloc(h.nz))
+	err = readPETScInts(r, h.nz, func(_, j int) error {
+		if j < 0 || j >= h.N {
+			return ErrIndexOutOfRange
+		}
+		cols = append(cols, j)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// i is the row of the kth nonzero, of which end is the end
+	i, end := -1, 0
+	return readPETScFloats(r, h.nz, func(k int, v float64) error {
+		for k >= end {
+			i++
+			end += lengths[i]
+		}
+		return fn(i, cols[k], v)
+	})
+}
+
+// UnmarshalPETScFrom deserializes a matrix from PETSc binary format, as
+// written by MatView, into the receiver, as configured by opts, and
+// returns the number of bytes read. Matrices in sparse (AIJ) and dense
+// formats are read, with 32-bit indices and real scalars. As r is read no
+// further than the end of the matrix, subsequent objects of the file, such
+// as a right-hand side vector, may be read from r.
+func (m *COO) UnmarshalPETScFrom(r io.Reader, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(o.limits.reader(r), &n)
+
+	h, err := readPETScHeader(r, o)
+	if err != nil {
+		return n.total, err
+	}
+
+	if h.isVec() {
+		return n.total, ErrUnsupportedType
+	}
+
+	c := newTriplets[int, float64](h.nz, o.duplicates, false)
+	c.x = o.xform
+
+	err = readPETScData(r, h, func(i, j int, v float64) error {
+
+		if v == 0 && o.zeros == ZeroDrop {
+			return nil
+		}
+
+		_, err := c.add(i, j, v)
+		return err
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	Mx, Nx := o.xform.dims(h.M, h.N)
+
+	d := sparse.NewCOO(Mx, Nx, c.rows, c.cols, c.data)
+	if o.coo != nil {
+		*o.coo = *d
+		d = o.coo
+	}
+
+	// apply header fields
+	m.Object = mtxObjectMatrix
+	m.Format = mtxFormatCoordinate
+	m.Field = mtxFieldReal
+	m.Symmetry = mtxSymmetryGeneral
+	m.mat = d
+
+	return n.total, nil
+}
+
+// UnmarshalPETScFrom deserializes a matrix or vector from PETSc binary
+// format, as written by MatView or VecView, into the receiver, as
+// configured by opts, and returns the number of bytes read. A vector is
+// read as a column vector, and a matrix in either sparse (AIJ) or dense
+// format is read, with 32-bit indices and real scalars. As r is read no
+// further than the end of the object, subsequent objects of the file may
+// be read from r.
+func (m *Dense) UnmarshalPETScFrom(r io.Reader, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(o.limits.reader(r), &n)
+
+	h, err := readPETScHeader(r, o)
+	if err != nil {
+		return n.total, err
+	}
+
+	// dense storage cannot be allocated for an empty matrix
+	Mx, Nx := o.xform.dims(h.M, h.N)
+	if Mx == 0 || Nx == 0 {
+		return n.total, ErrInvalidSize
+	}
+
+	// as for coordinate data, storage of a matrix in sparse format is
+	// bounded by the number of matrix elements rather than by the number
+	// of nonzeros
+	if !h.isDense() {
+		if err := o.checkDense(h.M, h.N); err != nil {
+			return n.total, err
+		}
+	}
+
+	// entries are read prior to allocating dense storage, such that the
+	// storage is not allocated for the dimensions of a header alone
+	c := newTriplets[int, float64](0, DuplicateSum, false)
+	c.x = o.xform
+
+	err = readPETScData(r, h, func(i, j int, v float64) error {
+		_, err := c.add(i, j, v)
+		return err
+	})
+	if err != nil {
+		return n.total, err
+	}
+
+	d := o.newDense(Mx, Nx)
+
+	// duplicate entries of a matrix in sparse format are summed
+	c.Do(func(i, j int, v float64) {
+		d.Set(i, j, d.At(i, j)+v)
+	})
+
+	*m = *NewDense(d)
+
+	return n.total, nil
+}
+
+// petscWriter writes the big-endian integers and floats of a PETSc binary
+// file, retaining the first error.
+type petscWriter struct {
+	w   *bufio.Writer
+	buf [8]byte
+	err error
+}
+
+// int writes v as a 32-bit integer.
+func (pw *petscWriter) int(v int) {
+	if pw.err == nil {
+		binary.BigEndian.PutUint32(pw.buf[:4], uint32(int32(v)))
+		_, pw.err = pw.w.Write(pw.buf[:4])
+	}
+}
+
+// float writes v as a float64.
+func (pw *petscWriter) float(v float64) {
+	if pw.err == nil {
+		binary.BigEndian.PutUint64(pw.buf[:], math.Float64bits(v))
+		_, pw.err = pw.w.Write(pw.buf[:])
+	}
+}
+
+// writePETSc writes an object to w by fn, and returns the number of bytes
+// written. Objects are of 32-bit indices, such that M, N and L must be
+// representable.
+func writePETSc(w io.Writer, M, N, L int, fn func(pw *petscWriter)) (int, error) {
+
+	var n counter
+
+	if M > math.MaxInt32 || N > math.MaxInt32 || L > math.MaxInt32 {
+		return 0, fmt.Errorf("%w: %d×%d matrix exceeds 32-bit indices", ErrInvalidSize, M, N)
+	}
+
+	pw := petscWriter{w: bufio.NewWriterSize(io.MultiWriter(w, &n), maxScanTokenSize)}
+
+	fn(&pw)
+
+	if pw.err == nil {
+		pw.err = pw.w.Flush()
+	}
+
+	if pw.err != nil {
+		return n.total, ErrUnwritable
+	}
+
+	return n.total, nil
+}
+
+// MarshalPETScTo serializes the receiver to w as a matrix in PETSc binary
+// sparse (AIJ) format, as read by MatLoad, as configured by opts, and
+// returns the number of bytes written. Entries are written in row major
+// order, with duplicates merged.
+func (m *COO) MarshalPETScTo(w io.Writer, opts ...WriteOption) (int, error) {
+
+	o := newWriteOptions(opts)
+
+	c := newTriplets[int, float64](m.mat.NNZ(), o.duplicates, false)
+
+	var err error
+	m.Do(func(i, j int, v float64) {
+		if err == nil {
+			_, err = c.add(i, j, v)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	c.sort(OrderRowMajor)
+
+	if o.zeros == ZeroDrop {
+		c.dropZeros()
+	}
+
+	M, N := m.mat.Dims()
+	L := len(c.data)
+
+	lengths := make([]int, M)
+	for _, i := range c.rows {
+		lengths[i]++
+	}
+
+	return writePETSc(w, M, N, L, func(pw *petscWriter) {
+
+		pw.int(petscMatClassID)
+		pw.int(M)
+		pw.int(N)
+		pw.int(L)
+
+		for _, n := range lengths {
+			pw.int(n)
+		}
+
+		for _, j := range c.cols {
+			pw.int(j)
+		}
+
+		for _, v := range c.data {
+			pw.float(v)
+		}
+	})
+}
+
+// MarshalPETScTo serializes the receiver to w in PETSc binary format, as
+// configured by opts, and returns the number of bytes written. A column
+// vector is written as a vector, as read by VecLoad, and any other matrix
+// is written as a matrix in dense format, as read by MatLoad.
+func (m *Dense) MarshalPETScTo(w io.Writer, opts ...WriteOption) (int, error) {
+
+	M, N := m.mat.Dims()
+
+	L, err := elements(M, N)
+	if err != nil {
+		return 0, err
+	}
+
+	return writePETSc(w, M, N, L, func(pw *petscWriter) {
+
+		if N == 1 {
+			pw.int(petscVecClassID)
+			pw.int(M)
+		} else {
+			pw.int(petscMatClassID)
+			pw.int(M)
+			pw.int(N)
+			pw.int(petscDenseFormat)
+		}
+
+		// elements are in row major order
+		for i := 0; i < M; i++ {
+			for j := 0; j < N; j++ {
+				pw.float(m.mat.At(i, j))
+			}
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package market
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// petscFile returns the big-endian integers and then floats of a PETSc
+// binary object.
+func petscFile(ints []int32, floats []float64) []byte {
+
+	var b []byte
+	for _, v := range ints {
+		b = binary.BigEndian.AppendUint32(b, uint32(v))
+	}
+	for _, v := range floats {
+		b = binary.BigEndian.AppendUint64(b, math.Float64bits(v))
+	}
+
+	return b
+}
+
+// petsc01 is the 2×3 matrix [1 0 2; 0 3 0] in sparse (AIJ) format,
+// followed by the vector [1 2].
+var petsc01 = append(
+	petscFile([]int32{petscMatClassID, 2, 3, 3, 2, 1, 0, 2, 1}, []float64{1, 2, 3}),
+	petscFile([]int32{petscVecClassID, 2}, []float64{1, 2})...,
+)
+
+func TestCOOUnmarshalPETScFrom(t *testing.T) {
+
+	r := bytes.NewReader(petsc01)
+
+	var m COO
+
+	n, err := m.UnmarshalPETScFrom(r)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 4*9+8*3, n)
+	assert.True(t, mat.Equal(mat.NewDense(2, 3, []float64{1, 0, 2, 0, 3, 0}), m.ToCOO()))
+
+	// the vector following the matrix is read from the same reader
+	var v Dense
+
+	_, err = v.UnmarshalPETScFrom(r)
+	if assert.NoError(t, err) {
+		assert.True(t, mat.Equal(mat.NewDense(2, 1, []float64{1, 2}), v.ToDense()))
+	}
+
+	// vectors are not read as sparse matrices
+	_, err = m.UnmarshalPETScFrom(bytes.NewReader(petsc01[4*9+8*3:]))
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+func TestCOOUnmarshalPETScFromErrors(t *testing.T) {
+
+	for _, test := range []struct {
+		name string
+		file []byte
+		err  error
+	}{
+		{"class", petscFile([]int32{1211215, 2, 3, 3}, nil), ErrNotPETSc},
+		{"text", []byte("%%MatrixMarket"), ErrNotPETSc},
+		{"size", petscFile([]int32{petscMatClassID, -2, 3, 3}, nil), ErrInvalidSize},
+		{"lengths", petscFile([]int32{petscMatClassID, 2, 3, 3, 2, 2, 0, 2, 1, 1}, []float64{1, 2, 3}), ErrInputScanError},
+		{"short", petscFile([]int32{petscMatClassID, 2, 3, 3, 1, 1, 0, 2}, []float64{1, 2, 3}), ErrInputScanError},
+		{"index", petscFile([]int32{petscMatClassID, 2, 3, 3, 2, 1, 0, 3, 1}, []float64{1, 2, 3}), ErrIndexOutOfRange},
+		{"truncated", petsc01[:4*9+8*2], ErrTruncated},
+		{"hostile", petscFile([]int32{petscMatClassID, 1, 1, math.MaxInt32, math.MaxInt32}, nil), ErrTruncated},
+	} {
+		var m COO
+
+		_, err := m.UnmarshalPETScFrom(bytes.NewReader(test.file))
+		assert.ErrorIs(t, err, test.err, test.name)
+	}
+
+	// a header alone does not allocate dense storage
+	var d Dense
+
+	_, err := d.UnmarshalPETScFrom(bytes.NewReader(petscFile([]int32{petscMatClassID, 100000000, 100000000, petscDenseFormat}, nil)))
+	assert.ErrorIs(t, err, ErrTruncated)
+
+	_, err = d.UnmarshalPETScFrom(bytes.NewReader(petscFile([]int32{petscMatClassID, 100000000, 100000000, 1}, nil)))
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	var m COO
+
+	_, err = m.UnmarshalPETScFrom(bytes.NewReader(petsc01), WithLimits(Limits{MaxEntries: 2}))
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+}
+
+func TestCOOMarshalPETScTo(t *testing.T) {
+
+	var b bytes.Buffer
+
+	// entries are written in row major order, with duplicates merged
+	c := sparse.NewCOO(2, 3, []int{1, 0, 0, 1}, []int{1, 2, 0, 1}, []float64{1, 2, 1, 2})
+
+	n, err := NewCOO(c).MarshalPETScTo(&b)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, b.Len(), n)
+	assert.Equal(t, petsc01[:4*9+8*3], b.Bytes())
+
+	var m COO
+
+	b.Reset()
+	_, err = NewCOO(mtx01).MarshalPETScTo(&b)
+	if assert.NoError(t, err) {
+		_, err = m.UnmarshalPETScFrom(&b)
+		assert.NoError(t, err)
+		assert.True(t, mat.Equal(mtx01, m.ToCOO()))
+	}
+
+	_, err = NewCOO(mtx01).MarshalPETScTo(failWriter{})
+	assert.ErrorIs(t, err, ErrUnwritable)
+}
+
+func TestDensePETSc(t *testing.T) {
+
+	for _, d := range []*mat.Dense{
+		mat.NewDense(2, 3, []float64{1, 0, -2, 4e-300, 5, 6}),
+		mat.NewDense(3, 1, []float64{1, 0, 2}),
+	} {
+
+		var b bytes.Buffer
+
+		_, err := NewDense(d).MarshalPETScTo(&b)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		// column vectors are written as vectors
+		class := int32(binary.BigEndian.Uint32(b.Bytes()))
+		if _, N := d.Dims(); N == 1 {
+			assert.Equal(t, int32(petscVecClassID), class)
+		} else {
+			assert.Equal(t, int32(petscMatClassID), class)
+		}
+
+		var m Dense
+
+		_, err = m.UnmarshalPETScFrom(&b)
+		if assert.NoError(t, err) {
+			assert.True(t, mat.Equal(d, m.ToDense()))
+		}
+	}
+
+	// matrices in sparse format are read into dense storage
+	var m Dense
+
+	_, err := m.UnmarshalPETScFrom(bytes.NewReader(petsc01))
+	if assert.NoError(t, err) {
+		assert.True(t, mat.Equal(mat.NewDense(2, 3, []float64{1, 0, 2, 0, 3, 0}), m.ToDense()))
+	}
+}
@@ -0,0 +1,292 @@
+package market
+
+import (
+	"io"
+	"iter"
+)
+
+// Reader streams the entries of a Matrix Market file, such that a matrix
+// may be processed without being held in memory. The header and size line
+// are read by NewReader, and the entries of the data section are read as
+// they are iterated by All or Stored. As entries are not held, duplicate
+// entries are yielded as read, irrespective of any DuplicatePolicy.
+//
+// The entries may be iterated only once. Errors encountered while
+// iterating stop the iteration, and are reported by Err.
+type Reader[T Scalar] struct {
+	Object   string
+	Format   string
+	Field    string
+	Symmetry string
+
+	scanner *lineScanner
+	t       *mmType
+	o       *readOptions
+	x       *transform // applied to each entry as yielded
+	m, n, l int
+	k       int // number of entries read
+	i, j    int // (zero-indexed) position of the next entry of array data
+	err     error
+}
+
+// NewReader reads the header and size line of the Matrix Market file read
+// from r, as configured by opts, and returns a Reader over its entries.
+// The field of the file must be complex if T is complex, or otherwise
+// real, integer or pattern.
+func NewReader[T Scalar](r io.Reader, opts ...ReadOption) (*Reader[T], error) {
+
+	o := newReadOptions(opts)
+
+	scanner := newScanner(r, o)
+
+	// read header
+	t, err := scanHeader(scanner, o)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasField[T](t) {
+		return nil, ErrUnsupportedType
+	}
+
+	M, N, L, err := scanSize(scanner, t, o)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.isArray() {
+		L = storedEntries(t.Symmetry, M, N)
+	}
+
+	rd := Reader[T]{
+		Object:   t.Object,
+		Format:   t.Format,
+		Field:    t.Field,
+		Symmetry: o.xform.symmetry(t.Symmetry),
+		scanner:  scanner,
+		t:        t,
+		o:        o,
+		x:        o.xform,
+		m:        M,
+		n:        N,
+		l:        L,
+	}
+
+	if t.isPattern() {
+		rd.x = o.xform.indices()
+	}
+
+	// the strictly lower triangle of a skew-symmetric matrix is stored
+	if t.isSkew() {
+		rd.i = 1
+	}
+
+	return &rd, nil
+}
+
+// Dims returns the number of rows and columns of the matrix, as
+// transformed by any selection or transform options.
+func (r *Reader[T]) Dims() (int, int) { return r.x.dims(r.m, r.n) }
+
+// Len returns the number of entries in the data section of the file.
+func (r *Reader[T]) Len() int { return r.l }
+
+// Err returns the first error encountered while iterating, if any. If
+// reading with WithPartial and the input is truncated, then Err returns a
+// *TruncatedError once the entries read have been yielded.
+func (r *Reader[T]) Err() error { return r.err }
+
+// Stored returns an iterator over the entries of the data section, as
+// stored in the file. For array data, entries are yielded in column major
+// order, including any zeros. Entries are yielded as transformed by any
+// selection or transform options.
+func (r *Reader[T]) Stored() iter.Seq2[Index, T] {
+	return func(yield func(Index, T) bool) {
+		for {
+			k, v, ok := r.next()
+			if !ok || !r.emit(yield, k, v) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the entries of the matrix, yielding both
+// an entry and its transpose for symmetric, skew-symmetric and hermitian
+// matrices. Entries are yielded as transformed by any selection or
+// transform options.
+func (r *Reader[T]) All() iter.Seq2[Index, T] {
+	return func(yield func(Index, T) bool) {
+		for {
+
+			k, v, ok := r.next()
+			if !ok || !r.emit(yield, k, v) {
+				return
+			}
+
+			if k.Row != k.Col && !r.t.isGeneral() {
+				if !r.emit(yield, Index{k.Col, k.Row}, transpose(r.t.Symmetry, v)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// emit yields the entry v at k, as transformed, reporting false if the
+// iteration is stopped.
+func (r *Reader[T]) emit(yield func(Index, T) bool, k Index, v T) bool {
+
+	i, j, v, ok := apply(r.x, k.Row, k.Col, v)
+	if !ok {
+		return true
+	}
+
+	return yield(Index{i, j}, v)
+}
+
+// next reads the next entry of the data section, reporting false once
+// the data section is exhausted or an error is encountered.
+func (r *Reader[T]) next() (Index, T, bool) {
+
+	for r.err == nil {
+
+		if r.k == r.l {
+			// error out if data exceed the expected number of entries
+			r.err = r.scanner.end()
+			if r.err == nil {
+				r.err = scanError(r.scanner.Scanner)
+			}
+			break
+		}
+
+		var (
+			k   Index
+			v   T
+			err error
+		)
+
+		if r.t.isCoordinate() {
+			k, v, err = r.scanCoordinateEntry()
+		} else {
+			k, v, err = r.scanArrayEntry()
+		}
+
+		if trunc := r.scanner.truncation(r.o, err, r.k, r.l); trunc != nil {
+			r.err = trunc
+			break
+		}
+
+		if err != nil {
+			r.err = err
+			break
+		}
+
+		r.k++
+
+		if r.t.isCoordinate() && v == 0 && r.o.zeros == ZeroDrop {
+			continue
+		}
+
+		return k, v, true
+	}
+
+	return Index{}, 0, false
+}
+
+// scanCoordinateEntry reads the next entry of coordinate data.
+func (r *Reader[T]) scanCoordinateEntry() (Index, T, error) {
+
+	var (
+		i, j int
+		v    T = 1
+	)
+
+	// entries are i, j and v, excepting pattern entries, which have no v
+	// and complex entries, which have both a real and imaginary part
+	n := 3
+	switch {
+	case r.t.isPattern():
+		n = 2
+	case r.t.isComplex():
+		n = 4
+	}
+
+	toks, line, err := r.scanner.entry(n)
+	if err != nil {
+		return Index{}, 0, err
+	}
+
+	if i, err = parseInt(toks[0]); err != nil {
+		return Index{}, 0, r.scanner.errorf(line, err)
+	}
+
+	if j, err = parseInt(toks[1]); err != nil {
+		return Index{}, 0, r.scanner.errorf(line, err)
+	}
+
+	if n > 2 {
+		if v, err = parseScalar[T](toks[2:]); err != nil {
+			return Index{}, 0, r.scanner.errorf(line, err)
+		}
+	}
+
+	if !inBounds(i, j, r.m, r.n) {
+		return Index{}, 0, r.scanner.errorf(line, ErrIndexOutOfRange)
+	}
+
+	if i < j && !r.t.isGeneral() {
+		r.o.warnf(line, WarnUpperTriangle, "entry (%d, %d) is above the diagonal of a %s matrix", i, j, r.t.Symmetry)
+	}
+
+	if v == 0 {
+		r.o.warnf(line, WarnExplicitZero, "explicit zero at (%d, %d)", i, j)
+	}
+
+	if i == j && r.t.isHermitian() && imag(widen(v)) != 0 {
+		r.o.warnf(line, WarnHermitianDiagonal, "diagonal entry (%d, %d) of a hermitian matrix is not real", i, j)
+	}
+
+	return Index{i - 1, j - 1}, v, nil
+}
+
+// scanArrayEntry reads the next entry of array data.
+func (r *Reader[T]) scanArrayEntry() (Index, T, error) {
+
+	// entries are v, excepting complex entries, which have both a real and
+	// imaginary part
+	n := 1
+	if r.t.isComplex() {
+		n = 2
+	}
+
+	toks, line, err := r.scanner.entry(n)
+	if err != nil {
+		return Index{}, 0, err
+	}
+
+	v, err := parseScalar[T](toks)
+	if err != nil {
+		return Index{}, 0, r.scanner.errorf(line, err)
+	}
+
+	k := Index{r.i, r.j}
+
+	if k.Row == k.Col && r.t.isHermitian() && imag(widen(v)) != 0 {
+		r.o.warnf(line, WarnHermitianDiagonal, "diagonal entry (%d, %d) of a hermitian matrix is not real", k.Row+1, k.Col+1)
+	}
+
+	// advance to the next stored position, in column major order
+	if r.i++; r.i == r.m {
+		r.j++
+		r.i = 0
+		if !r.t.isGeneral() {
+			r.i = r.j
+		}
+		if r.t.isSkew() {
+			r.i++
+		}
+	}
+
+	return k, v, nil
+}
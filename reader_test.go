@@ -0,0 +1,179 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestReaderAll(t *testing.T) {
+
+	// real, integer and pattern fields
+	for _, k := range []int{1, 2, 3, 4, 5, 6, 10, 11, 12, 13, 14, 15, 21, 22} {
+
+		name := fmt.Sprintf("mmtype-%02d.mtx", k)
+
+		b, err := os.ReadFile(filepath.Join("testdata", name))
+		assert.Nil(t, err)
+
+		var want mat.Matrix
+		if k < 10 || k > 20 {
+			var m COO
+			assert.Nil(t, m.UnmarshalText(b), name)
+			want = m.ToMatrix()
+		} else {
+			var m Dense
+			assert.Nil(t, m.UnmarshalText(b), name)
+			want = m.ToMatrix()
+		}
+
+		r, err := NewReader[float64](strings.NewReader(string(b)))
+		assert.Nil(t, err, name)
+
+		M, N := r.Dims()
+		got := mat.NewDense(M, N, nil)
+		for k, v := range r.All() {
+			got.Set(k.Row, k.Col, got.At(k.Row, k.Col)+v)
+		}
+
+		assert.Nil(t, r.Err(), name)
+		assert.True(t, mat.Equal(got, want), name)
+	}
+
+	// complex field
+	for _, k := range []int{7, 8, 9, 16, 17, 18, 19, 20} {
+
+		name := fmt.Sprintf("mmtype-%02d.mtx", k)
+
+		b, err := os.ReadFile(filepath.Join("testdata", name))
+		assert.Nil(t, err)
+
+		var want CDense
+		assert.Nil(t, want.UnmarshalText(b), name)
+
+		r, err := NewReader[complex128](strings.NewReader(string(b)))
+		assert.Nil(t, err, name)
+
+		M, N := r.Dims()
+		got := mat.NewCDense(M, N, nil)
+		for k, v := range r.All() {
+			got.Set(k.Row, k.Col, got.At(k.Row, k.Col)+v)
+		}
+
+		assert.Nil(t, r.Err(), name)
+		assert.True(t, mat.CEqual(got, want.ToCMatrix()), name)
+	}
+}
+
+func TestReaderStored(t *testing.T) {
+
+	b, err := os.ReadFile(filepath.Join("testdata", "mmtype-12.mtx"))
+	assert.Nil(t, err)
+
+	r, err := NewReader[float32](strings.NewReader(string(b)))
+	assert.Nil(t, err)
+	assert.Equal(t, "skew-symmetric", r.Symmetry)
+	assert.Equal(t, 10, r.Len())
+
+	var got []Index
+	for k := range r.Stored() {
+		got = append(got, k)
+	}
+
+	assert.Nil(t, r.Err())
+	assert.Equal(t, []Index{
+		{1, 0}, {2, 0}, {3, 0}, {4, 0},
+		{2, 1}, {3, 1}, {4, 1},
+		{3, 2}, {4, 2},
+		{4, 3},
+	}, got)
+}
+
+func TestReaderBreak(t *testing.T) {
+
+	r, err := NewReader[float64](strings.NewReader(iterSkewText))
+	assert.Nil(t, err)
+
+	for k, v := range r.All() {
+		assert.Equal(t, Index{1, 0}, k)
+		assert.Equal(t, 1.0, v)
+		break
+	}
+
+	// iteration resumes with the next entry of the data section
+	var n int
+	for range r.Stored() {
+		n++
+	}
+	assert.Equal(t, 1, n)
+	assert.Nil(t, r.Err())
+}
+
+func TestReaderErrors(t *testing.T) {
+
+	// the field must match the element type
+	_, err := NewReader[complex128](strings.NewReader(iterSkewText))
+	assert.Equal(t, ErrUnsupportedType, err)
+
+	// data section errors are reported by Err
+	r, err := NewReader[float64](strings.NewReader(`%%MatrixMarket matrix coordinate real general
+ 2  2  2
+ 1  1  1
+ 3  1  1
+`))
+	assert.Nil(t, err)
+
+	var n int
+	for range r.All() {
+		n++
+	}
+	assert.Equal(t, 1, n)
+	assert.True(t, errors.Is(r.Err(), ErrIndexOutOfRange))
+
+	// truncated input
+	text := `%%MatrixMarket matrix coordinate real general
+ 2  2  3
+ 1  1  1
+ 2  2  1
+`
+	r, err = NewReader[float64](strings.NewReader(text))
+	assert.Nil(t, err)
+	for range r.All() {
+	}
+	assert.NotNil(t, r.Err())
+
+	r, err = NewReader[float64](strings.NewReader(text), WithPartial())
+	assert.Nil(t, err)
+	n = 0
+	for range r.All() {
+		n++
+	}
+	assert.Equal(t, 2, n)
+
+	var trunc *TruncatedError
+	assert.True(t, errors.As(r.Err(), &trunc))
+	assert.Equal(t, 2, trunc.Actual)
+}
+
+func TestReaderZeroDrop(t *testing.T) {
+
+	r, err := NewReader[float64](strings.NewReader(`%%MatrixMarket matrix coordinate real general
+ 2  2  2
+ 1  1  0
+ 2  2  1
+`), WithZeros(ZeroDrop))
+	assert.Nil(t, err)
+
+	var got []Index
+	for k := range r.All() {
+		got = append(got, k)
+	}
+	assert.Equal(t, []Index{{1, 1}}, got)
+	assert.Nil(t, r.Err())
+}
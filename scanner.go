@@ -0,0 +1,210 @@
+package market
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ParseError reports the line of the input at which reading a matrix
+// failed.
+type ParseError struct {
+	Line int   // one-indexed line number
+	Err  error // underlying error, such as ErrInputScanError
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// TruncatedError reports input which ends before all entries of the
+// data section were read. It is returned only when reading with
+// WithPartial, in which case the entries read are retained.
+type TruncatedError struct {
+	Line     int // one-indexed number of the last line
+	Expected int // number of entries specified by the size line
+	Actual   int // number of entries read
+}
+
+// Error implements the error interface.
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("line %d: %v: read %d of %d entries", e.Line, ErrTruncated, e.Actual, e.Expected)
+}
+
+// Unwrap returns ErrTruncated.
+func (e *TruncatedError) Unwrap() error { return ErrTruncated }
+
+// lineScanner is a bufio.Scanner which counts the lines it has scanned
+// and which splits the data section of a Matrix Market file into a
+// stream of whitespace-separated tokens.
+type lineScanner struct {
+	*bufio.Scanner
+	line   int      // line number of the most recently scanned line
+	fields []string // pending tokens
+	lines  []int    // line number of each pending token
+	pos    int      // position of the next pending token
+	eof    bool     // whether the input ended without error
+}
+
+// Scan advances to the next line.
+func (s *lineScanner) Scan() bool {
+
+	if !s.Scanner.Scan() {
+		s.eof = s.Err() == nil
+		return false
+	}
+
+	s.line++
+
+	return true
+}
+
+// errorf returns a ParseError for line, wrapping err.
+func (s *lineScanner) errorf(line int, err error) error {
+	return &ParseError{Line: line, Err: err}
+}
+
+// tokens returns the next n tokens of the data section and the line
+// number at which the first of them appears. The free format of the
+// Matrix Market specification allows that an entry may span lines, or
+// that a line may contain several entries, although typically a line
+// holds exactly one entry. Blank lines are skipped. If the data are
+// exhausted prior to reading any token then io.EOF is returned.
+func (s *lineScanner) tokens(n int) ([]string, int, error) {
+
+	for len(s.fields)-s.pos < n {
+
+		if !s.Scan() {
+
+			if err := scanError(s.Scanner); err != nil {
+				return nil, s.line, err
+			}
+
+			if len(s.fields) == s.pos {
+				return nil, s.line, io.EOF
+			}
+
+			return nil, s.lines[s.pos], s.errorf(s.line, fmt.Errorf("%w: incomplete entry", ErrInputScanError))
+		}
+
+		// discard consumed tokens, retaining any pending
+		k := copy(s.fields, s.fields[s.pos:])
+		copy(s.lines, s.lines[s.pos:])
+		s.fields, s.lines, s.pos = s.fields[:k], s.lines[:k], 0
+
+		s.fields = appendFields(s.fields, s.Text())
+		for len(s.lines) < len(s.fields) {
+			s.lines = append(s.lines, s.line)
+		}
+	}
+
+	toks, line := s.fields[s.pos:s.pos+n], s.lines[s.pos]
+	s.pos += n
+
+	return toks, line, nil
+}
+
+// entry returns the n tokens of the next entry of the data section and
+// the line number at which it begins, reporting an error if the data are
+// exhausted.
+func (s *lineScanner) entry(n int) ([]string, int, error) {
+
+	toks, line, err := s.tokens(n)
+	if err == io.EOF {
+		return nil, line, s.errorf(line, fmt.Errorf("%w: fewer entries than expected", ErrInputScanError))
+	}
+
+	return toks, line, err
+}
+
+// truncation returns a TruncatedError if o permits partial data and err
+// arises from the input ending after k of L entries, or nil otherwise.
+// An entry incomplete at the end of the input is not counted.
+func (s *lineScanner) truncation(o *readOptions, err error, k, L int) *TruncatedError {
+
+	if err == nil || !o.partial || !s.eof {
+		return nil
+	}
+
+	return &TruncatedError{Line: s.line, Expected: L, Actual: k}
+}
+
+// end reports an error if the data section holds any further tokens.
+func (s *lineScanner) end() error {
+
+	switch _, line, err := s.tokens(1); err {
+
+	case io.EOF:
+		return nil
+
+	case nil:
+		return s.errorf(line, fmt.Errorf("%w: more entries than expected", ErrInputScanError))
+
+	default:
+		return err
+	}
+}
+
+// skipLine discards any pending tokens, such that the next entry begins
+// on a new line. This resynchronizes the token stream after a malformed
+// entry, given that typically a line holds exactly one entry.
+func (s *lineScanner) skipLine() {
+	s.pos = len(s.fields)
+}
+
+// appendFields appends the whitespace-separated fields of line to dst.
+func appendFields(dst []string, line string) []string {
+
+	start := -1
+
+	for i := 0; i < len(line); i++ {
+
+		switch line[i] {
+
+		case ' ', '\t', '\r', '\v', '\f':
+			if start >= 0 {
+				dst = append(dst, line[start:i])
+				start = -1
+			}
+
+		default:
+			if start < 0 {
+				start = i
+			}
+		}
+	}
+
+	if start >= 0 {
+		dst = append(dst, line[start:])
+	}
+
+	return dst
+}
+
+// parseInt parses an integer, such as a size or a one-indexed row or
+// column index.
+func parseInt(tok string) (int, error) {
+
+	i, err := strconv.Atoi(tok)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid integer %q", ErrInputScanError, tok)
+	}
+
+	return i, nil
+}
+
+// parseFloat parses a real value, or either part of a complex value.
+func parseFloat(tok string) (float64, error) {
+
+	v, err := strconv.ParseFloat(tok, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid number %q", ErrInputScanError, tok)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,122 @@
+package market
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLineScannerTokens(t *testing.T) {
+
+	s := sts("1 2 3\n\n4\n5 6\n")
+
+	toks, line, err := s.tokens(2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2"}, toks)
+	assert.Equal(t, 1, line)
+
+	toks, line, err = s.tokens(2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"3", "4"}, toks)
+	assert.Equal(t, 1, line)
+
+	toks, line, err = s.tokens(2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"5", "6"}, toks)
+	assert.Equal(t, 4, line)
+
+	_, _, err = s.tokens(2)
+	assert.Equal(t, io.EOF, err)
+
+	// incomplete entry
+	s = sts("1 2\n3\n")
+
+	_, _, err = s.tokens(2)
+	assert.Nil(t, err)
+
+	_, _, err = s.tokens(2)
+	assert.True(t, errors.Is(err, ErrInputScanError))
+}
+
+func TestAppendFields(t *testing.T) {
+
+	assert.Equal(t, []string(nil), appendFields(nil, ""))
+	assert.Equal(t, []string(nil), appendFields(nil, " \t\r"))
+	assert.Equal(t, []string{"1", "-2.5e3", "x"}, appendFields(nil, "\t1  -2.5e3 x\r"))
+}
+
+func TestUnmarshalTextFromFreeFormat(t *testing.T) {
+
+	// Fortran-style array with several values per line
+	text := `%%MatrixMarket matrix array real general
+% values in column major order
+4 5
+ 0.9448533463379065 -0.681501551465435 0 0.4026962903538138
+ 0.32860106770453723 0.8120799665624883 0 0.7565364621388195
+ 0.011573183932084063 -0.5512711550785843 0.20755267526021282 0.7959819937038737
+-0.24204866731983699 0 0.8856137343734234 0.4193710271772375
+ 0.8856137343734234 0 0.6694215255530185 0.3938367041885751
+`
+
+	var d Dense
+	_, err := d.UnmarshalTextFrom(strings.NewReader(text))
+	assert.Nil(t, err)
+
+	M, N := d.ToDense().Dims()
+	assert.Equal(t, 4, M)
+	assert.Equal(t, 5, N)
+	assert.Equal(t, -0.681501551465435, d.ToDense().At(1, 0))
+	assert.Equal(t, 0.3938367041885751, d.ToDense().At(3, 4))
+
+	// coordinate entries split across lines
+	text = `%%MatrixMarket matrix coordinate real general
+3 3 3
+1 1
+0.9448533463379065 2 2 0.8975666640458155
+3
+3 0.4026962903538138
+`
+
+	var c COO
+	_, err = c.UnmarshalTextFrom(strings.NewReader(text))
+	assert.Nil(t, err)
+	assert.True(t, mat.Equal(c.ToMatrix(), mat.NewDiagDense(3, []float64{
+		0.9448533463379065,
+		0.8975666640458155,
+		0.4026962903538138,
+	})))
+
+	// complex array with several values per line
+	text = "%%MatrixMarket matrix array complex general\n2 1\n1 2 3 4\n"
+
+	var cd CDense
+	_, err = cd.UnmarshalTextFrom(strings.NewReader(text))
+	assert.Nil(t, err)
+	assert.True(t, mat.CEqual(cd.ToCMatrix(), mat.NewCDense(2, 1, []complex128{1 + 2i, 3 + 4i})))
+}
+
+func TestUnmarshalTextFromLineNumbers(t *testing.T) {
+
+	c := map[string]string{
+		"%%MatrixMarket matrix coordinate real general\n%\n2 2 2\n1 1 1\n\n2 x 1\n":     "line 6: error while scanning matrix input: invalid integer \"x\"",
+		"%%MatrixMarket matrix coordinate real general\n%\n2 2 2\n1 1 1\n3 1 1\n":       "line 5: entry index outside matrix dimensions",
+		"%%MatrixMarket matrix coordinate real general\n%\n2 2 2\n1 1 1\n":              "line 4: error while scanning matrix input: fewer entries than expected",
+		"%%MatrixMarket matrix coordinate real general\n%\n2 2 1\n1 1 1\n2 2 2\n":       "line 5: error while scanning matrix input: more entries than expected",
+		"%%MatrixMarket matrix coordinate real general\n%\n2 2 2\n1 1 1 2\n2\n":         "line 5: error while scanning matrix input: incomplete entry",
+		"%%MatrixMarket matrix coordinate real general\n%\n% comment\n2 two 2\n1 1 1\n": "line 4: error while scanning matrix input: invalid integer \"two\"",
+	}
+
+	for text, want := range c {
+
+		var m COO
+		_, err := m.UnmarshalTextFrom(strings.NewReader(text))
+		assert.EqualError(t, err, want)
+
+		var pe *ParseError
+		assert.True(t, errors.As(err, &pe))
+	}
+}
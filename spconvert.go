@@ -0,0 +1,334 @@
+package market
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/james-bowman/sparse"
+)
+
+// spconvertEntry is a (zero-indexed) entry of a spconvert triplet file.
+type spconvertEntry struct {
+	i, j int
+	v    complex128
+}
+
+// parseSpconvertIndex parses a one-indexed row or column index, which is
+// written as a real number by save -ascii.
+func parseSpconvertIndex(tok string) (int, error) {
+
+	x, err := parseFloat(tok)
+	if err != nil {
+		return 0, err
+	}
+
+	if x != math.Trunc(x) || x > math.MaxInt32 {
+		return 0, fmt.Errorf("%w: invalid index %q", ErrInputScanError, tok)
+	}
+
+	if x < 1 {
+		return 0, ErrIndexOutOfRange
+	}
+
+	return int(x), nil
+}
+
+// scanSpconvert scans the lines of a spconvert triplet file, of three
+// columns (i, j and a real value) or of four columns (i, j and the real
+// and imaginary parts of a complex value), and returns its entries and
+// size. The size is that of the greatest indices. Blank lines and
+// comments, which begin with %, are skipped. Entries of zero value, such
+// as that conventionally giving the size, are not returned.
+func scanSpconvert(scanner *lineScanner, o *readOptions) ([]spconvertEntry, int, int, bool, error) {
+
+	var (
+		entries []spconvertEntry
+		fields  []string
+		columns int
+		M, N    int
+		L       int
+	)
+
+	for scanner.Scan() {
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '%' {
+			continue
+		}
+
+		fields = appendFields(fields[:0], line)
+
+		if columns == 0 {
+			columns = len(fields)
+			if columns != 3 && columns != 4 {
+				return nil, 0, 0, false, scanner.errorf(scanner.line, fmt.Errorf("%w: %d columns", ErrInputScanError, columns))
+			}
+		}
+
+		if len(fields) != columns {
+			return nil, 0, 0, false, scanner.errorf(scanner.line, fmt.Errorf("%w: %d of %d columns", ErrInputScanError, len(fields), columns))
+		}
+
+		i, err := parseSpconvertIndex(fields[0])
+		if err != nil {
+			return nil, 0, 0, false, scanner.errorf(scanner.line, err)
+		}
+
+		j, err := parseSpconvertIndex(fields[1])
+		if err != nil {
+			return nil, 0, 0, false, scanner.errorf(scanner.line, err)
+		}
+
+		var v complex128
+		for k, tok := range fields[2:] {
+
+			x, err := parseFloat(tok)
+			if err != nil {
+				return nil, 0, 0, false, scanner.errorf(scanner.line, err)
+			}
+
+			if k == 0 {
+				v = complex(x, 0)
+			} else {
+				v = complex(real(v), x)
+			}
+		}
+
+		M, N = max(M, i), max(N, j)
+
+		if v != 0 {
+			L++
+		}
+
+		if err := o.limits.checkSize(M, N, L); err != nil {
+			return nil, 0, 0, false, scanner.errorf(scanner.line, err)
+		}
+
+		if v != 0 {
+			entries = append(entries, spconvertEntry{i - 1, j - 1, v})
+		}
+	}
+
+	if err := scanError(scanner.Scanner); err != nil {
+		return nil, 0, 0, false, err
+	}
+
+	if err := o.xform.check(M, N); err != nil {
+		return nil, 0, 0, false, err
+	}
+
+	return entries, M, N, columns == 4, nil
+}
+
+// UnmarshalSpconvertFrom deserializes a sparse matrix from a triplet file
+// of three columns, as read by the MATLAB function spconvert, into the
+// receiver, as configured by opts, and returns the number of bytes read.
+// Each line holds the one-indexed row and column and the value of an
+// entry, and the size of the matrix is that of the greatest indices,
+// such that a final line "M N 0" sets the size. Entries of zero value
+// are not stored.
+func (m *COO) UnmarshalSpconvertFrom(r io.Reader, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	entries, M, N, cplx, err := scanSpconvert(newScanner(r, o), o)
+	if err != nil {
+		return n.total, err
+	}
+
+	if cplx {
+		return n.total, ErrUnsupportedType
+	}
+
+	c := newTriplets[int, float64](len(entries), o.duplicates, false)
+	c.x = o.xform
+
+	for _, e := range entries {
+		if _, err := c.add(e.i, e.j, real(e.v)); err != nil {
+			return n.total, err
+		}
+	}
+
+	Mx, Nx := o.xform.dims(M, N)
+	d := sparse.NewCOO(Mx, Nx, c.rows, c.cols, c.data)
+	if o.coo != nil {
+		*o.coo = *d
+		d = o.coo
+	}
+
+	// apply header fields
+	m.Object = mtxObjectMatrix
+	m.Format = mtxFormatCoordinate
+	m.Field = mtxFieldReal
+	m.Symmetry = mtxSymmetryGeneral
+	m.mat = d
+
+	return n.total, nil
+}
+
+// UnmarshalSpconvertFrom deserializes a complex matrix from a triplet file
+// of four columns, as read by the MATLAB function spconvert, into the
+// receiver, as configured by opts, and returns the number of bytes read.
+// Each line holds the one-indexed row and column and the real and
+// imaginary parts of an entry, and the size of the matrix is that of the
+// greatest indices. A file of three columns is read as of real values.
+func (m *CDense) UnmarshalSpconvertFrom(r io.Reader, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	entries, M, N, _, err := scanSpconvert(newScanner(r, o), o)
+	if err != nil {
+		return n.total, err
+	}
+
+	// dense storage cannot be allocated for an empty matrix
+	Mx, Nx := o.xform.dims(M, N)
+	if Mx == 0 || Nx == 0 {
+		return n.total, ErrInvalidSize
+	}
+
+	L, err := elements(M, N)
+	if err != nil {
+		return n.total, err
+	}
+
+	if err := o.limits.checkSize(M, N, L); err != nil {
+		return n.total, err
+	}
+
+	c := newCDenseEntries(Mx, Nx, o.duplicates, false)
+	c.x = o.xform
+
+	for _, e := range entries {
+		if _, err := c.add(e.i, e.j, e.v); err != nil {
+			return n.total, err
+		}
+	}
+
+	d, err := c.dense(o)
+	if err != nil {
+		return n.total, err
+	}
+
+	*m = *NewCDense(d)
+
+	return n.total, nil
+}
+
+// writeSpconvert writes the lines written by data to w, and returns the
+// number of bytes written.
+func writeSpconvert(w io.Writer, data func(bw *bufio.Writer)) (int, error) {
+
+	var n counter
+
+	bw := bufio.NewWriterSize(io.MultiWriter(w, &n), maxScanTokenSize)
+
+	data(bw)
+
+	if err := bw.Flush(); err != nil {
+		return n.total, ErrUnwritable
+	}
+
+	return n.total, nil
+}
+
+// MarshalSpconvertTo serializes the receiver to w as a triplet file of
+// three columns, as read by the MATLAB function spconvert, as configured
+// by opts, and returns the number of bytes written. Entries are written
+// in column major order, with duplicates merged, and are followed by a
+// line "M N 0" giving the size of the matrix unless the last entry is at
+// (M, N).
+func (m *COO) MarshalSpconvertTo(w io.Writer, opts ...WriteOption) (int, error) {
+
+	o := newWriteOptions(opts)
+
+	c := newTriplets[int, float64](m.mat.NNZ(), o.duplicates, false)
+
+	var err error
+	m.Do(func(i, j int, v float64) {
+		if err == nil {
+			_, err = c.add(i, j, v)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	c.sort(OrderColMajor)
+
+	if o.zeros == ZeroDrop {
+		c.dropZeros()
+	}
+
+	integer := m.Field == mtxFieldInteger || m.Field == mtxFieldPattern
+
+	M, N := m.mat.Dims()
+	L := len(c.data)
+
+	return writeSpconvert(w, func(bw *bufio.Writer) {
+
+		var b []byte
+
+		for k, v := range c.data {
+			b = appendCompactIndex(b[:0], c.rows[k], c.cols[k])
+			b = append(b, ' ')
+			b = appendOctaveFloat(b, v, integer)
+			b = append(b, '\n')
+			bw.Write(b)
+		}
+
+		if M > 0 && N > 0 && (L == 0 || c.rows[L-1] != M-1 || c.cols[L-1] != N-1) {
+			b = appendCompactIndex(b[:0], M-1, N-1)
+			bw.Write(append(b, " 0\n"...))
+		}
+	})
+}
+
+// MarshalSpconvertTo serializes the receiver to w as a triplet file of
+// four columns, as read by the MATLAB function spconvert, and returns the
+// number of bytes written. Nonzero elements are written in column major
+// order, and are followed by a line "M N 0 0" giving the size of the
+// matrix unless the element at (M, N) is nonzero.
+func (m *CDense) MarshalSpconvertTo(w io.Writer, opts ...WriteOption) (int, error) {
+
+	M, N := m.mat.Dims()
+
+	return writeSpconvert(w, func(bw *bufio.Writer) {
+
+		var b []byte
+
+		for j := 0; j < N; j++ {
+			for i := 0; i < M; i++ {
+
+				v := m.mat.At(i, j)
+				if v == 0 {
+					continue
+				}
+
+				b = appendCompactIndex(b[:0], i, j)
+				b = append(b, ' ')
+				b = appendOctaveFloat(b, real(v), false)
+				b = append(b, ' ')
+				b = appendOctaveFloat(b, imag(v), false)
+				b = append(b, '\n')
+				bw.Write(b)
+			}
+		}
+
+		if M > 0 && N > 0 && m.mat.At(M-1, N-1) == 0 {
+			b = appendCompactIndex(b[:0], M-1, N-1)
+			bw.Write(append(b, " 0 0\n"...))
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package market
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestCOOUnmarshalSpconvertFrom(t *testing.T) {
+
+	// indices are written as real numbers by save -ascii, and the final
+	// line sets the size
+	const file = "% triplets\n" +
+		"   1.0000000e+00   1.0000000e+00   1.0000000e+00\n" +
+		"   2.0000000e+00   2.0000000e+00   3.0000000e+00\n" +
+		"\n" +
+		"   1.0000000e+00   3.0000000e+00   2.0000000e+00\n" +
+		"   3.0000000e+00   4.0000000e+00   0.0000000e+00\n"
+
+	var m COO
+
+	n, err := m.UnmarshalSpconvertFrom(strings.NewReader(file))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, len(file), n)
+	assert.Equal(t, 3, m.ToCOO().NNZ())
+	assert.True(t, mat.Equal(mat.NewDense(3, 4, []float64{1, 0, 2, 0, 0, 3, 0, 0, 0, 0, 0, 0}), m.ToCOO()))
+
+	// duplicates are summed
+	_, err = m.UnmarshalSpconvertFrom(strings.NewReader("1 1 1\n1 1 2\n"))
+	if assert.NoError(t, err) {
+		assert.True(t, mat.Equal(mat.NewDense(1, 1, []float64{3}), m.ToCOO()))
+	}
+
+	for _, test := range []struct {
+		name string
+		file string
+		line int
+		err  error
+	}{
+		{"columns", "1 1 1\n2 2\n", 2, ErrInputScanError},
+		{"width", "1 1\n", 1, ErrInputScanError},
+		{"fraction", "1 1.5 1\n", 1, ErrInputScanError},
+		{"zero", "1 1 1\n0 1 1\n", 2, ErrIndexOutOfRange},
+		{"limit", "1 1 1\n4 1 1\n", 2, ErrLimitExceeded},
+	} {
+		_, err := m.UnmarshalSpconvertFrom(strings.NewReader(test.file), WithLimits(Limits{MaxRows: 3}))
+		assert.ErrorIs(t, err, test.err, test.name)
+
+		var pe *ParseError
+		if assert.ErrorAs(t, err, &pe, test.name) {
+			assert.Equal(t, test.line, pe.Line, test.name)
+		}
+	}
+
+	_, err = m.UnmarshalSpconvertFrom(strings.NewReader("1 1 1 2\n"))
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+func TestCOOMarshalSpconvertTo(t *testing.T) {
+
+	var b bytes.Buffer
+
+	// a line giving the size follows the entries
+	c := sparse.NewCOO(3, 4, []int{1, 0, 0}, []int{1, 2, 0}, []float64{3, 2, 1.5})
+
+	n, err := NewCOO(c).MarshalSpconvertTo(&b)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, b.Len(), n)
+	assert.Equal(t, "1 1 1.5\n2 2 3\n1 3 2\n3 4 0\n", b.String())
+
+	var m COO
+
+	b.Reset()
+	_, err = NewCOO(mtx01).MarshalSpconvertTo(&b)
+	if assert.NoError(t, err) {
+		_, err = m.UnmarshalSpconvertFrom(&b)
+		assert.NoError(t, err)
+		assert.True(t, mat.Equal(mtx01, m.ToCOO()))
+	}
+
+	_, err = NewCOO(mtx01).MarshalSpconvertTo(failWriter{})
+	assert.ErrorIs(t, err, ErrUnwritable)
+}
+
+func TestCDenseSpconvert(t *testing.T) {
+
+	z := mat.NewCDense(2, 3, []complex128{1 + 2i, 0, -3i, 4, 0, 0})
+
+	var b bytes.Buffer
+
+	_, err := NewCDense(z).MarshalSpconvertTo(&b)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "1 1 1 2\n2 1 4 0\n1 3 0 -3\n2 3 0 0\n", b.String())
+
+	var m CDense
+
+	_, err = m.UnmarshalSpconvertFrom(&b)
+	if assert.NoError(t, err) {
+		assert.True(t, mat.CEqual(z, m.ToCDense()))
+	}
+
+	// files of three columns are of real values
+	_, err = m.UnmarshalSpconvertFrom(strings.NewReader("2 1 5\n"))
+	if assert.NoError(t, err) {
+		assert.True(t, mat.CEqual(mat.NewCDense(2, 1, []complex128{0, 5}), m.ToCDense()))
+	}
+
+	_, err = m.UnmarshalSpconvertFrom(strings.NewReader(""))
+	assert.ErrorIs(t, err, ErrInvalidSize)
+}
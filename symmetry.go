@@ -0,0 +1,163 @@
+package market
+
+import (
+	"math/cmplx"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Symmetry values for the Symmetry field of COO, Dense and CDense.
+const (
+	SymmetryGeneral   = mtxSymmetryGeneral
+	SymmetryHermitian = mtxSymmetryHermitian
+	SymmetrySkew      = mtxSymmetrySkew
+	SymmetrySymmetric = mtxSymmetrySymm
+
+	// SymmetryAuto is a Symmetry for writing only. The tightest symmetry
+	// which holds for the matrix is detected and written, such that the
+	// written file is as small as possible.
+	SymmetryAuto = "auto"
+)
+
+// DetectSymmetry returns the tightest Matrix Market symmetry which holds
+// for the real matrix a, within an absolute tolerance tol: one of
+// SymmetrySymmetric, SymmetrySkew or SymmetryGeneral. As the zero matrix
+// is both symmetric and skew-symmetric, SymmetrySymmetric is preferred.
+// Duplicate entries of a sparse matrix are summed.
+func DetectSymmetry(a mat.Matrix, tol float64) string {
+
+	M, N := a.Dims()
+
+	if s, ok := a.(nonZeroDoer); ok {
+		return detectSymmetry(M, N, entryPairs(s.DoNonZero), tol)
+	}
+
+	return detectSymmetry(M, N, densePairs(N, a.At), tol)
+}
+
+// DetectCSymmetry returns the tightest Matrix Market symmetry which holds
+// for the complex matrix a, within an absolute tolerance tol: one of
+// SymmetrySymmetric, SymmetrySkew, SymmetryHermitian or SymmetryGeneral.
+// Where more than one symmetry holds, they are preferred in that order.
+func DetectCSymmetry(a mat.CMatrix, tol float64) string {
+
+	M, N := a.Dims()
+
+	return detectSymmetry(M, N, densePairs(N, a.At), tol)
+}
+
+// detectSymmetry returns the tightest Matrix Market symmetry which holds
+// for the M×N matrix of which pairs enumerates each entry v along with
+// its transpose t, within an absolute tolerance tol. Hermitian symmetry
+// is considered only where T is complex.
+func detectSymmetry[T Scalar](M, N int, pairs func(fn func(v, t T) bool), tol float64) string {
+
+	var symm, skew, herm bool = true, true, isComplex[T]()
+
+	if M != N {
+		return SymmetryGeneral
+	}
+
+	pairs(func(x, y T) bool {
+
+		v, t := widen(x), widen(y)
+
+		symm = symm && cmplx.Abs(v-t) <= tol
+		skew = skew && cmplx.Abs(v+t) <= tol
+		herm = herm && cmplx.Abs(v-cmplx.Conj(t)) <= tol
+
+		return symm || skew || herm
+	})
+
+	switch {
+	case symm:
+		return SymmetrySymmetric
+	case skew:
+		return SymmetrySkew
+	case herm:
+		return SymmetryHermitian
+	}
+
+	return SymmetryGeneral
+}
+
+// densePairs returns the pairs, for detectSymmetry, of the lower triangle
+// of the N×N matrix of which at returns each element.
+func densePairs[T Scalar](N int, at func(i, j int) T) func(fn func(v, t T) bool) {
+	return func(fn func(v, t T) bool) {
+		for j := 0; j < N; j++ {
+			for i := j; i < N; i++ {
+				if !fn(at(i, j), at(j, i)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// entryPairs returns the pairs, for detectSymmetry, of the entries
+// enumerated by do, of which duplicates are summed.
+func entryPairs[T Scalar](do func(fn func(i, j int, v T))) func(fn func(v, t T) bool) {
+	return func(fn func(v, t T) bool) {
+
+		vals := make(map[[2]int]T)
+		do(func(i, j int, v T) {
+			vals[[2]int{i, j}] += v
+		})
+
+		for k, v := range vals {
+			if !fn(v, vals[[2]int{k[1], k[0]}]) {
+				return
+			}
+		}
+	}
+}
+
+// nonZeroDoer is implemented by sparse matrices, including sparse.COO.
+type nonZeroDoer interface {
+	DoNonZero(fn func(i, j int, v float64))
+}
+
+// isStored reports whether the (zero-indexed) entry at (i, j) is stored
+// in a Matrix Market file of the given symmetry. Only the lower triangle
+// of symmetric and hermitian matrices is stored, and only the strictly
+// lower triangle of skew-symmetric matrices.
+func isStored(symmetry string, i, j int) bool {
+
+	switch symmetry {
+
+	case mtxSymmetrySymm, mtxSymmetryHermitian:
+		return i >= j
+
+	case mtxSymmetrySkew:
+		return i > j
+	}
+
+	return true
+}
+
+// storedEntries returns the number of entries of an M×N matrix stored in
+// a Matrix Market file of the given symmetry in array format.
+func storedEntries(symmetry string, M, N int) int {
+
+	switch symmetry {
+
+	case mtxSymmetrySymm, mtxSymmetryHermitian:
+		return M * (M + 1) / 2
+
+	case mtxSymmetrySkew:
+		return M * (M - 1) / 2
+	}
+
+	return M * N
+}
+
+// stored wraps fn such that it is called only for entries which are
+// stored in a Matrix Market file of the given symmetry.
+func stored[T any](symmetry string, fn func(i, j int, v T)) func(i, j int, v T) {
+	return func(i, j int, v T) {
+		if isStored(symmetry, i, j) {
+			fn(i, j, v)
+		}
+	}
+}
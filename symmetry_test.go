@@ -0,0 +1,93 @@
+package market
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestDetectSymmetry(t *testing.T) {
+
+	c := []struct {
+		a    mat.Matrix
+		tol  float64
+		want string
+	}{
+		{mtx01, 0, SymmetryGeneral},
+		{mtx02, 0, SymmetrySymmetric},
+		{mtx03, 0, SymmetrySkew},
+		{mtx10, 0, SymmetryGeneral},
+		{mtx11, 0, SymmetrySymmetric},
+		{mtx12, 0, SymmetrySkew},
+		{mat.NewDense(2, 2, []float64{1, 2, 2 + 1e-9, 1}), 0, SymmetryGeneral},
+		{mat.NewDense(2, 2, []float64{1, 2, 2 + 1e-9, 1}), 1e-6, SymmetrySymmetric},
+		{sparse.NewCOO(2, 2, []int{1, 0, 1}, []int{0, 1, 0}, []float64{1, 3, 2}), 0, SymmetrySymmetric},
+	}
+
+	for _, v := range c {
+		assert.Equal(t, v.want, DetectSymmetry(v.a, v.tol))
+	}
+}
+
+func TestDetectCSymmetry(t *testing.T) {
+
+	c := map[mat.CMatrix]string{
+		mtx16: SymmetryGeneral,
+		mtx17: SymmetrySymmetric,
+		mtx18: SymmetrySkew,
+		mtx20: SymmetryGeneral, // diagonal is not real
+
+		mat.NewCDense(2, 2, []complex128{1, 2 - 1i, 2 + 1i, 3}): SymmetryHermitian,
+	}
+
+	for a, want := range c {
+		assert.Equal(t, want, DetectCSymmetry(a, 0))
+	}
+}
+
+func TestMarshalTextToSymmetryAuto(t *testing.T) {
+
+	c := map[string]interface {
+		MarshalTextTo(w io.Writer, opts ...WriteOption) (int, error)
+	}{
+		"mmtype-02.mtx": &COO{mtxObjectMatrix, mtxFormatCoordinate, mtxFieldReal, SymmetryAuto, mtx02},
+		"mmtype-03.mtx": &COO{mtxObjectMatrix, mtxFormatCoordinate, mtxFieldReal, SymmetryAuto, mtx03},
+		"mmtype-11.mtx": &Dense{mtxObjectMatrix, mtxFormatArray, mtxFieldReal, SymmetryAuto, mtx11},
+		"mmtype-12.mtx": &Dense{mtxObjectMatrix, mtxFormatArray, mtxFieldReal, SymmetryAuto, mtx12},
+	}
+
+	for k, m := range c {
+
+		var b strings.Builder
+
+		_, err := m.MarshalTextTo(&b)
+		assert.Nil(t, err)
+
+		mm, err := os.ReadFile(filepath.Join("testdata", k))
+		assert.Nil(t, err)
+
+		assert.Equal(t, string(mm), b.String(), k)
+	}
+
+	// complex matrices round trip, as the testdata are not written with
+	// the same precision
+	for _, v := range []*mat.CDense{mtx16, mtx17, mtx18, mtx20} {
+
+		m := NewCDense(v)
+		m.Symmetry = SymmetryAuto
+
+		b, err := m.MarshalText()
+		assert.Nil(t, err)
+
+		var mm CDense
+		assert.Nil(t, mm.UnmarshalText(b))
+		assert.Equal(t, DetectCSymmetry(v, 0), mm.Symmetry)
+		assert.True(t, mat.CEqual(mm.ToCMatrix(), v))
+	}
+}
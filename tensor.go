@@ -0,0 +1,808 @@
+package market
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Tensor is a sparse tensor of any number of modes in coordinate form,
+// for reading and writing the .tns files of the FROSTT collection and
+// Matrix Market files of the tensor object, each a generalization of the
+// coordinate format to N modes. Entry p is the element Data[p] at the
+// (zero-indexed) index Indices[k][p] in each mode k. The field is complex
+// if T is complex, and is otherwise real, integer or pattern. Duplicate
+// entries are summed.
+type Tensor[I Integer, T Scalar] struct {
+	Field   string
+	Dims    []int // size of each mode
+	Indices [][]I // index of each entry, by mode
+	Data    []T
+}
+
+// NewTensor initializes a new sparse tensor of the modes of size dims
+// from the (zero-indexed) entries in indices, by mode, and data, which
+// are not copied. The field is real or complex, per T.
+func NewTensor[I Integer, T Scalar](dims []int, indices [][]I, data []T) *Tensor[I, T] {
+
+	field := mtxFieldReal
+	if isComplex[T]() {
+		field = mtxFieldComplex
+	}
+
+	return &Tensor[I, T]{
+		Field:   field,
+		Dims:    dims,
+		Indices: indices,
+		Data:    data,
+	}
+}
+
+// Modes returns the number of modes, or the order, of the tensor.
+func (m *Tensor[I, T]) Modes() int { return len(m.Dims) }
+
+// NNZ returns the number of stored entries.
+func (m *Tensor[I, T]) NNZ() int { return len(m.Data) }
+
+// Do calls fn for each stored entry, of the (zero-indexed) index idx in
+// each mode. The slice idx is reused between calls.
+func (m *Tensor[I, T]) Do(fn func(idx []int, v T)) {
+
+	idx := make([]int, len(m.Indices))
+
+	for p, v := range m.Data {
+		for k := range idx {
+			idx[k] = int(m.Indices[k][p])
+		}
+		fn(idx, v)
+	}
+}
+
+// check returns an error wrapping ErrInvalidSize if the indices and data
+// of the receiver do not agree in length, or if an index lies outside the
+// dimensions of its mode.
+func (m *Tensor[I, T]) check() error {
+
+	if len(m.Indices) != len(m.Dims) {
+		return fmt.Errorf("%w: %d modes of indices for %d dimensions", ErrInvalidSize, len(m.Indices), len(m.Dims))
+	}
+
+	for k, idx := range m.Indices {
+
+		if len(idx) != len(m.Data) {
+			return fmt.Errorf("%w: %d indices of mode %d for %d entries", ErrInvalidSize, len(idx), k, len(m.Data))
+		}
+
+		for _, i := range idx {
+			if i < 0 || int(i) >= m.Dims[k] {
+				return fmt.Errorf("%w: index %d of mode %d", ErrIndexOutOfRange, i, k)
+			}
+		}
+	}
+
+	return nil
+}
+
+// tensorEntries accumulates the entries of a sparse tensor in coordinate
+// form, resolving duplicate entries per a DuplicatePolicy, as do triplets
+// for a matrix.
+type tensorEntries[I Integer, T Scalar] struct {
+	indices [][]I
+	data    []T
+	policy  DuplicatePolicy
+	seen    map[string]int // position of each index within data
+	key     []byte
+}
+
+// newTensorEntries returns entries of the given number of modes with
+// capacity for up to L entries, as bounded by prealloc, such that a
+// hostile entry count does not drive allocation. Duplicates are tracked, such that they may be
+// reported, if track is true or if p is other than DuplicateSum.
+func newTensorEntries[I Integer, T Scalar](modes, L int, p DuplicatePolicy, track bool) *tensorEntries[I, T] {
+
+	t := tensorEntries[I, T]{
+		indices: make([][]I, modes),
+		data:    make([]T, 0, prealloc(L)),
+		policy:  p,
+	}
+
+	for k := range t.indices {
+		t.indices[k] = make([]I, 0, prealloc(L))
+	}
+
+	if track || p != DuplicateSum {
+		t.seen = make(map[string]int)
+	}
+
+	return &t
+}
+
+// add adds the (zero-indexed) entry v at idx, reporting whether the entry
+// is a known duplicate.
+func (t *tensorEntries[I, T]) add(idx []int, v T) (bool, error) {
+
+	var dup bool
+
+	if t.seen != nil {
+
+		t.key = appendCompactIndices(t.key[:0], idx, 0)
+
+		if p, ok := t.seen[string(t.key)]; ok {
+
+			dup = true
+
+			switch t.policy {
+
+			case DuplicateKeepLast:
+				t.data[p] = v
+				return dup, nil
+
+			case DuplicateKeepFirst:
+				return dup, nil
+
+			case DuplicateError:
+				return dup, ErrDuplicateEntry
+			}
+
+		} else {
+			t.seen[string(t.key)] = len(t.data)
+		}
+	}
+
+	for k, i := range idx {
+		t.indices[k] = append(t.indices[k], I(i))
+	}
+	t.data = append(t.data, v)
+
+	return dup, nil
+}
+
+// dropZeros discards stored entries having a value of zero.
+func (t *tensorEntries[I, T]) dropZeros() {
+
+	var n int
+
+	for p, v := range t.data {
+
+		if v == 0 {
+			continue
+		}
+
+		for k := range t.indices {
+			t.indices[k][n] = t.indices[k][p]
+		}
+		t.data[n] = v
+		n++
+	}
+
+	for k := range t.indices {
+		t.indices[k] = t.indices[k][:n]
+	}
+	t.data = t.data[:n]
+	t.seen = nil
+}
+
+// sort sorts the stored entries in the given order, summing duplicates.
+// In row major order the index of the first mode varies slowest, and in
+// column major order the index of the last mode varies slowest.
+func (t *tensorEntries[I, T]) sort(order Order) {
+
+	modes := make([]int, len(t.indices))
+	for k := range modes {
+		modes[k] = k
+	}
+	if order == OrderColMajor {
+		slices.Reverse(modes)
+	}
+
+	perm := make([]int, len(t.data))
+	for p := range perm {
+		perm[p] = p
+	}
+
+	compare := func(p, q int) int {
+		for _, k := range modes {
+			if c := cmp.Compare(t.indices[k][p], t.indices[k][q]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+
+	slices.SortStableFunc(perm, compare)
+
+	var (
+		indices = make([][]I, len(t.indices))
+		data    = make([]T, 0, len(perm))
+		last    = -1
+	)
+
+	for k := range indices {
+		indices[k] = make([]I, 0, len(perm))
+	}
+
+	for _, p := range perm {
+
+		if last >= 0 && compare(last, p) == 0 {
+			data[len(data)-1] += t.data[p]
+			continue
+		}
+
+		for k := range indices {
+			indices[k] = append(indices[k], t.indices[k][p])
+		}
+		data = append(data, t.data[p])
+		last = p
+	}
+
+	t.indices, t.data = indices, data
+	t.seen = nil
+}
+
+// Do calls fn for each stored entry.
+func (t *tensorEntries[I, T]) Do(fn func(idx []int, v T)) {
+
+	idx := make([]int, len(t.indices))
+
+	for p, v := range t.data {
+		for k := range idx {
+			idx[k] = int(t.indices[k][p])
+		}
+		fn(idx, v)
+	}
+}
+
+// checkTensor reports an error wrapping ErrLimitExceeded if a tensor of
+// the modes of size dims and L stored entries would exceed the limits, of
+// which MaxRows bounds the first mode and MaxCols any other mode, or an
+// error wrapping ErrIndexOverflow if its indices are not representable
+// by I.
+func checkTensor[I Integer](l *Limits, dims []int, L int) error {
+
+	for k, d := range dims {
+
+		M, N := 0, d
+		if k == 0 {
+			M, N = d, 0
+		}
+
+		if err := l.checkSize(M, N, 0); err != nil {
+			return err
+		}
+
+		if int64(d)-1 > maxIndex[I]() {
+			return fmt.Errorf("%w: mode of size %d exceeds %T indices", ErrIndexOverflow, d, I(0))
+		}
+	}
+
+	return l.checkSize(0, 0, L)
+}
+
+// parseTensorEntry parses the tokens of an entry of a tensor of the given
+// number of modes into idx, offsetting indices by base, and returns its
+// value. Indices must lie within dims, if not nil. The value of an entry
+// having no value token, as of the pattern field, is one.
+func parseTensorEntry[T Scalar](toks []string, idx []int, base int, dims []int) (T, error) {
+
+	var v T = 1
+
+	for k := range idx {
+
+		i, err := parseInt(toks[k])
+		if err != nil {
+			return 0, err
+		}
+
+		i -= base
+		if i < 0 || (dims != nil && i >= dims[k]) {
+			return 0, ErrIndexOutOfRange
+		}
+
+		idx[k] = i
+	}
+
+	if len(toks) > len(idx) {
+		return parseScalar[T](toks[len(idx):])
+	}
+
+	return v, nil
+}
+
+// setEntries sets the dimensions and entries of the receiver.
+func (m *Tensor[I, T]) setEntries(dims []int, c *tensorEntries[I, T]) {
+	m.Dims, m.Indices, m.Data = dims, c.indices, c.data
+}
+
+// entries returns a function iterating over the entries of the receiver
+// as configured by o, such that duplicates are resolved, zeros dropped
+// and entries sorted where o requires.
+func (m *Tensor[I, T]) entries(o *writeOptions) (func(func(idx []int, v T)), error) {
+
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+
+	if o.duplicates == DuplicateSum && o.zeros != ZeroDrop && o.order == OrderUnsorted {
+		return m.Do, nil
+	}
+
+	c := newTensorEntries[I, T](len(m.Dims), len(m.Data), o.duplicates, false)
+
+	var err error
+	m.Do(func(idx []int, v T) {
+		if err == nil {
+			_, err = c.add(idx, v)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if o.order != OrderUnsorted {
+		c.sort(o.order)
+	}
+
+	if o.zeros == ZeroDrop {
+		c.dropZeros()
+	}
+
+	return c.Do, nil
+}
+
+// MarshalText serializes the receiver to []byte in Matrix Market format
+// and returns the result.
+func (m *Tensor[I, T]) MarshalText() ([]byte, error) {
+
+	var b strings.Builder
+
+	if _, err := m.MarshalTextTo(&b); err != nil {
+		return nil, err
+	}
+
+	return []byte(b.String()), nil
+}
+
+// MarshalTextTo serializes the receiver to w in Matrix Market format, as
+// configured by opts, and returns the number of bytes written. The header
+// is of the tensor object ("%%MatrixMarket tensor coordinate real
+// general"), and the size line holds the size of each mode followed by
+// the number of entries, each of which is written as its one-indexed
+// index in each mode followed by its value. Values are written with the
+// precision of T.
+func (m *Tensor[I, T]) MarshalTextTo(w io.Writer, opts ...WriteOption) (int, error) {
+
+	var total int
+
+	o := newWriteOptions(opts)
+
+	t := mmType{mtxObjectTensor, mtxFormatCoordinate, m.Field, mtxSymmetryGeneral}
+	t.canonicalize()
+
+	if !(t.isComplex() || t.isReal() || t.isInteger() || t.isPattern()) || !hasField[T](&t) {
+		return total, ErrUnsupportedType
+	}
+
+	do, err := m.entries(o)
+	if err != nil {
+		return total, err
+	}
+
+	// entries are fit and counted prior to writing the header, which
+	// includes the number of entries. Real values are fit as the real
+	// part of a complex value, of twice the size.
+	var (
+		a    = tensorAligner{idx: make([]intAligner, len(m.Dims))}
+		nnz  int
+		bits = bitSize[T]()
+	)
+	if !isComplex[T]() {
+		bits *= 2
+	}
+	fit := a.Fit('f', -1, bits)
+	do(func(idx []int, v T) {
+		if !o.compact {
+			fit(idx, widen(v))
+		}
+		nnz++
+	})
+
+	buf := append(make([]byte, 0, 64), t.Bytes()...)
+	buf = append(buf, "%\n"...)
+
+	// the size line is spaced as is that of a matrix
+	sep := "  "
+	if o.compact {
+		sep = " "
+	} else {
+		buf = append(buf, ' ')
+	}
+
+	for k, d := range append(slices.Clone(m.Dims), nnz) {
+		if k > 0 {
+			buf = append(buf, sep...)
+		}
+		buf = strconv.AppendInt(buf, int64(d), 10)
+	}
+	buf = append(buf, '\n')
+
+	if n, err := w.Write(buf); err == nil {
+		total += n
+	} else {
+		return total, ErrUnwritable
+	}
+
+	var n int
+	do(func(idx []int, v T) {
+		if err != nil {
+			return
+		}
+
+		switch {
+
+		case o.compact:
+			buf = appendCompactIndices(buf[:0], idx, 1)
+			if !t.isPattern() {
+				buf = append(buf, ' ')
+				buf = appendCompactValue(buf, v, &t)
+			}
+
+		// pattern entries have no value
+		case t.isPattern():
+			buf = a.AppendIndex(buf[:0], idx)
+
+		case isComplex[T]():
+			buf = a.AppendIndex(buf[:0], idx)
+			buf = append(buf, ' ')
+			buf = a.val.Append(buf, widen(v), 'f', -1, bits)
+
+		default:
+			buf = a.AppendIndex(buf[:0], idx)
+			buf = append(buf, ' ')
+			buf = a.val.r.Append(buf, real(widen(v)), 'f', -1, bits/2)
+		}
+		buf = append(buf, '\n')
+
+		n, err = w.Write(buf)
+		total += n
+	})
+	if err != nil {
+		return total, ErrUnwritable
+	}
+
+	return total, nil
+}
+
+// UnmarshalText deserializes []byte from Matrix Market format into the
+// receiver.
+func (m *Tensor[I, T]) UnmarshalText(text []byte) error {
+
+	r := bytes.NewReader(text)
+
+	if _, err := m.UnmarshalTextFrom(r); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnmarshalTextFrom deserializes r from Matrix Market format, of the
+// tensor object, into the receiver, as configured by opts, and returns
+// the number of bytes read. The format must be coordinate and the
+// symmetry general, and the field complex if T is complex, or otherwise
+// real, integer or pattern. The size line holds the size of each mode,
+// of which there are any number, followed by the number of entries. The
+// limits on rows and columns bound the first and any other mode
+// respectively. Selection and transform options do not apply.
+func (m *Tensor[I, T]) UnmarshalTextFrom(r io.Reader, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	scanner := newScanner(r, o)
+
+	// read header
+	t, err := scanBanner(scanner, o)
+	if err != nil {
+		return n.total, err
+	}
+
+	if !(t.isTensor() && t.isCoordinate() && t.isGeneral()) || !hasField[T](t) {
+		return n.total, ErrUnsupportedType
+	}
+
+	if !(t.isComplex() || t.isReal() || t.isInteger() || t.isPattern()) {
+		return n.total, ErrUnsupportedType
+	}
+
+	dims, L, err := scanTensorSize[I](scanner, o)
+	if err != nil {
+		return n.total, err
+	}
+
+	c := newTensorEntries[I, T](len(dims), L, o.duplicates, o.warn != nil)
+
+	// entries are an index for each mode and v, excepting pattern
+	// entries, which have no v, and complex entries, which have both a
+	// real and imaginary part
+	width := len(dims) + 1
+	switch {
+	case t.isPattern():
+		width = len(dims)
+	case t.isComplex():
+		width = len(dims) + 2
+	}
+
+	var (
+		idx   = make([]int, len(dims))
+		trunc *TruncatedError
+	)
+
+	for k := 0; k < L; k++ {
+
+		toks, line, err := scanner.entry(width)
+		if trunc = scanner.truncation(o, err, k, L); trunc != nil {
+			break
+		}
+
+		if err != nil {
+			return n.total, err
+		}
+
+		v, err := parseTensorEntry[T](toks, idx, 1, dims)
+		if err != nil {
+			return n.total, scanner.errorf(line, err)
+		}
+
+		if v == 0 {
+			o.warnf(line, WarnExplicitZero, "explicit zero at %v", toks[:len(dims)])
+		}
+
+		dup, err := c.add(idx, v)
+		if err != nil {
+			return n.total, scanner.errorf(line, err)
+		}
+
+		if dup {
+			o.warnf(line, WarnDuplicate, "duplicate entry at %v", toks[:len(dims)])
+		}
+	}
+
+	// error out if data exceed the expected number of entries
+	if trunc == nil {
+		if err := scanner.end(); err != nil {
+			return n.total, err
+		}
+	}
+
+	if o.zeros == ZeroDrop {
+		c.dropZeros()
+	}
+
+	m.Field = t.Field
+	m.setEntries(dims, c)
+
+	if trunc != nil {
+		return n.total, trunc
+	}
+
+	return n.total, nil
+}
+
+// scanTensorSize skips comments and blank lines, then scans the size
+// line of a Matrix Market file of the tensor object, returning the size
+// of each mode and the number of stored entries L, which are checked
+// against the limits.
+func scanTensorSize[I Integer](scanner *lineScanner, o *readOptions) ([]int, int, error) {
+
+	for scanner.Scan() {
+
+		fields := appendFields(nil, scanner.Text())
+
+		// blank line or comment (%, Unicode 37)
+		if len(fields) == 0 || fields[0][0] == 37 {
+			continue
+		}
+
+		// at least one mode and the number of entries
+		if len(fields) < 2 {
+			return nil, 0, scanner.errorf(scanner.line, ErrInputScanError)
+		}
+
+		size := make([]int, len(fields))
+		for k, f := range fields {
+
+			var err error
+			if size[k], err = parseInt(f); err != nil {
+				return nil, 0, scanner.errorf(scanner.line, err)
+			}
+
+			if size[k] < 0 {
+				return nil, 0, scanner.errorf(scanner.line, ErrInvalidSize)
+			}
+		}
+
+		dims, L := size[:len(size)-1], size[len(size)-1]
+
+		if err := checkTensor[I](&o.limits, dims, L); err != nil {
+			return nil, 0, scanner.errorf(scanner.line, err)
+		}
+
+		return dims, L, nil
+	}
+
+	if err := scanError(scanner.Scanner); err != nil {
+		return nil, 0, err
+	}
+
+	return nil, 0, scanner.errorf(scanner.line, ErrPrematureEOF)
+}
+
+// UnmarshalTNSFrom deserializes r from the .tns format of the FROSTT
+// collection into the receiver, as configured by opts, and returns the
+// number of bytes read. Each line holds an entry, as its one-indexed
+// index in each mode followed by its value, or by the real and imaginary
+// parts of its value if T is complex. The number of modes is that of the
+// first entry, and the size of each mode is the greatest index of the
+// mode. Blank lines and comments (#) are skipped. Indices are one-indexed
+// unless reading WithIndexBase. The field is real or complex, per T. The
+// limits on rows and columns bound the first and any other mode
+// respectively. Selection and transform options do not apply.
+func (m *Tensor[I, T]) UnmarshalTNSFrom(r io.Reader, opts ...ReadOption) (int, error) {
+
+	var n counter
+
+	o := newReadOptions(opts)
+
+	r = io.TeeReader(r, &n)
+
+	scanner := newScanner(r, o)
+
+	base := o.indexBase(1)
+
+	width := 1
+	if isComplex[T]() {
+		width = 2
+	}
+
+	var (
+		c      *tensorEntries[I, T]
+		dims   []int
+		idx    []int
+		fields []string
+	)
+
+	for scanner.Scan() {
+
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || line[0] == '#' || line[0] == '%' {
+			continue
+		}
+
+		fields = appendFields(fields[:0], line)
+
+		if c == nil {
+
+			modes := len(fields) - width
+			if modes < 1 {
+				return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: %d columns", ErrInputScanError, len(fields)))
+			}
+
+			c = newTensorEntries[I, T](modes, 0, o.duplicates, o.warn != nil)
+			dims = make([]int, modes)
+			idx = make([]int, modes)
+		}
+
+		if len(fields) != len(dims)+width {
+			return n.total, scanner.errorf(scanner.line, fmt.Errorf("%w: %d of %d columns", ErrInputScanError, len(fields), len(dims)+width))
+		}
+
+		v, err := parseTensorEntry[T](fields, idx, base, nil)
+		if err != nil {
+			return n.total, scanner.errorf(scanner.line, err)
+		}
+
+		for k, i := range idx {
+			dims[k] = max(dims[k], i+1)
+		}
+
+		if err := checkTensor[I](&o.limits, dims, len(c.data)+1); err != nil {
+			return n.total, scanner.errorf(scanner.line, err)
+		}
+
+		if v == 0 {
+			o.warnf(scanner.line, WarnExplicitZero, "explicit zero at %v", fields[:len(dims)])
+		}
+
+		dup, err := c.add(idx, v)
+		if err != nil {
+			return n.total, scanner.errorf(scanner.line, err)
+		}
+
+		if dup {
+			o.warnf(scanner.line, WarnDuplicate, "duplicate entry at %v", fields[:len(dims)])
+		}
+	}
+
+	if err := scanError(scanner.Scanner); err != nil {
+		return n.total, err
+	}
+
+	if c == nil {
+		c = newTensorEntries[I, T](0, 0, o.duplicates, false)
+	}
+
+	if o.zeros == ZeroDrop {
+		c.dropZeros()
+	}
+
+	m.Field = mtxFieldReal
+	if isComplex[T]() {
+		m.Field = mtxFieldComplex
+	}
+	m.setEntries(dims, c)
+
+	return n.total, nil
+}
+
+// MarshalTNSTo serializes the receiver to w in the .tns format of the
+// FROSTT collection, as configured by opts, and returns the number of
+// bytes written. Each entry is written on a line as its index in each
+// mode followed by its value, in the compact number format, of which
+// the value of the pattern field is one. Indices are one-indexed unless
+// writing WriteIndexBase. As the format has no header, the size of a
+// mode is not retained beyond its greatest index.
+func (m *Tensor[I, T]) MarshalTNSTo(w io.Writer, opts ...WriteOption) (int, error) {
+
+	var total int
+
+	o := newWriteOptions(opts)
+
+	base := o.indexBase(1)
+
+	t := mmType{mtxObjectTensor, mtxFormatCoordinate, m.Field, mtxSymmetryGeneral}
+	t.canonicalize()
+
+	if !hasField[T](&t) {
+		return total, ErrUnsupportedType
+	}
+
+	do, err := m.entries(o)
+	if err != nil {
+		return total, err
+	}
+
+	var (
+		buf = make([]byte, 0, 64)
+		n   int
+	)
+	do(func(idx []int, v T) {
+		if err != nil {
+			return
+		}
+
+		if t.isPattern() {
+			v = 1
+		}
+
+		buf = appendCompactIndices(buf[:0], idx, base)
+		buf = append(buf, ' ')
+		buf = appendCompactValue(buf, v, &t)
+		buf = append(buf, '\n')
+
+		n, err = w.Write(buf)
+		total += n
+	})
+	if err != nil {
+		return total, ErrUnwritable
+	}
+
+	return total, nil
+}
@@ -0,0 +1,246 @@
+package market
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tns01 is a three-mode tensor in the .tns format of the FROSTT
+// collection.
+const tns01 = `# a 2x3x4 tensor
+1 1 1 1.5
+2 3 4 -2
+
+1 2 3 3
+`
+
+// mtt01 is the tensor of tns01 as a Matrix Market file.
+const mtt01 = `%%MatrixMarket tensor coordinate real general
+% a 2x3x4 tensor
+2 3 4 3
+1 1 1 1.5
+2 3 4 -2
+1 2 3 3
+`
+
+func TestTensorUnmarshalTNSFrom(t *testing.T) {
+
+	var m Tensor[int32, float64]
+
+	n, err := m.UnmarshalTNSFrom(strings.NewReader(tns01))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, len(tns01), n)
+	assert.Equal(t, mtxFieldReal, m.Field)
+	assert.Equal(t, 3, m.Modes())
+	assert.Equal(t, []int{2, 3, 4}, m.Dims)
+	assert.Equal(t, [][]int32{{0, 1, 0}, {0, 2, 1}, {0, 3, 2}}, m.Indices)
+	assert.Equal(t, []float64{1.5, -2, 3}, m.Data)
+
+	// zero-indexed, with duplicates summed
+	_, err = m.UnmarshalTNSFrom(strings.NewReader("0 0 1\n1 2 2\n0 0 3\n"), WithIndexBase(0))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{2, 3}, m.Dims)
+		assert.Equal(t, 3, m.NNZ())
+
+		var b bytes.Buffer
+		if _, err := m.MarshalTNSTo(&b, WriteOrder(OrderRowMajor)); assert.NoError(t, err) {
+			assert.Equal(t, "1 1 4\n2 3 2\n", b.String())
+		}
+	}
+
+	// complex values are of two tokens
+	var c Tensor[int, complex128]
+
+	_, err = c.UnmarshalTNSFrom(strings.NewReader("1 2 1 -1\n"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, mtxFieldComplex, c.Field)
+		assert.Equal(t, []complex128{1 - 1i}, c.Data)
+	}
+
+	_, err = m.UnmarshalTNSFrom(strings.NewReader("# empty\n"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, m.Modes())
+		assert.Equal(t, 0, m.NNZ())
+	}
+
+	for _, test := range []struct {
+		name string
+		file string
+		line int
+		err  error
+	}{
+		{"columns", "1 1 1\n1 1 1 1\n", 2, ErrInputScanError},
+		{"width", "1\n", 1, ErrInputScanError},
+		{"index", "1 x 1\n", 1, ErrInputScanError},
+		{"zero", "0 1 1\n", 1, ErrIndexOutOfRange},
+		{"duplicate", "1 1 1\n1 1 2\n", 2, ErrDuplicateEntry},
+		{"limit", "1 1 1\n1 5 1\n", 2, ErrLimitExceeded},
+	} {
+		_, err := m.UnmarshalTNSFrom(strings.NewReader(test.file), WithDuplicates(DuplicateError), WithLimits(Limits{MaxCols: 4}))
+		assert.ErrorIs(t, err, test.err, test.name)
+
+		var pe *ParseError
+		if assert.ErrorAs(t, err, &pe, test.name) {
+			assert.Equal(t, test.line, pe.Line, test.name)
+		}
+	}
+}
+
+func TestTensorMarshalTNSTo(t *testing.T) {
+
+	m := NewTensor([]int{2, 3, 4}, [][]int{{1, 0, 0}, {2, 0, 1}, {3, 0, 2}}, []float64{-2, 1.5, 0})
+
+	var b bytes.Buffer
+
+	n, err := m.MarshalTNSTo(&b, WriteOrder(OrderRowMajor), WriteZeros(ZeroDrop))
+	if assert.NoError(t, err) {
+		assert.Equal(t, b.Len(), n)
+		assert.Equal(t, "1 1 1 1.5\n2 3 4 -2\n", b.String())
+	}
+
+	// pattern entries are of unit value
+	m.Field = mtxFieldPattern
+
+	b.Reset()
+	_, err = m.MarshalTNSTo(&b, WriteIndexBase(0))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "1 2 3 1\n0 0 0 1\n0 1 2 1\n", b.String())
+	}
+
+	m.Indices[2][0] = 4
+	_, err = m.MarshalTNSTo(&b)
+	assert.ErrorIs(t, err, ErrIndexOutOfRange)
+
+	m.Indices = m.Indices[:2]
+	_, err = m.MarshalTNSTo(&b)
+	assert.ErrorIs(t, err, ErrInvalidSize)
+
+	m = NewTensor([]int{1}, [][]int{{0}}, []float64{1})
+	_, err = m.MarshalTNSTo(failWriter{})
+	assert.ErrorIs(t, err, ErrUnwritable)
+}
+
+func TestTensorUnmarshalTextFrom(t *testing.T) {
+
+	var m Tensor[int, float64]
+
+	n, err := m.UnmarshalTextFrom(strings.NewReader(mtt01))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, len(mtt01), n)
+	assert.Equal(t, mtxFieldReal, m.Field)
+	assert.Equal(t, []int{2, 3, 4}, m.Dims)
+	assert.Equal(t, [][]int{{0, 1, 0}, {0, 2, 1}, {0, 3, 2}}, m.Indices)
+	assert.Equal(t, []float64{1.5, -2, 3}, m.Data)
+
+	// the same tensor as read from .tns
+	var r Tensor[int, float64]
+	if _, err := r.UnmarshalTNSFrom(strings.NewReader(tns01)); assert.NoError(t, err) {
+		assert.Equal(t, r, m)
+	}
+
+	// pattern entries have no value
+	err = m.UnmarshalText([]byte("%%MatrixMarket tensor coordinate pattern general\n2 2 2 2 2\n1 1 1 1\n2 2 2 2\n"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, mtxFieldPattern, m.Field)
+		assert.Equal(t, []int{2, 2, 2, 2}, m.Dims)
+		assert.Equal(t, []float64{1, 1}, m.Data)
+	}
+
+	var c Tensor[int, complex64]
+
+	err = c.UnmarshalText([]byte("%%MatrixMarket tensor coordinate complex general\n1 1 1\n1 1 0.5 2\n"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []complex64{0.5 + 2i}, c.Data)
+	}
+
+	// tensors are not matrices
+	var d COO
+	_, err = d.UnmarshalTextFrom(strings.NewReader(mtt01))
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	// truncated input is retained when reading partially
+	_, err = m.UnmarshalTextFrom(strings.NewReader(mtt01[:len(mtt01)-6]), WithPartial())
+	if assert.ErrorIs(t, err, ErrTruncated) {
+		assert.Equal(t, 2, m.NNZ())
+	}
+
+	for _, test := range []struct {
+		name string
+		file string
+		err  error
+	}{
+		{"header", "%%MatrixMarket matrix coordinate real general\n1 1 1\n1 1 1\n", ErrUnsupportedType},
+		{"symmetry", "%%MatrixMarket tensor coordinate real symmetric\n1 1 1\n1 1 1\n", ErrUnsupportedType},
+		{"field", "%%MatrixMarket tensor coordinate complex general\n1 1 1\n1 1 1 1\n", ErrUnsupportedType},
+		{"format", "%%MatrixMarket tensor array real general\n1 1\n1\n", ErrUnsupportedType},
+		{"size", "%%MatrixMarket tensor coordinate real general\n1\n", ErrInputScanError},
+		{"negative", "%%MatrixMarket tensor coordinate real general\n1 -1 0\n", ErrInvalidSize},
+		{"missing", "%%MatrixMarket tensor coordinate real general\n% no size\n", ErrPrematureEOF},
+		{"index", "%%MatrixMarket tensor coordinate real general\n2 2 1\n1 3 1\n", ErrIndexOutOfRange},
+		{"more", "%%MatrixMarket tensor coordinate real general\n2 2 1\n1 1 1\n2 2 2\n", ErrInputScanError},
+		{"fewer", "%%MatrixMarket tensor coordinate real general\n2 2 2\n1 1 1\n", ErrInputScanError},
+		{"limit", "%%MatrixMarket tensor coordinate real general\n2 2 9 1\n1 1 1 1\n", ErrLimitExceeded},
+	} {
+		_, err := m.UnmarshalTextFrom(strings.NewReader(test.file), WithLimits(Limits{MaxCols: 4}))
+		assert.ErrorIs(t, err, test.err, test.name)
+	}
+
+	// a hostile entry count does not drive allocation
+	_, err = m.UnmarshalTextFrom(strings.NewReader("%%MatrixMarket tensor coordinate real general\n2 2 2 100000000000000\n1 1 1 1\n"))
+	assert.ErrorIs(t, err, ErrInputScanError)
+}
+
+func TestTensorMarshalTextTo(t *testing.T) {
+
+	var m Tensor[int, float64]
+
+	if _, err := m.UnmarshalTextFrom(strings.NewReader(mtt01)); !assert.NoError(t, err) {
+		return
+	}
+
+	var b bytes.Buffer
+
+	n, err := m.MarshalTextTo(&b, WriteCompact())
+	if assert.NoError(t, err) {
+		assert.Equal(t, b.Len(), n)
+		assert.Equal(t, "%%MatrixMarket tensor coordinate real general\n%\n2 3 4 3\n1 1 1 1.5\n2 3 4 -2\n1 2 3 3\n", b.String())
+	}
+
+	// entries are aligned unless compact
+	b.Reset()
+	_, err = m.MarshalTextTo(&b, WriteOrder(OrderColMajor))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "%%MatrixMarket tensor coordinate real general\n%\n 2  3  4  3\n 1  1  1  1.5\n 1  2  3  3\n 2  3  4 -2\n", b.String())
+	}
+
+	// round trip
+	var r Tensor[int, float64]
+	if _, err := r.UnmarshalTextFrom(&b); assert.NoError(t, err) {
+		assert.Equal(t, m.Dims, r.Dims)
+		assert.Equal(t, [][]int{{0, 0, 1}, {0, 1, 2}, {0, 2, 3}}, r.Indices)
+		assert.Equal(t, []float64{1.5, 3, -2}, r.Data)
+	}
+
+	c := NewTensor([]int{2, 2}, [][]int32{{1}, {0}}, []complex128{1 - 2i})
+	text, err := c.MarshalText()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "%%MatrixMarket tensor coordinate complex general\n%\n 2  2  1\n 2  1  1 -2\n", string(text))
+	}
+
+	m.Field = mtxFieldComplex
+	_, err = m.MarshalTextTo(&b)
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	m.Field = mtxFieldReal
+	_, err = m.MarshalTextTo(failWriter{})
+	assert.ErrorIs(t, err, ErrUnwritable)
+}
@@ -0,0 +1,256 @@
+package market
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+)
+
+// span is a half-open range [lo, hi) of (zero-indexed) indices.
+type span struct {
+	lo, hi int
+	set    bool
+}
+
+// transform maps the entries of a matrix as they are read, per the
+// selection and transform ReadOptions, such that the full matrix is not
+// held. Indices are permuted, then selected, then transposed. A nil
+// *transform is the identity.
+type transform struct {
+	rowPerm, colPerm []int // permuted index of each index of the file
+	rows, cols       span  // selected (permuted) indices
+	trans            bool
+	scale            float64
+	scaled           bool
+	dropTol          float64
+}
+
+// check returns an error if the transform is not applicable to an M×N
+// matrix.
+func (x *transform) check(M, N int) error {
+
+	if x == nil {
+		return nil
+	}
+
+	if err := checkPermutation(x.rowPerm, M, "row"); err != nil {
+		return err
+	}
+
+	if err := checkPermutation(x.colPerm, N, "column"); err != nil {
+		return err
+	}
+
+	if s := x.rows; s.set && (s.lo < 0 || s.lo > s.hi || s.hi > M) {
+		return fmt.Errorf("%w: rows [%d, %d) of %d×%d matrix", ErrIndexOutOfRange, s.lo, s.hi, M, N)
+	}
+
+	if s := x.cols; s.set && (s.lo < 0 || s.lo > s.hi || s.hi > N) {
+		return fmt.Errorf("%w: columns [%d, %d) of %d×%d matrix", ErrIndexOutOfRange, s.lo, s.hi, M, N)
+	}
+
+	return nil
+}
+
+// checkPermutation returns an error wrapping ErrInvalidPermutation if p
+// is non-nil and is not a permutation of the n indices of a dimension.
+func checkPermutation(p []int, n int, dim string) error {
+
+	if p == nil {
+		return nil
+	}
+
+	if len(p) != n {
+		return fmt.Errorf("%w: %s permutation of length %d for %d indices", ErrInvalidPermutation, dim, len(p), n)
+	}
+
+	seen := make([]bool, n)
+	for _, i := range p {
+		if i < 0 || i >= n || seen[i] {
+			return fmt.Errorf("%w: %s permutation has invalid or repeated index %d", ErrInvalidPermutation, dim, i)
+		}
+		seen[i] = true
+	}
+
+	return nil
+}
+
+// dims returns the dimensions of an M×N matrix once transformed.
+func (x *transform) dims(M, N int) (int, int) {
+
+	if x == nil {
+		return M, N
+	}
+
+	if x.rows.set {
+		M = x.rows.hi - x.rows.lo
+	}
+
+	if x.cols.set {
+		N = x.cols.hi - x.cols.lo
+	}
+
+	if x.trans {
+		return N, M
+	}
+
+	return M, N
+}
+
+// index maps the (zero-indexed) indices i and j, reporting false if the
+// entry at (i, j) is not selected.
+func (x *transform) index(i, j int) (int, int, bool) {
+
+	if x == nil {
+		return i, j, true
+	}
+
+	if x.rowPerm != nil {
+		i = x.rowPerm[i]
+	}
+
+	if x.colPerm != nil {
+		j = x.colPerm[j]
+	}
+
+	if s := x.rows; s.set {
+		if i < s.lo || i >= s.hi {
+			return 0, 0, false
+		}
+		i -= s.lo
+	}
+
+	if s := x.cols; s.set {
+		if j < s.lo || j >= s.hi {
+			return 0, 0, false
+		}
+		j -= s.lo
+	}
+
+	if x.trans {
+		return j, i, true
+	}
+
+	return i, j, true
+}
+
+// indices returns the transform of indices only, without scaling or
+// dropping values, as applies to pattern matrices.
+func (x *transform) indices() *transform {
+
+	if x == nil {
+		return nil
+	}
+
+	y := *x
+	y.scaled, y.dropTol = false, 0
+
+	return &y
+}
+
+// symmetry returns the symmetry of a matrix of the given symmetry once
+// transformed. Selecting or permuting indices does not in general
+// preserve symmetry, in which case the matrix is general.
+func (x *transform) symmetry(symmetry string) string {
+
+	if x == nil || (x.rowPerm == nil && x.colPerm == nil && !x.rows.set && !x.cols.set) {
+		return symmetry
+	}
+
+	return mtxSymmetryGeneral
+}
+
+// apply maps the (zero-indexed) entry v at (i, j), reporting false if the
+// entry is not selected or is dropped.
+func apply[T Scalar](x *transform, i, j int, v T) (int, int, T, bool) {
+
+	if x == nil {
+		return i, j, v, true
+	}
+
+	i, j, ok := x.index(i, j)
+	if !ok {
+		return 0, 0, v, false
+	}
+
+	if x.scaled {
+		v *= narrow[T](complex(x.scale, 0))
+	}
+
+	if x.dropTol > 0 && cmplx.Abs(widen(v)) < x.dropTol {
+		return 0, 0, v, false
+	}
+
+	return i, j, v, true
+}
+
+// selectRows returns the values v, which are indexed by the rows of a
+// matrix of len(v) rows, such as the labels of a dataset, permuted and
+// selected as are the rows of the matrix. Transposing the matrix does not
+// reorder them.
+func selectRows[T any](x *transform, v []T) []T {
+
+	if x == nil || (x.rowPerm == nil && !x.rows.set) {
+		return v
+	}
+
+	n := len(v)
+	if x.rows.set {
+		n = x.rows.hi - x.rows.lo
+	}
+
+	dst := make([]T, n)
+	for i := range v {
+
+		k := i
+		if x.rowPerm != nil {
+			k = x.rowPerm[i]
+		}
+
+		if s := x.rows; s.set {
+			if k < s.lo || k >= s.hi {
+				continue
+			}
+			k -= s.lo
+		}
+
+		dst[k] = v[i]
+	}
+
+	return dst
+}
+
+// ReadPermutation reads a permutation vector, for use with
+// WithRowPermutation or WithColPermutation, from a Matrix Market file in
+// array format having a single row or column, as configured by opts. The
+// elements of the vector are the one-indexed positions to which the
+// corresponding indices are permuted, and are returned zero-indexed. An
+// error wrapping ErrInvalidPermutation is returned if the vector is not
+// a permutation.
+func ReadPermutation(r io.Reader, opts ...ReadOption) ([]int, error) {
+
+	var a Array[float64]
+
+	if _, err := a.UnmarshalTextFrom(r, opts...); err != nil {
+		return nil, err
+	}
+
+	if a.M != 1 && a.N != 1 {
+		return nil, fmt.Errorf("%w: %d×%d matrix is not a vector", ErrInvalidPermutation, a.M, a.N)
+	}
+
+	p := make([]int, len(a.Data))
+	for k, v := range a.Data {
+		if v != math.Trunc(v) || v < 1 || v > float64(len(p)) {
+			return nil, fmt.Errorf("%w: invalid index %v", ErrInvalidPermutation, v)
+		}
+		p[k] = int(v) - 1
+	}
+
+	if err := checkPermutation(p, len(p), "vector"); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,218 @@
+package market
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+const transformText = `%%MatrixMarket matrix coordinate real general
+%
+ 3  4  5
+ 1  1  1
+ 1  4  2
+ 2  2  3
+ 3  1  4
+ 3  3  0.01
+`
+
+func TestTransformCOO(t *testing.T) {
+
+	for _, test := range []struct {
+		name string
+		opts []ReadOption
+		want *mat.Dense
+	}{
+		{
+			name: "identity",
+			want: mat.NewDense(3, 4, []float64{1, 0, 0, 2, 0, 3, 0, 0, 4, 0, 0.01, 0}),
+		},
+		{
+			name: "rows",
+			opts: []ReadOption{WithRows(1, 3)},
+			want: mat.NewDense(2, 4, []float64{0, 3, 0, 0, 4, 0, 0.01, 0}),
+		},
+		{
+			name: "submatrix",
+			opts: []ReadOption{WithRows(0, 2), WithCols(1, 4)},
+			want: mat.NewDense(2, 3, []float64{0, 0, 2, 3, 0, 0}),
+		},
+		{
+			name: "transpose",
+			opts: []ReadOption{WithTranspose(), WithCols(0, 1)},
+			want: mat.NewDense(1, 3, []float64{1, 0, 4}),
+		},
+		{
+			name: "permutation",
+			opts: []ReadOption{WithRowPermutation([]int{2, 0, 1}), WithColPermutation([]int{3, 2, 1, 0})},
+			want: mat.NewDense(3, 4, []float64{0, 0, 3, 0, 0, 0.01, 0, 4, 2, 0, 0, 1}),
+		},
+		{
+			name: "scale",
+			opts: []ReadOption{WithScale(-2), WithDropTolerance(0.1)},
+			want: mat.NewDense(3, 4, []float64{-2, 0, 0, -4, 0, -6, 0, 0, -8, 0, 0, 0}),
+		},
+	} {
+		var m COO
+		_, err := m.UnmarshalTextFrom(strings.NewReader(transformText), test.opts...)
+		assert.Nil(t, err, test.name)
+		assert.True(t, mat.Equal(m.ToMatrix(), test.want), test.name)
+	}
+
+	// dropped entries are not held
+	var m COO
+	_, err := m.UnmarshalTextFrom(strings.NewReader(transformText), WithDropTolerance(0.1))
+	assert.Nil(t, err)
+	assert.Equal(t, 4, m.ToCOO().NNZ())
+}
+
+func TestTransformSymmetry(t *testing.T) {
+
+	text := `%%MatrixMarket matrix array real symmetric
+ 3  3
+ 1
+ 2
+ 3
+ 4
+ 5
+ 6
+`
+
+	// selection reads a symmetric matrix as general
+	var d Dense
+	_, err := d.UnmarshalTextFrom(strings.NewReader(text), WithRows(1, 3))
+	assert.Nil(t, err)
+	assert.Equal(t, "general", d.Symmetry)
+	assert.True(t, mat.Equal(d.ToMatrix(), mat.NewDense(2, 3, []float64{2, 4, 5, 3, 5, 6})))
+
+	// transposing and scaling preserve symmetry
+	_, err = d.UnmarshalTextFrom(strings.NewReader(text), WithTranspose(), WithScale(2))
+	assert.Nil(t, err)
+	assert.Equal(t, "symmetric", d.Symmetry)
+	assert.True(t, mat.Equal(d.ToMatrix(), mat.NewDense(3, 3, []float64{2, 4, 6, 4, 8, 10, 6, 10, 12})))
+
+	var a Array[float32]
+	_, err = a.UnmarshalTextFrom(strings.NewReader(text), WithCols(2, 3), WithTranspose())
+	assert.Nil(t, err)
+	assert.Equal(t, []float32{3, 5, 6}, a.Data)
+	assert.Equal(t, 1, a.M)
+}
+
+func TestTransformComplex(t *testing.T) {
+
+	text := `%%MatrixMarket matrix coordinate complex hermitian
+ 2  2  2
+ 1  1  1  0
+ 2  1  2  3
+`
+
+	var m CDense
+	_, err := m.UnmarshalTextFrom(strings.NewReader(text), WithTranspose(), WithScale(2))
+	assert.Nil(t, err)
+	assert.True(t, mat.CEqual(m.ToCMatrix(), mat.NewCDense(2, 2, []complex128{2, 4 + 6i, 4 - 6i, 0})))
+
+	var c Coordinate[int32, complex64]
+	_, err = c.UnmarshalTextFrom(strings.NewReader(text), WithRows(1, 2))
+	assert.Nil(t, err)
+	assert.Equal(t, []int32{0}, c.Rows)
+	assert.Equal(t, []int32{0}, c.Cols)
+	assert.Equal(t, []complex64{2 + 3i}, c.Data)
+}
+
+func TestTransformPattern(t *testing.T) {
+
+	text := `%%MatrixMarket matrix coordinate pattern symmetric
+ 3  3  2
+ 2  1
+ 3  3
+`
+
+	// pattern entries are neither scaled nor dropped
+	var p Pattern[int]
+	_, err := p.UnmarshalTextFrom(strings.NewReader(text), WithCols(0, 2), WithDropTolerance(2))
+	assert.Nil(t, err)
+	assert.Equal(t, "general", p.Symmetry)
+	assert.Equal(t, []int{0, 1, 2, 2}, p.RowPtr)
+	assert.Equal(t, []int{1, 0}, p.ColIdx)
+
+	var m COO
+	_, err = m.UnmarshalTextFrom(strings.NewReader(text), WithScale(3))
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, m.ToMatrix().At(2, 2))
+}
+
+func TestTransformReader(t *testing.T) {
+
+	text := `%%MatrixMarket matrix coordinate real skew-symmetric
+ 3  3  2
+ 2  1  1
+ 3  2  2
+`
+
+	r, err := NewReader[float64](strings.NewReader(text), WithRows(1, 3), WithTranspose())
+	assert.Nil(t, err)
+	assert.Equal(t, "general", r.Symmetry)
+
+	M, N := r.Dims()
+	assert.Equal(t, 3, M)
+	assert.Equal(t, 2, N)
+
+	got := make(map[Index]float64)
+	for k, v := range r.All() {
+		got[k] = v
+	}
+	assert.Nil(t, r.Err())
+	assert.Equal(t, map[Index]float64{{0, 0}: 1, {2, 0}: -2, {1, 1}: 2}, got)
+}
+
+func TestTransformErrors(t *testing.T) {
+
+	var m COO
+
+	for _, opts := range [][]ReadOption{
+		{WithRows(2, 4)},
+		{WithCols(-1, 1)},
+		{WithRows(2, 1)},
+	} {
+		_, err := m.UnmarshalTextFrom(strings.NewReader(transformText), opts...)
+		assert.True(t, errors.Is(err, ErrIndexOutOfRange))
+	}
+
+	for _, opts := range [][]ReadOption{
+		{WithRowPermutation([]int{0, 1})},
+		{WithRowPermutation([]int{0, 1, 1})},
+		{WithColPermutation([]int{0, 1, 2, 4})},
+	} {
+		_, err := m.UnmarshalTextFrom(strings.NewReader(transformText), opts...)
+		assert.True(t, errors.Is(err, ErrInvalidPermutation))
+	}
+
+	// dense storage cannot be allocated for an empty selection
+	var d Dense
+	_, err := d.UnmarshalTextFrom(strings.NewReader("%%MatrixMarket matrix array real general\n2 2\n1\n2\n3\n4\n"), WithRows(1, 1))
+	assert.True(t, errors.Is(err, ErrInvalidSize))
+}
+
+func TestReadPermutation(t *testing.T) {
+
+	p, err := ReadPermutation(strings.NewReader("%%MatrixMarket matrix array integer general\n3 1\n2\n3\n1\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 0}, p)
+
+	p, err = ReadPermutation(strings.NewReader("%%MatrixMarket matrix array real general\n1 2\n2\n1\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 0}, p)
+
+	for _, text := range []string{
+		"%%MatrixMarket matrix array integer general\n2 2\n1\n2\n3\n4\n",
+		"%%MatrixMarket matrix array integer general\n2 1\n1\n1\n",
+		"%%MatrixMarket matrix array integer general\n2 1\n1\n3\n",
+		"%%MatrixMarket matrix array real general\n2 1\n1.5\n2\n",
+	} {
+		_, err := ReadPermutation(strings.NewReader(text))
+		assert.True(t, errors.Is(err, ErrInvalidPermutation), text)
+	}
+}
@@ -0,0 +1,205 @@
+package market
+
+import (
+	"cmp"
+	"slices"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// triplets accumulates the entries of a sparse matrix in coordinate
+// form, resolving duplicate entries per a DuplicatePolicy.
+type triplets[I Integer, T Scalar] struct {
+	rows   []I
+	cols   []I
+	data   []T
+	policy DuplicatePolicy
+	seen   map[[2]int]int // position of each (i, j) within data
+	x      *transform     // applied to each entry as added
+}
+
+// newTriplets returns triplets with capacity for L entries, or for at
+// most maxPrealloc entries beyond which storage grows as entries are
+// added. Duplicates are tracked, such that they may be reported, if track is true or if p
+// is other than DuplicateSum.
+func newTriplets[I Integer, T Scalar](L int, p DuplicatePolicy, track bool) *triplets[I, T] {
+
+	var t triplets[I, T]
+	t.reset(L, p, track)
+
+	return &t
+}
+
+// reset empties t, retaining its storage, and ensures capacity for L
+// entries, as bounded by newTriplets. Duplicates are tracked as by
+// newTriplets.
+func (t *triplets[I, T]) reset(L int, p DuplicatePolicy, track bool) {
+
+	t.rows = slices.Grow(t.rows[:0], prealloc(L))
+	t.cols = slices.Grow(t.cols[:0], prealloc(L))
+	t.data = slices.Grow(t.data[:0], prealloc(L))
+	t.policy = p
+
+	switch {
+	case !track && p == DuplicateSum:
+		t.seen = nil
+	case t.seen == nil:
+		t.seen = make(map[[2]int]int)
+	default:
+		clear(t.seen)
+	}
+}
+
+// add adds the (zero-indexed) entry v at (i, j), reporting whether the
+// entry is a known duplicate.
+func (t *triplets[I, T]) add(i, j int, v T) (bool, error) {
+
+	var dup bool
+
+	i, j, v, ok := apply(t.x, i, j, v)
+	if !ok {
+		return dup, nil
+	}
+
+	if t.seen != nil {
+
+		k := [2]int{i, j}
+
+		if p, ok := t.seen[k]; ok {
+
+			dup = true
+
+			switch t.policy {
+
+			case DuplicateKeepLast:
+				t.data[p] = v
+				return dup, nil
+
+			case DuplicateKeepFirst:
+				return dup, nil
+
+			case DuplicateError:
+				return dup, ErrDuplicateEntry
+			}
+
+		} else {
+			t.seen[k] = len(t.data)
+		}
+	}
+
+	t.rows = append(t.rows, I(i))
+	t.cols = append(t.cols, I(j))
+	t.data = append(t.data, v)
+
+	return dup, nil
+}
+
+// dropZeros discards stored entries having a value of zero.
+func (t *triplets[I, T]) dropZeros() {
+
+	var k int
+
+	for p, v := range t.data {
+
+		if v == 0 {
+			continue
+		}
+
+		t.rows[k], t.cols[k], t.data[k] = t.rows[p], t.cols[p], v
+		k++
+	}
+
+	t.rows, t.cols, t.data = t.rows[:k], t.cols[:k], t.data[:k]
+	t.seen = nil
+}
+
+// sort sorts the stored entries in the given order, summing duplicates.
+// Entries sharing coordinates are summed in the order held.
+func (t *triplets[I, T]) sort(order Order) {
+
+	// major and minor index of the entry at position p
+	key := func(p int) (I, I) {
+		if order == OrderColMajor {
+			return t.cols[p], t.rows[p]
+		}
+		return t.rows[p], t.cols[p]
+	}
+
+	perm := make([]int, len(t.data))
+	for p := range perm {
+		perm[p] = p
+	}
+
+	slices.SortStableFunc(perm, func(p, q int) int {
+		p1, p2 := key(p)
+		q1, q2 := key(q)
+		if c := cmp.Compare(p1, q1); c != 0 {
+			return c
+		}
+		return cmp.Compare(p2, q2)
+	})
+
+	var (
+		rows = make([]I, 0, len(perm))
+		cols = make([]I, 0, len(perm))
+		data = make([]T, 0, len(perm))
+	)
+
+	for _, p := range perm {
+
+		if k := len(data) - 1; k >= 0 && rows[k] == t.rows[p] && cols[k] == t.cols[p] {
+			data[k] += t.data[p]
+			continue
+		}
+
+		rows = append(rows, t.rows[p])
+		cols = append(cols, t.cols[p])
+		data = append(data, t.data[p])
+	}
+
+	t.rows, t.cols, t.data = rows, cols, data
+	t.seen = nil
+}
+
+// Do calls fn for each stored entry.
+func (t *triplets[I, T]) Do(fn func(i, j int, v T)) {
+	for p, v := range t.data {
+		fn(int(t.rows[p]), int(t.cols[p]), v)
+	}
+}
+
+// cdenseEntries accumulates the entries of a complex-valued matrix in
+// coordinate form for dense storage, resolving duplicate entries per a
+// DuplicatePolicy. Entries are held as triplets until all are read, such
+// that dense storage is not allocated for the dimensions of a header
+// alone.
+type cdenseEntries struct {
+	*triplets[int, complex128]
+	M, N int // dimensions of the dense matrix
+}
+
+// newCDenseEntries returns cdenseEntries of an M×N matrix. Duplicates are
+// tracked, such that they may be reported, if track is true or if p is
+// other than DuplicateSum.
+func newCDenseEntries(M, N int, p DuplicatePolicy, track bool) *cdenseEntries {
+	return &cdenseEntries{newTriplets[int, complex128](0, p, track), M, N}
+}
+
+// dense returns the entries in dense storage, which is the destination
+// set by WithCDense if any, or an error if the storage would exceed the
+// limits of o.
+func (d *cdenseEntries) dense(o *readOptions) (*mat.CDense, error) {
+
+	if err := o.checkDense(d.M, d.N); err != nil {
+		return nil, err
+	}
+
+	m := o.newCDense(d.M, d.N)
+
+	// duplicates remaining are summed
+	d.Do(func(i, j int, v complex128) {
+		m.Set(i, j, m.At(i, j)+v)
+	})
+
+	return m, nil
+}
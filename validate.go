@@ -0,0 +1,410 @@
+package market
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// MaxViolations is the maximum number of violations retained in the
+// Violations of a Report. Violations beyond this number are counted, but
+// not retained, such that validation runs in bounded memory.
+const MaxViolations = 1000
+
+// ViolationKind classifies a Violation.
+type ViolationKind int
+
+const (
+	// ViolationBanner is a missing or malformed header line.
+	ViolationBanner ViolationKind = iota
+
+	// ViolationUnsupportedType is a header describing an object, format,
+	// field and symmetry combination which is not supported.
+	ViolationUnsupportedType
+
+	// ViolationSize is a missing or malformed size line, or a size which
+	// is invalid for the matrix type.
+	ViolationSize
+
+	// ViolationEntryCount is a data section holding more or fewer entries
+	// than specified by the size line.
+	ViolationEntryCount
+
+	// ViolationIndexRange is an entry having a row or column index outside
+	// of the dimensions of the matrix.
+	ViolationIndexRange
+
+	// ViolationDuplicate is an entry sharing coordinates with a prior
+	// entry.
+	ViolationDuplicate
+
+	// ViolationTriangle is an entry of a symmetric, skew-symmetric or
+	// hermitian matrix outside of the stored triangle, or a hermitian
+	// diagonal entry which is not real.
+	ViolationTriangle
+
+	// ViolationNaN is a value which is NaN or infinite.
+	ViolationNaN
+
+	// ViolationNumber is a malformed index or value.
+	ViolationNumber
+)
+
+var violationKinds = [...]string{
+	ViolationBanner:          "banner",
+	ViolationUnsupportedType: "unsupported-type",
+	ViolationSize:            "size",
+	ViolationEntryCount:      "entry-count",
+	ViolationIndexRange:      "index-range",
+	ViolationDuplicate:       "duplicate",
+	ViolationTriangle:        "triangle",
+	ViolationNaN:             "nan",
+	ViolationNumber:          "number",
+}
+
+// String returns the name of the kind.
+func (k ViolationKind) String() string {
+	if k < 0 || int(k) >= len(violationKinds) {
+		return fmt.Sprintf("ViolationKind(%d)", int(k))
+	}
+	return violationKinds[k]
+}
+
+// Violation is a departure from the Matrix Market specification.
+type Violation struct {
+	Line int           // one-indexed line number
+	Kind ViolationKind // classification
+	Msg  string        // description
+}
+
+// String returns the violation prefixed by its line number and kind.
+func (v Violation) String() string {
+	return fmt.Sprintf("line %d: %s: %s", v.Line, v.Kind, v.Msg)
+}
+
+// Report is the result of validating a Matrix Market file.
+type Report struct {
+	Object   string // header object, if read
+	Format   string // header format, if read
+	Field    string // header field, if read
+	Symmetry string // header symmetry, if read
+
+	M, N, L int // size line, with L the number of expected entries
+	Entries int // number of entries read
+
+	Violations []Violation // at most MaxViolations violations
+	Total      int         // total number of violations
+}
+
+// Valid reports whether no violations were found.
+func (r *Report) Valid() bool { return r.Total == 0 }
+
+// add records a violation.
+func (r *Report) add(line int, kind ViolationKind, format string, args ...any) {
+	if r.Total < MaxViolations {
+		r.Violations = append(r.Violations, Violation{line, kind, fmt.Sprintf(format, args...)})
+	}
+	r.Total++
+}
+
+// ValidateOption configures the validation of a Matrix Market file.
+type ValidateOption func(*validateOptions)
+
+// validateOptions holds the configuration applied by a set of
+// ValidateOptions.
+type validateOptions struct {
+	duplicates bool
+}
+
+// newValidateOptions applies opts over the default validator
+// configuration.
+func newValidateOptions(opts []ValidateOption) *validateOptions {
+	var o validateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &o
+}
+
+// ValidateDuplicates reports duplicate entries of coordinate data as
+// violations. Detecting duplicates requires retaining the coordinates of
+// each entry, such that memory use grows with the number of entries.
+func ValidateDuplicates() ValidateOption {
+	return func(o *validateOptions) {
+		o.duplicates = true
+	}
+}
+
+// Validate checks the Matrix Market file read from r against the
+// specification, as configured by opts, reporting every violation found
+// rather than stopping at the first. The matrix is not loaded, and memory
+// use is constant unless ValidateDuplicates is given. Validation stops
+// early only where the header or size line cannot be read, as the data
+// cannot then be interpreted. The returned error is non-nil only if r
+// could not be read.
+func Validate(r io.Reader, opts ...ValidateOption) (Report, error) {
+
+	var rep Report
+
+	vo := newValidateOptions(opts)
+
+	o := newReadOptions(nil)
+	scanner := newScanner(r, o)
+
+	// read header
+	t, err := scanHeader(scanner, o)
+	switch {
+
+	case err == nil:
+		rep.Object, rep.Format, rep.Field, rep.Symmetry = t.Object, t.Format, t.Field, t.Symmetry
+
+	case errors.Is(err, ErrUnsupportedType):
+		rep.add(1, ViolationUnsupportedType, "unsupported matrix type")
+		return rep, nil
+
+	case errors.Is(err, ErrPrematureEOF), errors.Is(err, ErrNoHeader):
+		rep.add(1, ViolationBanner, "%v", err)
+		return rep, nil
+
+	// empty input
+	case err == ErrInputScanError && scanner.Err() == nil:
+		rep.add(1, ViolationBanner, "%v", ErrNoHeader)
+		return rep, nil
+
+	default:
+		return rep, scanViolation(scanner, err, ViolationBanner, &rep)
+	}
+
+	// read size line
+	M, N, L, err := scanSize(scanner, t, o)
+	if err != nil {
+
+		var pe *ParseError
+		switch {
+
+		case errors.As(err, &pe):
+			rep.add(pe.Line, ViolationSize, "%v", pe.Err)
+
+		// a size of which the number of elements overflows int
+		case errors.Is(err, ErrLimitExceeded):
+			rep.add(scanner.line, ViolationSize, "%v", err)
+
+		default:
+			return rep, scanViolation(scanner, err, ViolationSize, &rep)
+		}
+
+		return rep, nil
+	}
+
+	rep.M, rep.N, rep.L = M, N, L
+
+	if t.isCoordinate() {
+		err = validateCoordinateData(scanner, t, vo, &rep)
+	} else {
+		err = validateArrayData(scanner, t, &rep)
+	}
+
+	return rep, err
+}
+
+// validateCoordinateData validates the data section of a file in
+// coordinate format. Duplicate entries are detected only if configured by
+// vo, as their detection requires retaining the coordinates of each entry.
+func validateCoordinateData(scanner *lineScanner, t *mmType, vo *validateOptions, rep *Report) error {
+
+	var seen map[[2]int]struct{}
+	if vo.duplicates {
+		seen = make(map[[2]int]struct{})
+	}
+
+	// entries are i, j and v, excepting pattern entries, which have no v
+	// and complex entries, which have both a real and imaginary part
+	n := 3
+	switch {
+	case t.isPattern():
+		n = 2
+	case t.isComplex():
+		n = 4
+	}
+
+	for {
+
+		toks, line, err := scanner.tokens(n)
+		if err == io.EOF {
+			break
+		}
+
+		var pe *ParseError
+		if errors.As(err, &pe) {
+			rep.add(pe.Line, ViolationNumber, "%v", pe.Err)
+			break
+		}
+
+		if err != nil {
+			return scanViolation(scanner, err, ViolationNumber, rep)
+		}
+
+		rep.Entries++
+
+		if rep.Entries == rep.L+1 {
+			rep.add(line, ViolationEntryCount, "more than %d entries", rep.L)
+		}
+
+		i, erri := parseInt(toks[0])
+		j, errj := parseInt(toks[1])
+
+		if erri != nil || errj != nil {
+			rep.add(line, ViolationNumber, "%v", errors.Join(erri, errj))
+			scanner.skipLine()
+			continue
+		}
+
+		if !validateValues(toks[2:], line, rep) {
+			scanner.skipLine()
+			continue
+		}
+
+		if !inBounds(i, j, rep.M, rep.N) {
+			rep.add(line, ViolationIndexRange, "entry (%d, %d) outside %d×%d matrix", i, j, rep.M, rep.N)
+			continue
+		}
+
+		switch {
+
+		case t.isSkew() && i <= j:
+			rep.add(line, ViolationTriangle, "entry (%d, %d) is not below the diagonal of a %s matrix", i, j, t.Symmetry)
+
+		case !t.isGeneral() && i < j:
+			rep.add(line, ViolationTriangle, "entry (%d, %d) is above the diagonal of a %s matrix", i, j, t.Symmetry)
+
+		case t.isHermitian() && i == j && toks[3] != "0":
+			if v, _ := parseFloat(toks[3]); v != 0 {
+				rep.add(line, ViolationTriangle, "diagonal entry (%d, %d) of a hermitian matrix is not real", i, j)
+			}
+		}
+
+		if seen == nil {
+			continue
+		}
+
+		// an entry and its transpose are duplicates in a symmetric matrix
+		k := [2]int{i, j}
+		if !t.isGeneral() && i < j {
+			k = [2]int{j, i}
+		}
+
+		if _, ok := seen[k]; ok {
+			rep.add(line, ViolationDuplicate, "duplicate entry at (%d, %d)", i, j)
+		}
+		seen[k] = struct{}{}
+	}
+
+	if rep.Entries < rep.L {
+		rep.add(scanner.line, ViolationEntryCount, "%d entries, expected %d", rep.Entries, rep.L)
+	}
+
+	return scanError(scanner.Scanner)
+}
+
+// validateArrayData validates the data section of a file in array
+// format.
+func validateArrayData(scanner *lineScanner, t *mmType, rep *Report) error {
+
+	rep.L = storedEntries(t.Symmetry, rep.M, rep.N)
+
+	// entries are v, excepting complex entries, which have both a real and
+	// imaginary part
+	n := 1
+	if t.isComplex() {
+		n = 2
+	}
+
+	// (i, j) tracks the position of each entry in column major order
+	var i, j int
+	if t.isSkew() {
+		i = 1
+	}
+
+	for {
+
+		toks, line, err := scanner.tokens(n)
+		if err == io.EOF {
+			break
+		}
+
+		var pe *ParseError
+		if errors.As(err, &pe) {
+			rep.add(pe.Line, ViolationNumber, "%v", pe.Err)
+			break
+		}
+
+		if err != nil {
+			return scanViolation(scanner, err, ViolationNumber, rep)
+		}
+
+		rep.Entries++
+
+		if rep.Entries == rep.L+1 {
+			rep.add(line, ViolationEntryCount, "more than %d entries", rep.L)
+		}
+
+		if validateValues(toks, line, rep) && t.isHermitian() && i == j {
+			if v, _ := parseFloat(toks[1]); v != 0 {
+				rep.add(line, ViolationTriangle, "diagonal entry (%d, %d) of a hermitian matrix is not real", i+1, j+1)
+			}
+		}
+
+		if i++; i == rep.M {
+			j++
+			i = j
+			if t.isSkew() {
+				i++
+			}
+		}
+	}
+
+	if rep.Entries < rep.L {
+		rep.add(scanner.line, ViolationEntryCount, "%d entries, expected %d", rep.Entries, rep.L)
+	}
+
+	return scanError(scanner.Scanner)
+}
+
+// scanViolation records a line longer than the buffer of the scanner, at
+// which scanning stops, as a violation of kind, and otherwise returns err.
+func scanViolation(scanner *lineScanner, err error, kind ViolationKind, rep *Report) error {
+
+	if !errors.Is(scanner.Err(), bufio.ErrTooLong) {
+		return err
+	}
+
+	rep.add(scanner.line+1, kind, "line exceeds %d bytes", maxScanTokenSize)
+
+	return nil
+}
+
+// validateValues validates the value tokens of an entry, reporting
+// whether each is a number.
+func validateValues(toks []string, line int, rep *Report) bool {
+
+	var ok bool = true
+
+	for _, tok := range toks {
+
+		v, err := parseFloat(tok)
+
+		switch {
+
+		case err != nil:
+			rep.add(line, ViolationNumber, "%v", err)
+			ok = false
+
+		case math.IsNaN(v) || math.IsInf(v, 0):
+			rep.add(line, ViolationNaN, "value %q is not finite", tok)
+		}
+	}
+
+	return ok
+}
@@ -0,0 +1,186 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTestdata(t *testing.T) {
+
+	for i, k := range supported {
+
+		name := fmt.Sprintf("mmtype-%02d.mtx", i)
+
+		f, err := os.Open(filepath.Join("testdata", name))
+		assert.Nil(t, err)
+		defer f.Close()
+
+		rep, err := Validate(f)
+		assert.Nil(t, err)
+
+		// hermitian testdata have non-zero imaginary parts on the diagonal
+		if k.isHermitian() {
+			assert.False(t, rep.Valid(), name)
+			for _, v := range rep.Violations {
+				assert.Equal(t, ViolationTriangle, v.Kind, name)
+			}
+			continue
+		}
+
+		assert.True(t, rep.Valid(), "%s: %v", name, rep.Violations)
+		assert.Equal(t, rep.L, rep.Entries, name)
+	}
+}
+
+func TestValidate(t *testing.T) {
+
+	text := `%%MatrixMarket matrix coordinate real symmetric
+% comment
+3 3 6
+1 1 1
+2 x 1
+1 3 1
+2 1 nan
+4 1 1
+2 1 2
+3 3 inf
+`
+
+	rep, err := Validate(strings.NewReader(text), ValidateDuplicates())
+	assert.Nil(t, err)
+
+	assert.Equal(t, "coordinate", rep.Format)
+	assert.Equal(t, "symmetric", rep.Symmetry)
+	assert.Equal(t, []int{3, 3, 6, 7}, []int{rep.M, rep.N, rep.L, rep.Entries})
+
+	assert.Equal(t, []Violation{
+		{Line: 5, Kind: ViolationNumber, Msg: `error while scanning matrix input: invalid integer "x"`},
+		{Line: 6, Kind: ViolationTriangle, Msg: "entry (1, 3) is above the diagonal of a symmetric matrix"},
+		{Line: 7, Kind: ViolationNaN, Msg: `value "nan" is not finite`},
+		{Line: 8, Kind: ViolationIndexRange, Msg: "entry (4, 1) outside 3×3 matrix"},
+		{Line: 9, Kind: ViolationDuplicate, Msg: "duplicate entry at (2, 1)"},
+		{Line: 10, Kind: ViolationEntryCount, Msg: "more than 6 entries"},
+		{Line: 10, Kind: ViolationNaN, Msg: `value "inf" is not finite`},
+	}, rep.Violations)
+	assert.Equal(t, 7, rep.Total)
+	assert.False(t, rep.Valid())
+
+	// duplicates are not detected by default
+	rep, err = Validate(strings.NewReader(text))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 6, rep.Total)
+	for _, v := range rep.Violations {
+		assert.NotEqual(t, ViolationDuplicate, v.Kind)
+	}
+}
+
+func TestValidateArray(t *testing.T) {
+
+	text := "%%MatrixMarket matrix array real skew-symmetric\n3 3\n1\n2\n"
+
+	rep, err := Validate(strings.NewReader(text))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []Violation{
+		{Line: 4, Kind: ViolationEntryCount, Msg: "2 entries, expected 3"},
+	}, rep.Violations)
+
+	text = "%%MatrixMarket matrix array complex hermitian\n2 2\n1 0 2 3\n4 5\n"
+
+	rep, err = Validate(strings.NewReader(text))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []Violation{
+		{Line: 4, Kind: ViolationTriangle, Msg: "diagonal entry (2, 2) of a hermitian matrix is not real"},
+	}, rep.Violations)
+}
+
+func TestValidateHeader(t *testing.T) {
+
+	c := map[string]Violation{
+		"":                        {1, ViolationBanner, ErrNoHeader.Error()},
+		"%%MatrixMarket matrix\n": {1, ViolationBanner, ErrPrematureEOF.Error()},
+		"%%NotMarket matrix array real general\n":                                              {1, ViolationBanner, ErrNoHeader.Error()},
+		"%%MatrixMarket matrix array pattern general\n":                                        {1, ViolationUnsupportedType, "unsupported matrix type"},
+		"%%MatrixMarket matrix array real general\n":                                           {1, ViolationSize, ErrPrematureEOF.Error()},
+		"%%MatrixMarket matrix array real general\n2\n":                                        {2, ViolationSize, ErrInputScanError.Error()},
+		"%%MatrixMarket matrix array real symmetric\n2 3\n":                                    {2, ViolationSize, ErrInvalidSize.Error()},
+		"%%MatrixMarket matrix coordinate real general\n% overflow\n9223372036854775807 2 1\n": {3, ViolationSize, ErrLimitExceeded.Error()},
+	}
+
+	for text, want := range c {
+
+		rep, err := Validate(strings.NewReader(text))
+		assert.Nil(t, err)
+
+		if assert.Len(t, rep.Violations, 1, text) {
+			assert.Equal(t, want.Line, rep.Violations[0].Line, text)
+			assert.Equal(t, want.Kind, rep.Violations[0].Kind, text)
+			assert.True(t, strings.HasPrefix(rep.Violations[0].Msg, want.Msg), "%s: %s", text, rep.Violations[0].Msg)
+		}
+	}
+}
+
+func TestValidateMaxViolations(t *testing.T) {
+
+	var b strings.Builder
+	b.WriteString("%%MatrixMarket matrix coordinate real general\n1 1 1\n")
+	for i := 0; i < 2*MaxViolations; i++ {
+		b.WriteString("2 2 1\n")
+	}
+
+	rep, err := Validate(strings.NewReader(b.String()))
+	assert.Nil(t, err)
+
+	assert.Len(t, rep.Violations, MaxViolations)
+	assert.Equal(t, 2*MaxViolations+1, rep.Total)
+}
+
+func TestValidateLongLine(t *testing.T) {
+
+	long := strings.Repeat("1", maxScanTokenSize+1)
+
+	for _, test := range []struct {
+		text string
+		want Violation
+	}{
+		{"%" + long + "\n", Violation{1, ViolationBanner, ""}},
+		{"%%MatrixMarket matrix coordinate real general\n%" + long + "\n", Violation{2, ViolationSize, ""}},
+		{"%%MatrixMarket matrix coordinate real general\n2 2 2\n1 1 1\n2 2 " + long + "\n", Violation{4, ViolationNumber, ""}},
+		{"%%MatrixMarket matrix array real general\n2 1\n1\n" + long + "\n", Violation{4, ViolationNumber, ""}},
+	} {
+
+		rep, err := Validate(strings.NewReader(test.text))
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		if assert.Len(t, rep.Violations, 1) {
+			assert.Equal(t, test.want.Line, rep.Violations[0].Line)
+			assert.Equal(t, test.want.Kind, rep.Violations[0].Kind)
+			assert.Contains(t, rep.Violations[0].Msg, "line exceeds")
+		}
+	}
+}
+
+func TestValidateReadError(t *testing.T) {
+
+	_, err := Validate(iotest.ErrReader(io.ErrUnexpectedEOF))
+	assert.True(t, errors.Is(err, ErrInputScanError))
+}
+
+func TestViolationString(t *testing.T) {
+
+	v := Violation{Line: 3, Kind: ViolationDuplicate, Msg: "duplicate entry at (1, 1)"}
+	assert.Equal(t, "line 3: duplicate: duplicate entry at (1, 1)", v.String())
+	assert.Equal(t, "ViolationKind(99)", ViolationKind(99).String())
+}
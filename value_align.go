@@ -190,6 +190,53 @@ func (a *intTripletAligner) Fit(base int) func(i, j, v int) {
 	}
 }
 
+type indexAligner struct {
+	row intAligner
+	col intAligner
+}
+
+func (a indexAligner) Append(dst []byte, i, j int) []byte {
+	dst = a.row.Append(dst, i+1, 10)
+	dst = append(dst, ' ')
+	dst = a.col.Append(dst, j+1, 10)
+	return dst
+}
+
+func (a *indexAligner) Fit() func(i, j int) {
+	return func(i, j int) {
+		a.row.fit(i+1, 10)
+		a.col.fit(j+1, 10)
+	}
+}
+
+type tensorAligner struct {
+	idx []intAligner
+	val cmplxAligner
+}
+
+func (a tensorAligner) AppendIndex(dst []byte, idx []int) []byte {
+	for k, i := range idx {
+		if k > 0 {
+			dst = append(dst, ' ')
+		}
+		dst = a.idx[k].Append(dst, i+1, 10)
+	}
+	return dst
+}
+
+func (a *tensorAligner) Fit(fmt byte, p int, bitSize int) func(idx []int, v complex128) {
+	var buf = make([]byte, 0, bitSize)
+	return func(idx []int, v complex128) {
+		for len(a.idx) < len(idx) {
+			a.idx = append(a.idx, 0)
+		}
+		for k, i := range idx {
+			a.idx[k].fit(i+1, 10)
+		}
+		a.val.fit(buf, v, fmt, p, bitSize)
+	}
+}
+
 // characteristic counts the number of characters to the left of the decimal,
 // always adding one to account for a potential sign.  This function is only
 // useful when formatting in decimal point notation (%f/%F); i.e., will return